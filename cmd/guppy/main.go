@@ -2,19 +2,29 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/jaredhaight/guppy/internal/config"
 	"github.com/jaredhaight/guppy/internal/util"
 	"github.com/jaredhaight/guppy/pkg/applier"
+	"github.com/jaredhaight/guppy/pkg/backup"
 	"github.com/jaredhaight/guppy/pkg/checksum"
+	"github.com/jaredhaight/guppy/pkg/health"
+	"github.com/jaredhaight/guppy/pkg/hooks"
+	"github.com/jaredhaight/guppy/pkg/manifest"
 	"github.com/jaredhaight/guppy/pkg/repository"
+	"github.com/jaredhaight/guppy/pkg/signature"
+	"github.com/jaredhaight/guppy/pkg/version"
 	"github.com/spf13/cobra"
 )
 
@@ -24,15 +34,40 @@ var (
 	cfg          *config.Config
 	debug        bool
 	intervalFlag string
+	jsonFlag     bool
+)
+
+// Exit codes
+const (
+	exitError             = 1
+	exitHealthCheckFailed = 2 // The update was rolled back because its health check failed
 )
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		var healthErr *healthCheckError
+		if errors.As(err, &healthErr) {
+			os.Exit(exitHealthCheckFailed)
+		}
+		os.Exit(exitError)
 	}
 }
 
+// healthCheckError reports an update that was rolled back after its health check failed
+type healthCheckError struct {
+	Version string
+	Err     error
+}
+
+func (e *healthCheckError) Error() string {
+	return fmt.Sprintf("health check failed for version %s, previous version restored: %v", e.Version, e.Err)
+}
+
+func (e *healthCheckError) Unwrap() error {
+	return e.Err
+}
+
 // debugLog prints a debug message if debug mode is enabled
 func debugLog(format string, args ...interface{}) {
 	if debug {
@@ -50,6 +85,9 @@ var rootCmd = &cobra.Command{
 		if err := loadConfig(); err != nil {
 			return err
 		}
+		if err := recoverInterruptedUpdate(); err != nil {
+			return err
+		}
 
 		repo, err := createRepository()
 		if err != nil {
@@ -117,6 +155,74 @@ var checkCmd = &cobra.Command{
 	},
 }
 
+var installCmd = &cobra.Command{
+	Use:   "install <version>",
+	Short: "Install a specific version, even if it is older than the current one",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return err
+		}
+		if err := recoverInterruptedUpdate(); err != nil {
+			return err
+		}
+
+		repo, err := createRepository()
+		if err != nil {
+			return err
+		}
+
+		return installVersion(repo, args[0])
+	},
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Restore the previous version from backup",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return err
+		}
+		if err := recoverInterruptedUpdate(); err != nil {
+			return err
+		}
+
+		return rollback()
+	},
+}
+
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the files installed by the current version",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return err
+		}
+		if err := recoverInterruptedUpdate(); err != nil {
+			return err
+		}
+
+		return uninstall()
+	},
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available releases",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return err
+		}
+
+		repo, err := createRepository()
+		if err != nil {
+			return err
+		}
+
+		return listReleases(repo, os.Stdout, jsonFlag)
+	},
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show guppy version",
@@ -221,7 +327,7 @@ var initCmd = &cobra.Command{
 			fmt.Println("  - repository.token: GitHub personal access token (for private repos or higher rate limits)")
 			fmt.Println("  - repository.asset_name: Specific asset name to download")
 			fmt.Println("  - current_version: Current version (will be auto-updated after first update)")
-			fmt.Println("  - applier: Type of applier (binary or archive)")
+			fmt.Println("  - applier: Type of applier (binary, archive or versioned)")
 			fmt.Println("  - download_dir: Directory for temporary downloads")
 		} else { // http
 			fmt.Println("\nPlease edit the config file and update the following fields:")
@@ -229,7 +335,7 @@ var initCmd = &cobra.Command{
 			fmt.Println("  - target_path: Path where the binary should be installed")
 			fmt.Println("\nOptional fields:")
 			fmt.Println("  - current_version: Current version (will be auto-updated after first update)")
-			fmt.Println("  - applier: Type of applier (binary or archive)")
+			fmt.Println("  - applier: Type of applier (binary, archive or versioned)")
 			fmt.Println("  - download_dir: Directory for temporary downloads")
 			fmt.Println("\nYour releases.json file should be a JSON array with this format:")
 			fmt.Println(`  [
@@ -251,7 +357,13 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "enable debug logging")
 	rootCmd.Flags().StringVarP(&intervalFlag, "interval", "i", "", "check for updates at regular intervals (e.g., 15m, 1h, 1d, or HH:MM:SS)")
 
+	listCmd.Flags().BoolVar(&jsonFlag, "json", false, "output releases as JSON")
+
 	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(installCmd)
+	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(uninstallCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(initCmd)
 }
@@ -280,10 +392,68 @@ func createRepository() (repository.Repository, error) {
 		if cfg.Repository.AssetName != "" {
 			repo.SetAssetName(cfg.Repository.AssetName)
 		}
+		repo.SetAPIURL(cfg.Repository.APIURL)
+		repo.SetChannel(cfg.Repository.Channel)
 		repo.SetDebug(debug)
 		return repo, nil
 	case "http":
 		repo := repository.NewHTTPRepository(cfg.Repository.URL)
+		repo.SetMirrors(cfg.Repository.Mirrors)
+		repo.SetMirrorStrategy(cfg.Repository.MirrorStrategy)
+		repo.SetChannel(cfg.Repository.Channel)
+		repo.SetDebug(debug)
+		return repo, nil
+	case "gitlab":
+		repo := repository.NewGitLabRepository(
+			cfg.Repository.BaseURL,
+			cfg.Repository.Owner+"/"+cfg.Repository.Repo,
+			cfg.Repository.Token,
+		)
+		if cfg.Repository.AssetName != "" {
+			repo.SetAssetName(cfg.Repository.AssetName)
+		}
+		repo.SetTokenType(cfg.Repository.TokenType)
+		repo.SetDebug(debug)
+		return repo, nil
+	case "gitea":
+		repo := repository.NewGiteaRepository(
+			cfg.Repository.BaseURL,
+			cfg.Repository.Owner,
+			cfg.Repository.Repo,
+			cfg.Repository.Token,
+		)
+		if cfg.Repository.AssetName != "" {
+			repo.SetAssetName(cfg.Repository.AssetName)
+		}
+		repo.SetDebug(debug)
+		return repo, nil
+	case "s3":
+		repo := repository.NewS3Repository(
+			cfg.Repository.BaseURL,
+			cfg.Repository.Bucket,
+			cfg.Repository.Region,
+			cfg.Repository.KeyPattern,
+		)
+		// Fall back to the standard AWS environment variables so secrets can stay out of the config file
+		if cfg.Repository.AccessKeyID != "" {
+			repo.SetCredentials(cfg.Repository.AccessKeyID, cfg.Repository.SecretAccessKey, "")
+		} else {
+			repo.SetCredentials(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN"))
+		}
+		repo.SetDebug(debug)
+		return repo, nil
+	case "file":
+		repo := repository.NewFileRepository(cfg.Repository.Path, cfg.Repository.KeyPattern)
+		repo.SetDebug(debug)
+		return repo, nil
+	case "oci":
+		repo := repository.NewOCIRepository(
+			cfg.Repository.BaseURL,
+			cfg.Repository.Repo,
+			cfg.Repository.Token,
+		)
+		repo.SetAssetName(cfg.Repository.AssetName)
+		repo.SetMediaType(cfg.Repository.MediaType)
 		repo.SetDebug(debug)
 		return repo, nil
 	default:
@@ -308,6 +478,11 @@ func checkForUpdates(repo repository.Repository) error {
 
 	fmt.Printf("Current version: %s\n", cfg.CurrentVersion)
 
+	if cfg.PinnedVersion != "" {
+		fmt.Printf("Pinned to version %s; updates are held until the pin is removed\n", cfg.PinnedVersion)
+		return nil
+	}
+
 	isNewer, err := repo.CompareVersions(cfg.CurrentVersion, latest.Version)
 	if err != nil {
 		return fmt.Errorf("error comparing versions: %w", err)
@@ -323,8 +498,89 @@ func checkForUpdates(repo repository.Repository) error {
 	return nil
 }
 
-// performUpdate checks for and applies updates. Returns true if an update was applied, false otherwise.
+// releaseListEntry is a release as printed by guppy list --json
+type releaseListEntry struct {
+	Version     string     `json:"version"`
+	ReleaseDate *time.Time `json:"release_date,omitempty"`
+	Asset       string     `json:"asset"`
+	DownloadURL string     `json:"download_url"`
+	HasChecksum bool       `json:"has_checksum"`
+	Checksum    string     `json:"checksum,omitempty"`
+}
+
+// listReleases prints the available releases as a table or as JSON
+func listReleases(repo repository.Repository, w io.Writer, asJSON bool) error {
+	releases, err := repo.ListReleases()
+	if err != nil {
+		return fmt.Errorf("error listing releases: %w", err)
+	}
+
+	if asJSON {
+		entries := make([]releaseListEntry, 0, len(releases))
+		for _, rel := range releases {
+			entry := releaseListEntry{
+				Version:     rel.Version,
+				Asset:       rel.FileName,
+				DownloadURL: rel.DownloadURL,
+				HasChecksum: hasChecksum(rel),
+				Checksum:    rel.Checksum,
+			}
+			if !rel.ReleaseDate.IsZero() {
+				date := rel.ReleaseDate
+				entry.ReleaseDate = &date
+			}
+			entries = append(entries, entry)
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	if len(releases) == 0 {
+		_, _ = fmt.Fprintln(w, "No releases found")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "VERSION\tDATE\tASSET\tCHECKSUM")
+	for _, rel := range releases {
+		date := "-"
+		if !rel.ReleaseDate.IsZero() {
+			date = rel.ReleaseDate.Format("2006-01-02")
+		}
+		checksumColumn := "no"
+		if hasChecksum(rel) {
+			checksumColumn = "yes"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", rel.Version, date, rel.FileName, checksumColumn)
+	}
+	return tw.Flush()
+}
+
+// hasChecksum reports whether a release has a checksum, either given directly
+// or in a checksum file that is read when the release is downloaded
+func hasChecksum(rel *repository.Release) bool {
+	return rel.Checksum != "" || len(rel.ChecksumFiles) > 0
+}
+
+// performUpdate checks for and applies updates
+// With a pinned version, the pinned release is installed instead of the latest one
 func performUpdate(repo repository.Repository) error {
+	if cfg.PinnedVersion != "" {
+		if sameVersion(cfg.CurrentVersion, cfg.PinnedVersion) {
+			fmt.Printf("✓ Pinned to version %s, not checking for updates\n", cfg.PinnedVersion)
+			return nil
+		}
+
+		fmt.Printf("Pinned to version %s, installing it...\n", cfg.PinnedVersion)
+		release, err := repo.GetRelease(cfg.PinnedVersion)
+		if err != nil {
+			return fmt.Errorf("error getting pinned release %s: %w", cfg.PinnedVersion, err)
+		}
+		return installRelease(repo, release)
+	}
+
 	fmt.Println("Checking for updates...")
 	latest, err := repo.GetLatestRelease()
 	if err != nil {
@@ -343,58 +599,632 @@ func performUpdate(repo repository.Repository) error {
 		}
 	}
 
-	fmt.Printf("Downloading version %s...\n", latest.Version)
+	return installRelease(repo, latest)
+}
+
+// installVersion installs an exact version, allowing downgrades
+func installVersion(repo repository.Repository, v string) error {
+	release, err := repo.GetRelease(v)
+	if err != nil {
+		return fmt.Errorf("error getting release %s: %w", v, err)
+	}
+
+	if sameVersion(cfg.CurrentVersion, release.Version) {
+		fmt.Printf("✓ Version %s is already installed\n", release.Version)
+		return nil
+	}
+
+	if cfg.CurrentVersion != "" {
+		if isNewer, err := repo.CompareVersions(cfg.CurrentVersion, release.Version); err == nil && !isNewer {
+			fmt.Printf("Downgrading from %s to %s\n", cfg.CurrentVersion, release.Version)
+		}
+	}
+
+	if cfg.PinnedVersion != "" && !sameVersion(cfg.PinnedVersion, release.Version) {
+		fmt.Printf("Warning: pinned_version is %s; the next scheduled update will return to it\n", cfg.PinnedVersion)
+	}
+
+	return installRelease(repo, release)
+}
+
+// sameVersion reports whether two version strings refer to the same version, e.g. v1.2.0 and 1.2.0
+func sameVersion(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	if cmp, err := version.CompareStrings(a, b); err == nil {
+		return cmp == 0
+	}
+	return a == b
+}
+
+// installRelease downloads, verifies and applies a release, then records it as the current version
+// If any step fails, the on_failure hooks run before the error is returned
+func installRelease(repo repository.Repository, release *repository.Release) error {
+	downloadPath := filepath.Join(cfg.DownloadDir, release.FileName)
+	debugLog("Computed download path: %s", downloadPath)
+
+	hookCfg := cfg.Hooks
+	if hookCfg == nil {
+		hookCfg = &config.HooksConfig{}
+	}
+	runner := newHookRunner(hookCfg, release, downloadPath)
+
+	err := applyRelease(repo, release, downloadPath, hookCfg, runner)
+	if err != nil && len(hookCfg.OnFailure) > 0 {
+		fmt.Println("Running on_failure hooks...")
+		runner.Env = append(runner.Env, "GUPPY_ERROR="+err.Error())
+		if hookErr := runner.Run("on_failure", hookCfg.OnFailure); hookErr != nil {
+			fmt.Printf("Warning: %v\n", hookErr)
+		}
+	}
+	return err
+}
+
+// applyRelease runs each step of installRelease and its pre and post hooks
+func applyRelease(repo repository.Repository, release *repository.Release, downloadPath string, hookCfg *config.HooksConfig, runner *hooks.Runner) error {
+	if err := runHooks(runner, "pre_download", hookCfg.PreDownload); err != nil {
+		return fmt.Errorf("aborting update: %w", err)
+	}
+
+	fmt.Printf("Downloading version %s...\n", release.Version)
 
 	// Create download directory
 	if err := os.MkdirAll(cfg.DownloadDir, 0755); err != nil {
 		return fmt.Errorf("error creating download directory: %w", err)
 	}
 
-	downloadPath := filepath.Join(cfg.DownloadDir, latest.FileName)
-	debugLog("Computed download path: %s", downloadPath)
-	if err := repo.Download(latest, downloadPath); err != nil {
+	if err := repo.Download(release, downloadPath); err != nil {
 		return fmt.Errorf("error downloading release: %w", err)
 	}
 
 	fmt.Printf("Downloaded to: %s\n", downloadPath)
 
 	// Verify checksum if provided
-	if latest.Checksum != "" {
+	if release.Checksum != "" {
 		fmt.Println("Verifying checksum...")
-		valid, err := checksum.VerifySHA256(downloadPath, latest.Checksum)
+		valid, err := checksum.Verify(downloadPath, release.Checksum)
 		if err != nil {
+			_ = os.Remove(downloadPath)
 			return fmt.Errorf("error verifying checksum: %w", err)
 		}
 		if !valid {
+			_ = os.Remove(downloadPath)
 			return fmt.Errorf("checksum verification failed - file may be corrupted")
 		}
 		fmt.Println("✓ Checksum verified")
 	}
 
+	// Verify the release signature if keys are trusted
+	if cfg.Verify != nil && len(cfg.Verify.PublicKeys) > 0 {
+		fmt.Println("Verifying signature...")
+		keyID, err := verifySignature(repo, release, downloadPath)
+		if err != nil {
+			_ = os.Remove(downloadPath)
+			return fmt.Errorf("refusing to apply update: %w", err)
+		}
+		fmt.Printf("✓ Signature verified (key %s)\n", keyID)
+	}
+
+	// Verify the OpenPGP signature if a keyring is trusted
+	if cfg.Verify != nil && cfg.Verify.Keyring != "" {
+		fmt.Println("Verifying OpenPGP signature...")
+		fingerprint, err := verifyPGPSignature(repo, release, downloadPath)
+		if err != nil {
+			_ = os.Remove(downloadPath)
+			return fmt.Errorf("refusing to apply update: %w", err)
+		}
+		fmt.Printf("✓ OpenPGP signature verified (key fingerprint %s)\n", fingerprint)
+	}
+
+	// Verify the Sigstore bundle if a trusted root is configured
+	if cfg.Verify != nil && cfg.Verify.TrustedRoot != "" {
+		fmt.Println("Verifying Sigstore bundle...")
+		identity, err := verifySigstoreBundle(repo, release, downloadPath)
+		if err != nil {
+			_ = os.Remove(downloadPath)
+			return fmt.Errorf("refusing to apply update: %w", err)
+		}
+		fmt.Printf("✓ Sigstore bundle verified (identity %s, issuer %s)\n", identity, cfg.Verify.CertificateOIDCIssuer)
+	}
+
+	app, err := newApplier(release.Version)
+	if err != nil {
+		return err
+	}
+	if archiveApplier, ok := app.(*applier.ArchiveApplier); ok {
+		archiveApplier.PreviousFiles = previousFiles()
+	}
+
+	if err := runHooks(runner, "pre_apply", hookCfg.PreApply); err != nil {
+		return fmt.Errorf("aborting update: %w", err)
+	}
+
 	// Apply the update
 	fmt.Printf("Applying update to %s...\n", cfg.TargetPath)
 
-	var app applier.Applier
-	switch cfg.Applier {
-	case "binary":
-		app = applier.NewBinaryApplier()
-	case "archive":
-		app = applier.NewArchiveApplier()
-	default:
-		return fmt.Errorf("unknown applier type: %s", cfg.Applier)
+	// Back up the files the update replaces
+	// A failed health check needs a backup to restore, even with backups disabled
+	var gen *backup.Generation
+	store := backupStore()
+	if cfg.Backups > 0 || cfg.HealthCheck != nil {
+		targets, err := app.Targets(downloadPath, cfg.TargetPath)
+		if err != nil {
+			return fmt.Errorf("error listing files to back up: %w", err)
+		}
+		gen, err = store.Create(cfg.CurrentVersion, targets)
+		if err != nil {
+			return fmt.Errorf("error backing up current version: %w", err)
+		}
+		debugLog("Backed up %d file(s) to %s", len(gen.Entries), gen.Dir())
 	}
 
 	if err := app.Apply(downloadPath, cfg.TargetPath); err != nil {
+		if gen != nil {
+			fmt.Println("Restoring previous version from backup...")
+			if restoreErr := store.Restore(gen); restoreErr != nil {
+				return fmt.Errorf("error applying update: %w (restoring backup also failed: %v)", err, restoreErr)
+			}
+		}
 		return fmt.Errorf("error applying update: %w", err)
 	}
 
 	fmt.Println("✓ Update applied successfully!")
 
+	if archiveApplier, ok := app.(*applier.ArchiveApplier); ok {
+		reportPreserved(archiveApplier)
+		if len(archiveApplier.Removed) > 0 {
+			fmt.Printf("Removed %d file(s) no longer in the release\n", len(archiveApplier.Removed))
+		}
+	}
+
+	if err := runHooks(runner, "post_apply", hookCfg.PostApply); err != nil {
+		if gen != nil {
+			fmt.Println("Restoring previous version from backup...")
+			if restoreErr := store.Restore(gen); restoreErr != nil {
+				return fmt.Errorf("%w (restoring backup also failed: %v)", err, restoreErr)
+			}
+		}
+		return err
+	}
+
+	if cfg.HealthCheck != nil {
+		fmt.Println("Running health check...")
+		if err := newHealthCheck(cfg.HealthCheck).Run(); err != nil {
+			fmt.Printf("✗ Health check failed: %v\n", err)
+			fmt.Println("Restoring previous version from backup...")
+			if restoreErr := store.Restore(gen); restoreErr != nil {
+				return fmt.Errorf("health check failed: %w (restoring backup also failed: %v)", err, restoreErr)
+			}
+			return &healthCheckError{Version: release.Version, Err: err}
+		}
+		fmt.Println("✓ Health check passed")
+	}
+
+	// Backups kept only for the health check are removed here when backups is 0
+	if gen != nil {
+		if err := store.Prune(); err != nil {
+			fmt.Printf("Warning: Could not remove old backups: %v\n", err)
+		}
+	}
+
+	if versioned, ok := app.(*applier.VersionedApplier); ok {
+		keep, err := backedUpReleases(store)
+		if err == nil {
+			err = versioned.Prune(cfg.TargetPath, keep)
+		}
+		if err != nil {
+			fmt.Printf("Warning: Could not remove old releases: %v\n", err)
+		}
+	}
+
+	if err := saveManifest(newManifest(app, release.Version)); err != nil {
+		fmt.Printf("Warning: Could not save install manifest: %v\n", err)
+	}
+
 	// Update current version in config
-	cfg.CurrentVersion = latest.Version
+	cfg.CurrentVersion = release.Version
 	if err := cfg.Save(cfgFile); err != nil {
 		fmt.Printf("Warning: Could not save updated version to config: %v\n", err)
 	}
 
 	return nil
 }
+
+// backedUpReleases returns the releases that the backups of the versioned
+// applier's current link point at, so pruning never breaks a rollback
+func backedUpReleases(store *backup.Store) ([]string, error) {
+	generations, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	// Backups record absolute paths
+	current, err := filepath.Abs(filepath.Join(cfg.TargetPath, "current"))
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []string
+	for _, gen := range generations {
+		for _, entry := range gen.Entries {
+			if entry.Path == current && entry.Link != "" {
+				releases = append(releases, filepath.Base(entry.Link))
+			}
+		}
+	}
+	return releases, nil
+}
+
+// newApplier creates the configured applier for installing version
+func newApplier(version string) (applier.Applier, error) {
+	switch cfg.Applier {
+	case "binary":
+		binaryApplier := applier.NewBinaryApplier()
+		binaryApplier.BinaryPath = cfg.BinaryPath
+		return binaryApplier, nil
+	case "archive":
+		return newArchiveApplier(), nil
+	case "versioned":
+		versionedApplier := applier.NewVersionedApplier(version, cfg.KeepReleases)
+		versionedApplier.Archive = newArchiveApplier()
+		return versionedApplier, nil
+	default:
+		return nil, fmt.Errorf("unknown applier type: %s", cfg.Applier)
+	}
+}
+
+// recoverInterruptedUpdate rolls back an update that guppy was interrupted
+// while applying, so the target is never left half updated
+func recoverInterruptedUpdate() error {
+	if cfg.TargetPath == "" {
+		return nil
+	}
+	app, err := newApplier(cfg.CurrentVersion)
+	if err != nil {
+		// An unknown applier is reported when an update is applied
+		return nil
+	}
+	recoverer, ok := app.(applier.Recoverer)
+	if !ok {
+		return nil
+	}
+
+	recovered, err := recoverer.Recover(cfg.TargetPath)
+	if err != nil {
+		return fmt.Errorf("error recovering from interrupted update: %w", err)
+	}
+	if recovered {
+		fmt.Printf("✓ Rolled back an interrupted update to %s\n", cfg.TargetPath)
+	}
+	return nil
+}
+
+// newArchiveApplier creates an archive applier with the configured extraction filters
+func newArchiveApplier() *applier.ArchiveApplier {
+	archiveApplier := applier.NewArchiveApplier()
+	archiveApplier.StripComponents = cfg.StripComponents
+	archiveApplier.Include = cfg.Include
+	archiveApplier.Exclude = cfg.Exclude
+	archiveApplier.Preserve = cfg.Preserve
+	archiveApplier.PreserveMode = cfg.PreserveMode
+	return archiveApplier
+}
+
+// reportPreserved lists the files the archive applier left in place
+func reportPreserved(archiveApplier *applier.ArchiveApplier) {
+	if len(archiveApplier.Preserved) == 0 {
+		return
+	}
+
+	fmt.Printf("Preserved %d modified file(s):\n", len(archiveApplier.Preserved))
+	for _, name := range archiveApplier.Preserved {
+		if archiveApplier.PreserveMode == applier.PreserveKeep {
+			fmt.Printf("  %s (new version discarded)\n", name)
+		} else {
+			fmt.Printf("  %s (new version written to %s%s)\n", name, name, applier.PreservedSuffix)
+		}
+	}
+}
+
+// newHookRunner creates a runner that passes the update details to hook commands
+func newHookRunner(hookCfg *config.HooksConfig, release *repository.Release, downloadPath string) *hooks.Runner {
+	env := []string{
+		"GUPPY_OLD_VERSION=" + cfg.CurrentVersion,
+		"GUPPY_NEW_VERSION=" + release.Version,
+		"GUPPY_TARGET_PATH=" + cfg.TargetPath,
+		"GUPPY_DOWNLOAD_PATH=" + downloadPath,
+	}
+
+	// The configuration has already been validated, so the timeout parses
+	var timeout time.Duration
+	if hookCfg.Timeout != "" {
+		timeout, _ = util.ParseInterval(hookCfg.Timeout)
+	}
+	return hooks.NewRunner(env, timeout)
+}
+
+// runHooks runs the commands configured for a hook, if any
+func runHooks(runner *hooks.Runner, name string, commands []string) error {
+	if len(commands) == 0 {
+		return nil
+	}
+	fmt.Printf("Running %s hooks...\n", name)
+	return runner.Run(name, commands)
+}
+
+// verifySignature downloads the detached signature of a release and checks it
+// over the downloaded file, returning the ID of the trusted key that signed it
+func verifySignature(repo repository.Repository, release *repository.Release, downloadPath string) (string, error) {
+	verifier, err := signature.NewVerifier(cfg.Verify.PublicKeys)
+	if err != nil {
+		return "", err
+	}
+
+	sigPath, err := downloadSignature(repo, release, downloadPath, signature.SchemeMinisign)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.Remove(sigPath) }()
+
+	key, err := verifier.VerifyFile(downloadPath, sigPath)
+	if err != nil {
+		return "", err
+	}
+	return key.ID(), nil
+}
+
+// verifyPGPSignature downloads the detached OpenPGP signature of a release and
+// checks it against the keyring, returning the fingerprint of the signing key
+func verifyPGPSignature(repo repository.Repository, release *repository.Release, downloadPath string) (string, error) {
+	verifier, err := signature.LoadKeyring(cfg.Verify.Keyring)
+	if err != nil {
+		return "", err
+	}
+
+	sigPath, err := downloadSignature(repo, release, downloadPath, signature.SchemeOpenPGP)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.Remove(sigPath) }()
+
+	return verifier.VerifyFile(downloadPath, sigPath)
+}
+
+// verifySigstoreBundle downloads the Sigstore bundle of a release and checks it
+// offline against the trusted root, returning the identity that signed it
+func verifySigstoreBundle(repo repository.Repository, release *repository.Release, downloadPath string) (string, error) {
+	verifier, err := cfg.Verify.SigstoreVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	bundlePath, err := downloadSignature(repo, release, downloadPath, signature.SchemeSigstore)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.Remove(bundlePath) }()
+
+	return verifier.VerifyFile(downloadPath, bundlePath)
+}
+
+// downloadSignature fetches a detached signature of a release next to the download
+// The signature files the provider lists for the scheme are tried in turn
+func downloadSignature(repo repository.Repository, release *repository.Release, downloadPath string, scheme string) (string, error) {
+	urls := release.Signatures[scheme]
+	if len(urls) == 0 {
+		return "", fmt.Errorf("no %s signature published for %s", scheme, release.FileName)
+	}
+
+	sigPath := downloadPath + signature.SchemeExtensions(scheme)[0]
+	var lastErr error
+	for _, url := range urls {
+		debugLog("Downloading signature from: %s", url)
+		sigRelease := &repository.Release{
+			Version:     release.Version,
+			DownloadURL: url,
+			FileName:    filepath.Base(sigPath),
+		}
+		if err := repo.Download(sigRelease, sigPath); err != nil {
+			lastErr = err
+			continue
+		}
+		return sigPath, nil
+	}
+	_ = os.Remove(sigPath)
+	return "", fmt.Errorf("no signature found for %s: %w", release.FileName, lastErr)
+}
+
+// newHealthCheck builds a health check from its configuration
+// The configuration has already been validated, so durations parse
+func newHealthCheck(hc *config.HealthCheckConfig) *health.Check {
+	check := &health.Check{
+		Command:          hc.Command,
+		ExpectedExitCode: hc.ExpectedExitCode,
+		URL:              hc.URL,
+		Retries:          hc.Retries,
+	}
+	if hc.Timeout != "" {
+		check.Timeout, _ = util.ParseInterval(hc.Timeout)
+	}
+	if hc.RetryDelay != "" {
+		check.RetryDelay, _ = util.ParseInterval(hc.RetryDelay)
+	}
+	return check
+}
+
+// stateDir returns the directory guppy keeps backups and other state in
+// Defaults to .guppy next to the config file
+func stateDir() string {
+	if cfg.StateDir != "" {
+		return cfg.StateDir
+	}
+	return filepath.Join(filepath.Dir(cfgFile), ".guppy")
+}
+
+// backupStore returns the store holding backups of previous versions
+func backupStore() *backup.Store {
+	return backup.NewStore(filepath.Join(stateDir(), "backups"), cfg.Backups)
+}
+
+// manifestStore returns the store holding the manifest of each installed version
+func manifestStore() *manifest.Store {
+	return manifest.NewStore(filepath.Join(stateDir(), "manifests"))
+}
+
+// previousFiles returns the files the current version installed in the archive
+// extract directory, or nil if they are unknown
+func previousFiles() []string {
+	previous, err := manifestStore().Load(cfg.CurrentVersion)
+	if err != nil {
+		fmt.Printf("Warning: Could not read install manifest: %v\n", err)
+		return nil
+	}
+	// Files installed somewhere else are not ours to remove
+	if previous == nil || previous.Root != filepath.Dir(cfg.TargetPath) {
+		return nil
+	}
+	return previous.Files
+}
+
+// archiveManifestFiles returns the files the archive applier installed, and
+// the SHA256 of each one matching preserve so uninstall can tell whether the
+// user has changed it since
+// Preserved files the user had already changed are left out: guppy did not
+// install their content, so uninstall must not remove them
+func archiveManifestFiles(a *applier.ArchiveApplier, root string) ([]string, map[string]string) {
+	modified := make(map[string]bool, len(a.Preserved))
+	for _, name := range a.Preserved {
+		modified[filepath.ToSlash(name)] = true
+	}
+
+	var files []string
+	var sums map[string]string
+	for _, name := range a.Installed {
+		if modified[name] {
+			continue
+		}
+
+		if a.Preserves(name) {
+			sum, err := checksum.CalculateSHA256(filepath.Join(root, filepath.FromSlash(name)))
+			if err != nil {
+				// A file that cannot be checked is kept on uninstall
+				fmt.Printf("Warning: Could not record checksum of %s: %v\n", name, err)
+				continue
+			}
+			if sums == nil {
+				sums = make(map[string]string)
+			}
+			sums[name] = sum
+		}
+		files = append(files, name)
+	}
+	return files, sums
+}
+
+// newManifest records the files an applier installed for a version
+func newManifest(app applier.Applier, version string) *manifest.Manifest {
+	m := &manifest.Manifest{
+		Version: version,
+		Root:    filepath.Dir(cfg.TargetPath),
+	}
+
+	name := filepath.Base(cfg.TargetPath)
+	switch a := app.(type) {
+	case *applier.ArchiveApplier:
+		m.Files, m.Preserve = archiveManifestFiles(a, m.Root)
+	case *applier.VersionedApplier:
+		// Every release under the target belongs to guppy
+		m.Files = []string{name + "/current"}
+		m.Dirs = []string{name + "/releases"}
+	default:
+		m.Files = []string{name}
+	}
+	return m
+}
+
+// saveManifest saves the manifest for a newly installed version and removes
+// manifests that neither it nor a backup can return to
+func saveManifest(m *manifest.Manifest) error {
+	store := manifestStore()
+	if err := store.Save(m); err != nil {
+		return err
+	}
+
+	generations, err := backupStore().List()
+	if err != nil {
+		return err
+	}
+	keep := []string{m.Version}
+	for _, gen := range generations {
+		keep = append(keep, gen.Version)
+	}
+	return store.Prune(keep)
+}
+
+// uninstall removes the files the current version installed and clears current_version
+// Files guppy did not install are left alone
+func uninstall() error {
+	store := manifestStore()
+	m, err := store.Load(cfg.CurrentVersion)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return fmt.Errorf("no install manifest found for version %q in %s", cfg.CurrentVersion, store.Dir)
+	}
+
+	fmt.Printf("Uninstalling %s from %s...\n", m.Version, m.Root)
+
+	kept, err := m.Uninstall()
+	if err != nil {
+		return fmt.Errorf("error uninstalling: %w", err)
+	}
+	for _, name := range kept {
+		fmt.Printf("Kept %s, which was modified after it was installed\n", name)
+	}
+	if err := store.Remove(m.Version); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	cfg.CurrentVersion = ""
+	if err := cfg.Save(cfgFile); err != nil {
+		fmt.Printf("Warning: Could not clear current version in config: %v\n", err)
+	}
+
+	fmt.Printf("✓ Uninstalled %s\n", m.Version)
+	return nil
+}
+
+// rollback restores the most recent backup and resets current_version to match
+func rollback() error {
+	store := backupStore()
+	gen, err := store.Latest()
+	if err != nil {
+		return fmt.Errorf("error reading backups: %w", err)
+	}
+	if gen == nil {
+		return fmt.Errorf("no backups available in %s", store.Dir)
+	}
+
+	previous := gen.Version
+	if previous == "" {
+		previous = "(unknown version)"
+	}
+	fmt.Printf("Rolling back to %s...\n", previous)
+
+	if err := store.Restore(gen); err != nil {
+		return fmt.Errorf("error restoring backup: %w", err)
+	}
+
+	cfg.CurrentVersion = gen.Version
+	if err := cfg.Save(cfgFile); err != nil {
+		fmt.Printf("Warning: Could not save restored version to config: %v\n", err)
+	}
+
+	fmt.Printf("✓ Rolled back to %s\n", previous)
+	if cfg.PinnedVersion != "" && !sameVersion(cfg.PinnedVersion, gen.Version) {
+		fmt.Printf("Warning: pinned_version is %s; the next scheduled update will return to it\n", cfg.PinnedVersion)
+	}
+	return nil
+}
@@ -1,13 +1,42 @@
 package main
 
 import (
+	"archive/zip"
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
 	"github.com/jaredhaight/guppy/internal/config"
+	"github.com/jaredhaight/guppy/pkg/applier"
+	"github.com/jaredhaight/guppy/pkg/checksum"
+	"github.com/jaredhaight/guppy/pkg/manifest"
 	"github.com/jaredhaight/guppy/pkg/repository"
+	"golang.org/x/crypto/blake2b"
 )
 
 // Mock repository for testing
@@ -18,6 +47,9 @@ type mockRepository struct {
 	compareVersionsErr error
 	downloadErr        error
 	downloadCalled     bool
+	releases           []*repository.Release
+	listReleasesErr    error
+	requestedVersion   string
 }
 
 func (m *mockRepository) GetLatestRelease() (*repository.Release, error) {
@@ -25,9 +57,14 @@ func (m *mockRepository) GetLatestRelease() (*repository.Release, error) {
 }
 
 func (m *mockRepository) GetRelease(version string) (*repository.Release, error) {
+	m.requestedVersion = version
 	return m.latestRelease, m.getLatestReleaseErr
 }
 
+func (m *mockRepository) ListReleases() ([]*repository.Release, error) {
+	return m.releases, m.listReleasesErr
+}
+
 func (m *mockRepository) CompareVersions(current, latest string) (bool, error) {
 	return m.compareVersionsResult, m.compareVersionsErr
 }
@@ -121,6 +158,30 @@ func TestCreateRepository_GitHub(t *testing.T) {
 	}
 }
 
+func TestCreateRepository_GitHubEnterprise(t *testing.T) {
+	cfg = &config.Config{
+		Repository: config.RepositoryConfig{
+			Type:   "github",
+			Owner:  "testowner",
+			Repo:   "testrepo",
+			APIURL: "https://github.example.com/api/v3",
+		},
+	}
+
+	repo, err := createRepository()
+	if err != nil {
+		t.Fatalf("createRepository() failed: %v", err)
+	}
+
+	githubRepo, ok := repo.(*repository.GitHubRepository)
+	if !ok {
+		t.Fatal("createRepository() did not return GitHubRepository")
+	}
+	if githubRepo.APIURL != "https://github.example.com/api/v3" {
+		t.Errorf("APIURL = %s, want https://github.example.com/api/v3", githubRepo.APIURL)
+	}
+}
+
 func TestCreateRepository_HTTP(t *testing.T) {
 	cfg = &config.Config{
 		Repository: config.RepositoryConfig{
@@ -144,6 +205,190 @@ func TestCreateRepository_HTTP(t *testing.T) {
 	}
 }
 
+func TestCreateRepository_HTTPMirrors(t *testing.T) {
+	cfg = &config.Config{
+		Repository: config.RepositoryConfig{
+			Type:           "http",
+			URL:            "https://primary.example.com/releases.json",
+			Mirrors:        []string{"https://mirror.example.com/releases.json"},
+			MirrorStrategy: "ordered",
+		},
+	}
+
+	repo, err := createRepository()
+	if err != nil {
+		t.Fatalf("createRepository() failed: %v", err)
+	}
+
+	httpRepo, ok := repo.(*repository.HTTPRepository)
+	if !ok {
+		t.Fatal("createRepository() did not return HTTPRepository")
+	}
+	if len(httpRepo.Mirrors) != 1 || httpRepo.MirrorStrategy != "ordered" {
+		t.Errorf("Mirrors = %v, MirrorStrategy = %s, want mirror list and ordered strategy", httpRepo.Mirrors, httpRepo.MirrorStrategy)
+	}
+}
+
+func TestCreateRepository_Channel(t *testing.T) {
+	cfg = &config.Config{
+		Repository: config.RepositoryConfig{
+			Type:    "github",
+			Owner:   "owner",
+			Repo:    "repo",
+			Channel: "beta",
+		},
+	}
+
+	repo, err := createRepository()
+	if err != nil {
+		t.Fatalf("createRepository() failed: %v", err)
+	}
+
+	ghRepo, ok := repo.(*repository.GitHubRepository)
+	if !ok {
+		t.Fatal("createRepository() did not return GitHubRepository")
+	}
+	if ghRepo.Channel != "beta" {
+		t.Errorf("Channel = %s, want beta", ghRepo.Channel)
+	}
+}
+
+func TestCreateRepository_GitLab(t *testing.T) {
+	cfg = &config.Config{
+		Repository: config.RepositoryConfig{
+			Type:      "gitlab",
+			BaseURL:   "https://gitlab.example.com",
+			Owner:     "group/subgroup",
+			Repo:      "project",
+			Token:     "glpat-token",
+			TokenType: "job",
+			AssetName: "app-linux",
+		},
+	}
+
+	repo, err := createRepository()
+	if err != nil {
+		t.Fatalf("createRepository() failed: %v", err)
+	}
+
+	gitlabRepo, ok := repo.(*repository.GitLabRepository)
+	if !ok {
+		t.Fatal("createRepository() did not return GitLabRepository")
+	}
+	if gitlabRepo.Project != "group/subgroup/project" {
+		t.Errorf("Project = %s, want group/subgroup/project", gitlabRepo.Project)
+	}
+	if gitlabRepo.TokenType != "job" {
+		t.Errorf("TokenType = %s, want job", gitlabRepo.TokenType)
+	}
+}
+
+func TestCreateRepository_Gitea(t *testing.T) {
+	cfg = &config.Config{
+		Repository: config.RepositoryConfig{
+			Type:      "gitea",
+			BaseURL:   "https://forgejo.example.com",
+			Owner:     "tools",
+			Repo:      "app",
+			AssetName: "app-linux",
+		},
+	}
+
+	repo, err := createRepository()
+	if err != nil {
+		t.Fatalf("createRepository() failed: %v", err)
+	}
+
+	giteaRepo, ok := repo.(*repository.GiteaRepository)
+	if !ok {
+		t.Fatal("createRepository() did not return GiteaRepository")
+	}
+	if giteaRepo.AssetName != "app-linux" {
+		t.Errorf("AssetName = %s, want app-linux", giteaRepo.AssetName)
+	}
+}
+
+func TestCreateRepository_S3(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+
+	cfg = &config.Config{
+		Repository: config.RepositoryConfig{
+			Type:       "s3",
+			BaseURL:    "https://minio.example.com",
+			Bucket:     "releases",
+			KeyPattern: "myapp/{version}/myapp-linux-amd64",
+		},
+	}
+
+	repo, err := createRepository()
+	if err != nil {
+		t.Fatalf("createRepository() failed: %v", err)
+	}
+
+	s3Repo, ok := repo.(*repository.S3Repository)
+	if !ok {
+		t.Fatal("createRepository() did not return S3Repository")
+	}
+	if s3Repo.AccessKeyID != "env-key" {
+		t.Errorf("AccessKeyID = %s, want credentials from the environment", s3Repo.AccessKeyID)
+	}
+
+	// Explicit credentials take precedence over the environment
+	cfg.Repository.AccessKeyID = "config-key"
+	cfg.Repository.SecretAccessKey = "config-secret"
+	repo, err = createRepository()
+	if err != nil {
+		t.Fatalf("createRepository() failed: %v", err)
+	}
+	if got := repo.(*repository.S3Repository).AccessKeyID; got != "config-key" {
+		t.Errorf("AccessKeyID = %s, want config-key", got)
+	}
+}
+
+func TestCreateRepository_File(t *testing.T) {
+	cfg = &config.Config{
+		Repository: config.RepositoryConfig{
+			Type: "file",
+			Path: "/mnt/releases/releases.json",
+		},
+	}
+
+	repo, err := createRepository()
+	if err != nil {
+		t.Fatalf("createRepository() failed: %v", err)
+	}
+
+	if _, ok := repo.(*repository.FileRepository); !ok {
+		t.Error("createRepository() did not return FileRepository")
+	}
+}
+
+func TestCreateRepository_OCI(t *testing.T) {
+	cfg = &config.Config{
+		Repository: config.RepositoryConfig{
+			Type:      "oci",
+			BaseURL:   "registry.example.com",
+			Repo:      "team/myapp",
+			AssetName: "myapp-linux-amd64",
+			MediaType: "application/vnd.myapp.binary",
+		},
+	}
+
+	repo, err := createRepository()
+	if err != nil {
+		t.Fatalf("createRepository() failed: %v", err)
+	}
+
+	ociRepo, ok := repo.(*repository.OCIRepository)
+	if !ok {
+		t.Fatal("createRepository() did not return OCIRepository")
+	}
+	if ociRepo.MediaType != "application/vnd.myapp.binary" {
+		t.Errorf("MediaType = %s, want application/vnd.myapp.binary", ociRepo.MediaType)
+	}
+}
+
 func TestCreateRepository_UnsupportedType(t *testing.T) {
 	cfg = &config.Config{
 		Repository: config.RepositoryConfig{
@@ -241,105 +486,1425 @@ func TestPerformUpdate_NewVersionDownloadAndApply(t *testing.T) {
 	}
 }
 
-func TestPerformUpdate_DownloadError(t *testing.T) {
+// setupInstallTest writes a target file and a saved config for install and pin tests
+func setupInstallTest(t *testing.T, currentVersion, pinnedVersion string) string {
+	t.Helper()
 	tempDir := t.TempDir()
 
+	targetPath := filepath.Join(tempDir, "target")
+	if err := os.WriteFile(targetPath, []byte("old version"), 0644); err != nil {
+		t.Fatalf("Failed to create target file: %v", err)
+	}
+
+	configPath := filepath.Join(tempDir, "config.json")
 	cfg = &config.Config{
-		CurrentVersion: "v1.0.0",
-		DownloadDir:    tempDir,
-		TargetPath:     filepath.Join(tempDir, "target"),
+		CurrentVersion: currentVersion,
+		PinnedVersion:  pinnedVersion,
+		DownloadDir:    filepath.Join(tempDir, "downloads"),
+		TargetPath:     targetPath,
 		Applier:        "binary",
+		Repository: config.RepositoryConfig{
+			Type:  "github",
+			Owner: "test",
+			Repo:  "test",
+		},
 	}
-	cfgFile = filepath.Join(tempDir, "config.json")
+	cfgFile = configPath
+
+	if err := cfg.Save(configPath); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	return configPath
+}
+
+func TestInstallVersion_Downgrade(t *testing.T) {
+	configPath := setupInstallTest(t, "v2.0.0", "")
 
 	mockRepo := &mockRepository{
 		latestRelease: &repository.Release{
-			Version:  "v2.0.0",
-			FileName: "app.bin",
+			Version:  "v1.0.0",
+			FileName: "app-v1.0.0.bin",
 		},
-		compareVersionsResult: true,
-		downloadErr:           os.ErrPermission,
+		compareVersionsResult: false, // Older than current
 	}
 
-	err := performUpdate(mockRepo)
-	if err == nil {
-		t.Error("performUpdate() expected error when download fails, got nil")
+	if err := installVersion(mockRepo, "1.0.0"); err != nil {
+		t.Fatalf("installVersion() failed: %v", err)
+	}
+
+	if mockRepo.requestedVersion != "1.0.0" {
+		t.Errorf("installVersion() requested %q, want 1.0.0", mockRepo.requestedVersion)
+	}
+	if !mockRepo.downloadCalled {
+		t.Error("installVersion() should have called Download()")
+	}
+
+	updatedCfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load updated config: %v", err)
+	}
+	if updatedCfg.CurrentVersion != "v1.0.0" {
+		t.Errorf("Config current_version = %s, want v1.0.0", updatedCfg.CurrentVersion)
 	}
 }
 
-func TestPerformUpdate_UnknownApplierType(t *testing.T) {
-	tempDir := t.TempDir()
+func TestInstallVersion_AlreadyInstalled(t *testing.T) {
+	setupInstallTest(t, "1.0.0", "")
 
-	// Create download directory and file
-	downloadDir := filepath.Join(tempDir, "downloads")
-	if err := os.MkdirAll(downloadDir, 0755); err != nil {
-		t.Fatalf("Failed to create download dir: %v", err)
+	mockRepo := &mockRepository{
+		latestRelease: &repository.Release{Version: "v1.0.0", FileName: "app"},
 	}
 
-	cfg = &config.Config{
-		CurrentVersion: "v1.0.0",
-		DownloadDir:    downloadDir,
-		TargetPath:     filepath.Join(tempDir, "target"),
-		Applier:        "unknown_applier",
+	if err := installVersion(mockRepo, "1.0.0"); err != nil {
+		t.Fatalf("installVersion() failed: %v", err)
 	}
-	cfgFile = filepath.Join(tempDir, "config.json")
+	if mockRepo.downloadCalled {
+		t.Error("installVersion() should not download the installed version")
+	}
+}
+
+func TestInstallVersion_NotFound(t *testing.T) {
+	setupInstallTest(t, "1.0.0", "")
+
+	mockRepo := &mockRepository{getLatestReleaseErr: errors.New("release version 9.9.9 not found")}
+
+	if err := installVersion(mockRepo, "9.9.9"); err == nil {
+		t.Error("installVersion() expected error, got nil")
+	}
+}
+
+func TestPerformUpdate_PinnedVersion(t *testing.T) {
+	configPath := setupInstallTest(t, "v2.0.0", "1.5.0")
 
 	mockRepo := &mockRepository{
 		latestRelease: &repository.Release{
-			Version:  "v2.0.0",
-			FileName: "app.bin",
+			Version:  "v1.5.0",
+			FileName: "app-v1.5.0.bin",
 		},
 		compareVersionsResult: true,
 	}
 
-	err := performUpdate(mockRepo)
-	if err == nil {
-		t.Error("performUpdate() expected error for unknown applier type, got nil")
+	if err := performUpdate(mockRepo); err != nil {
+		t.Fatalf("performUpdate() failed: %v", err)
 	}
-}
 
-func TestPerformUpdate_NoCurrentVersion(t *testing.T) {
-	tempDir := t.TempDir()
+	if mockRepo.requestedVersion != "1.5.0" {
+		t.Errorf("performUpdate() requested %q, want the pinned version 1.5.0", mockRepo.requestedVersion)
+	}
 
-	targetPath := filepath.Join(tempDir, "target")
-	configPath := filepath.Join(tempDir, "config.json")
+	updatedCfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load updated config: %v", err)
+	}
+	if updatedCfg.CurrentVersion != "v1.5.0" {
+		t.Errorf("Config current_version = %s, want v1.5.0", updatedCfg.CurrentVersion)
+	}
+	if updatedCfg.PinnedVersion != "1.5.0" {
+		t.Errorf("Config pinned_version = %s, want it kept as 1.5.0", updatedCfg.PinnedVersion)
+	}
+}
 
-	cfg = &config.Config{
-		CurrentVersion: "", // No current version
-		DownloadDir:    filepath.Join(tempDir, "downloads"),
-		TargetPath:     targetPath,
-		Applier:        "binary",
-		Repository: config.RepositoryConfig{
-			Type:  "github",
-			Owner: "test",
-			Repo:  "test",
+func TestPerformUpdate_PinnedVersionHolds(t *testing.T) {
+	setupInstallTest(t, "v1.5.0", "1.5.0")
+
+	mockRepo := &mockRepository{
+		latestRelease: &repository.Release{
+			Version:  "v2.0.0",
+			FileName: "app-v2.0.0.bin",
 		},
+		compareVersionsResult: true, // A newer release exists
 	}
-	cfgFile = configPath
 
-	// Save config
-	if err := cfg.Save(configPath); err != nil {
-		t.Fatalf("Failed to save config: %v", err)
+	if err := performUpdate(mockRepo); err != nil {
+		t.Fatalf("performUpdate() failed: %v", err)
+	}
+	if mockRepo.downloadCalled {
+		t.Error("performUpdate() should not update past the pinned version")
 	}
+}
+
+func TestRollback(t *testing.T) {
+	configPath := setupInstallTest(t, "v1.0.0", "")
+	cfg.Backups = 2
+	cfg.StateDir = filepath.Join(filepath.Dir(configPath), "state")
 
 	mockRepo := &mockRepository{
 		latestRelease: &repository.Release{
-			Version:  "v1.0.0",
-			FileName: "app.bin",
+			Version:  "v2.0.0",
+			FileName: "app-v2.0.0.bin",
 		},
+		compareVersionsResult: true,
 	}
 
-	err := performUpdate(mockRepo)
+	if err := performUpdate(mockRepo); err != nil {
+		t.Fatalf("performUpdate() failed: %v", err)
+	}
+	if content, _ := os.ReadFile(cfg.TargetPath); string(content) != "mock download content" {
+		t.Fatalf("Target content after update = %q, want the downloaded release", string(content))
+	}
+
+	if err := rollback(); err != nil {
+		t.Fatalf("rollback() failed: %v", err)
+	}
+
+	if content, _ := os.ReadFile(cfg.TargetPath); string(content) != "old version" {
+		t.Errorf("Target content after rollback = %q, want %q", string(content), "old version")
+	}
+
+	updatedCfg, err := config.Load(configPath)
 	if err != nil {
-		t.Fatalf("performUpdate() failed when no current version: %v", err)
+		t.Fatalf("Failed to load updated config: %v", err)
+	}
+	if updatedCfg.CurrentVersion != "v1.0.0" {
+		t.Errorf("Config current_version = %s, want v1.0.0 after rollback", updatedCfg.CurrentVersion)
 	}
 
-	// Should proceed with download when no current version is set
+	// The only backup was used up
+	if err := rollback(); err == nil {
+		t.Error("rollback() expected error with no backups left, got nil")
+	}
+}
+
+func TestRollback_Versioned(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping symlink test on Windows")
+	}
+
+	configPath := setupInstallTest(t, "v1.0.0", "")
+	cfg.Backups = 1
+	cfg.Applier = "versioned"
+	cfg.KeepReleases = 3
+	cfg.TargetPath = filepath.Join(filepath.Dir(configPath), "myapp")
+
+	// An existing install of v1.0.0
+	if err := os.MkdirAll(filepath.Join(cfg.TargetPath, "releases", "v1.0.0"), 0755); err != nil {
+		t.Fatalf("Failed to create release directory: %v", err)
+	}
+	if err := os.Symlink(filepath.Join("releases", "v1.0.0"), filepath.Join(cfg.TargetPath, "current")); err != nil {
+		t.Fatalf("Failed to create current symlink: %v", err)
+	}
+
+	mockRepo := &mockRepository{
+		latestRelease:         &repository.Release{Version: "v2.0.0", FileName: "app"},
+		compareVersionsResult: true,
+	}
+
+	if err := performUpdate(mockRepo); err != nil {
+		t.Fatalf("performUpdate() failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(cfg.TargetPath, "current", "app"))
+	if err != nil || string(content) != "mock download content" {
+		t.Fatalf("current/app = %q (%v), want the downloaded release", string(content), err)
+	}
+
+	if err := rollback(); err != nil {
+		t.Fatalf("rollback() failed: %v", err)
+	}
+
+	link, err := os.Readlink(filepath.Join(cfg.TargetPath, "current"))
+	if err != nil {
+		t.Fatalf("current is not a symlink after rollback: %v", err)
+	}
+	if link != filepath.Join("releases", "v1.0.0") {
+		t.Errorf("current -> %s after rollback, want releases/v1.0.0", link)
+	}
+	if cfg.CurrentVersion != "v1.0.0" {
+		t.Errorf("CurrentVersion = %s, want v1.0.0 after rollback", cfg.CurrentVersion)
+	}
+}
+
+func TestRollback_VersionedKeepOne(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping symlink test on Windows")
+	}
+
+	configPath := setupInstallTest(t, "v1.0.0", "")
+	cfg.Backups = 1
+	cfg.Applier = "versioned"
+	cfg.KeepReleases = 1
+	cfg.TargetPath = filepath.Join(filepath.Dir(configPath), "myapp")
+
+	if err := os.MkdirAll(filepath.Join(cfg.TargetPath, "releases", "v1.0.0"), 0755); err != nil {
+		t.Fatalf("Failed to create release directory: %v", err)
+	}
+	if err := os.Symlink(filepath.Join("releases", "v1.0.0"), filepath.Join(cfg.TargetPath, "current")); err != nil {
+		t.Fatalf("Failed to create current symlink: %v", err)
+	}
+
+	mockRepo := &mockRepository{
+		latestRelease:         &repository.Release{Version: "v2.0.0", FileName: "app"},
+		compareVersionsResult: true,
+	}
+
+	if err := performUpdate(mockRepo); err != nil {
+		t.Fatalf("performUpdate() failed: %v", err)
+	}
+
+	// keep_releases 1 must not prune the release the backup switches back to
+	if _, err := os.Stat(filepath.Join(cfg.TargetPath, "releases", "v1.0.0")); err != nil {
+		t.Fatalf("Backed up release was pruned: %v", err)
+	}
+
+	if err := rollback(); err != nil {
+		t.Fatalf("rollback() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.TargetPath, "current")); err != nil {
+		t.Errorf("current does not resolve after rollback: %v", err)
+	}
+}
+
+func TestPerformUpdate_ArchiveRemovesStaleFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// A release share holding a zip of v2.0.0, which drops plugins/old.so
+	releaseDir := filepath.Join(tempDir, "share")
+	if err := os.MkdirAll(releaseDir, 0755); err != nil {
+		t.Fatalf("Failed to create release directory: %v", err)
+	}
+	zipFile, err := os.Create(filepath.Join(releaseDir, "app.zip"))
+	if err != nil {
+		t.Fatalf("Failed to create zip: %v", err)
+	}
+	zipWriter := zip.NewWriter(zipFile)
+	writer, err := zipWriter.Create("app")
+	if err != nil {
+		t.Fatalf("Failed to create zip entry: %v", err)
+	}
+	if _, err := writer.Write([]byte("new binary")); err != nil {
+		t.Fatalf("Failed to write zip entry: %v", err)
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	_ = zipFile.Close()
+
+	releasesJSON := `[{"version": "v2.0.0", "url": "app.zip"}]`
+	if err := os.WriteFile(filepath.Join(releaseDir, "releases.json"), []byte(releasesJSON), 0644); err != nil {
+		t.Fatalf("Failed to write releases.json: %v", err)
+	}
+
+	// An install of v1.0.0 with a user file alongside it
+	installDir := filepath.Join(tempDir, "install")
+	for name, content := range map[string]string{"app": "old binary", "plugins/old.so": "old plugin", "user.txt": "mine"} {
+		path := filepath.Join(installDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	configPath := filepath.Join(tempDir, "config.json")
+	cfg = &config.Config{
+		Repository: config.RepositoryConfig{
+			Type: "file",
+			Path: filepath.Join(releaseDir, "releases.json"),
+		},
+		CurrentVersion: "v1.0.0",
+		TargetPath:     filepath.Join(installDir, "app"),
+		Applier:        "archive",
+		DownloadDir:    filepath.Join(tempDir, "downloads"),
+	}
+	cfgFile = configPath
+	if err := cfg.Save(configPath); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	store := manifestStore()
+	if err := store.Save(&manifest.Manifest{Version: "v1.0.0", Root: installDir, Files: []string{"app", "plugins/old.so"}}); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+
+	repo, err := createRepository()
+	if err != nil {
+		t.Fatalf("createRepository() failed: %v", err)
+	}
+	if err := performUpdate(repo); err != nil {
+		t.Fatalf("performUpdate() failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(installDir, "plugins")); !os.IsNotExist(err) {
+		t.Error("performUpdate() should remove files dropped from the release")
+	}
+	if content, _ := os.ReadFile(filepath.Join(installDir, "user.txt")); string(content) != "mine" {
+		t.Errorf("user.txt = %q, want untracked files left alone", string(content))
+	}
+
+	m, err := store.Load("v2.0.0")
+	if err != nil || m == nil {
+		t.Fatalf("Load() = %v, %v, want the manifest for v2.0.0", m, err)
+	}
+	if len(m.Files) != 1 || m.Files[0] != "app" {
+		t.Errorf("Manifest files = %v, want [app]", m.Files)
+	}
+	if old, _ := store.Load("v1.0.0"); old != nil {
+		t.Error("The manifest for v1.0.0 should be pruned without a backup to return to it")
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	configPath := setupInstallTest(t, "v1.0.0", "")
+
+	untracked := filepath.Join(filepath.Dir(configPath), "notes.txt")
+	if err := os.WriteFile(untracked, []byte("mine"), 0644); err != nil {
+		t.Fatalf("Failed to write untracked file: %v", err)
+	}
+
+	mockRepo := &mockRepository{
+		latestRelease:         &repository.Release{Version: "v2.0.0", FileName: "app"},
+		compareVersionsResult: true,
+	}
+	if err := performUpdate(mockRepo); err != nil {
+		t.Fatalf("performUpdate() failed: %v", err)
+	}
+
+	if err := uninstall(); err != nil {
+		t.Fatalf("uninstall() failed: %v", err)
+	}
+
+	if _, err := os.Stat(cfg.TargetPath); !os.IsNotExist(err) {
+		t.Error("uninstall() should remove the installed binary")
+	}
+	if _, err := os.Stat(untracked); err != nil {
+		t.Error("uninstall() should leave untracked files alone")
+	}
+	if cfg.CurrentVersion != "" {
+		t.Errorf("CurrentVersion = %s, want it cleared after uninstall", cfg.CurrentVersion)
+	}
+	if m, _ := manifestStore().Load("v2.0.0"); m != nil {
+		t.Error("uninstall() should remove the manifest")
+	}
+}
+
+func TestUninstall_KeepsModifiedPreservedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// A release share holding a zip of v2.0.0 with a default app.conf
+	releaseDir := filepath.Join(tempDir, "share")
+	if err := os.MkdirAll(releaseDir, 0755); err != nil {
+		t.Fatalf("Failed to create release directory: %v", err)
+	}
+	zipFile, err := os.Create(filepath.Join(releaseDir, "app.zip"))
+	if err != nil {
+		t.Fatalf("Failed to create zip: %v", err)
+	}
+	zipWriter := zip.NewWriter(zipFile)
+	for _, entry := range []struct{ name, content string }{
+		{"app", "new binary"},
+		{"app.conf", "default config"},
+	} {
+		writer, err := zipWriter.Create(entry.name)
+		if err != nil {
+			t.Fatalf("Failed to create zip entry: %v", err)
+		}
+		if _, err := writer.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("Failed to write zip entry: %v", err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	_ = zipFile.Close()
+
+	releasesJSON := `[{"version": "v2.0.0", "url": "app.zip"}]`
+	if err := os.WriteFile(filepath.Join(releaseDir, "releases.json"), []byte(releasesJSON), 0644); err != nil {
+		t.Fatalf("Failed to write releases.json: %v", err)
+	}
+
+	// An install of v1.0.0 whose app.conf the user has edited
+	installDir := filepath.Join(tempDir, "install")
+	if err := os.MkdirAll(installDir, 0755); err != nil {
+		t.Fatalf("Failed to create install directory: %v", err)
+	}
+	for name, content := range map[string]string{"app": "old binary", "app.conf": "edited config"} {
+		if err := os.WriteFile(filepath.Join(installDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	configPath := filepath.Join(tempDir, "config.json")
+	cfg = &config.Config{
+		Repository: config.RepositoryConfig{
+			Type: "file",
+			Path: filepath.Join(releaseDir, "releases.json"),
+		},
+		CurrentVersion: "v1.0.0",
+		TargetPath:     filepath.Join(installDir, "app"),
+		Applier:        "archive",
+		Preserve:       []string{"app.conf"},
+		DownloadDir:    filepath.Join(tempDir, "downloads"),
+	}
+	cfgFile = configPath
+	if err := cfg.Save(configPath); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	if err := manifestStore().Save(&manifest.Manifest{Version: "v1.0.0", Root: installDir, Files: []string{"app", "app.conf"}}); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+
+	repo, err := createRepository()
+	if err != nil {
+		t.Fatalf("createRepository() failed: %v", err)
+	}
+	if err := performUpdate(repo); err != nil {
+		t.Fatalf("performUpdate() failed: %v", err)
+	}
+	newConf := filepath.Join(installDir, "app.conf"+applier.PreservedSuffix)
+	if content, _ := os.ReadFile(newConf); string(content) != "default config" {
+		t.Fatalf("%s = %q, want the release's app.conf", newConf, string(content))
+	}
+
+	if err := uninstall(); err != nil {
+		t.Fatalf("uninstall() failed: %v", err)
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(installDir, "app.conf")); string(content) != "edited config" {
+		t.Errorf("app.conf = %q, want the user's edits kept by uninstall", string(content))
+	}
+	for _, name := range []string{"app", "app.conf" + applier.PreservedSuffix} {
+		if _, err := os.Stat(filepath.Join(installDir, name)); !os.IsNotExist(err) {
+			t.Errorf("uninstall() should remove %s", name)
+		}
+	}
+}
+
+func TestUninstall_NoManifest(t *testing.T) {
+	setupInstallTest(t, "v1.0.0", "")
+
+	if err := uninstall(); err == nil {
+		t.Error("uninstall() expected error, got nil")
+	}
+	if _, err := os.Stat(cfg.TargetPath); err != nil {
+		t.Error("uninstall() without a manifest should not remove anything")
+	}
+}
+
+func TestPerformUpdate_NoBackupsWhenDisabled(t *testing.T) {
+	configPath := setupInstallTest(t, "v1.0.0", "")
+	cfg.StateDir = filepath.Join(filepath.Dir(configPath), "state")
+
+	mockRepo := &mockRepository{
+		latestRelease:         &repository.Release{Version: "v2.0.0", FileName: "app"},
+		compareVersionsResult: true,
+	}
+
+	if err := performUpdate(mockRepo); err != nil {
+		t.Fatalf("performUpdate() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.StateDir, "backups")); !os.IsNotExist(err) {
+		t.Error("performUpdate() should not create backups when backups is 0")
+	}
+}
+
+func TestPerformUpdate_HealthCheckFailureRestores(t *testing.T) {
+	configPath := setupInstallTest(t, "v1.0.0", "")
+	cfg.StateDir = filepath.Join(filepath.Dir(configPath), "state")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg.HealthCheck = &config.HealthCheckConfig{URL: server.URL, Timeout: "5s"}
+
+	mockRepo := &mockRepository{
+		latestRelease:         &repository.Release{Version: "v2.0.0", FileName: "app"},
+		compareVersionsResult: true,
+	}
+
+	err := performUpdate(mockRepo)
+	var healthErr *healthCheckError
+	if !errors.As(err, &healthErr) {
+		t.Fatalf("performUpdate() error = %v, want a health check error", err)
+	}
+
+	if content, _ := os.ReadFile(cfg.TargetPath); string(content) != "old version" {
+		t.Errorf("Target content after failed health check = %q, want %q", string(content), "old version")
+	}
+
+	updatedCfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load updated config: %v", err)
+	}
+	if updatedCfg.CurrentVersion != "v1.0.0" {
+		t.Errorf("Config current_version = %s, want v1.0.0 after failed health check", updatedCfg.CurrentVersion)
+	}
+}
+
+func TestPerformUpdate_HealthCheckPasses(t *testing.T) {
+	configPath := setupInstallTest(t, "v1.0.0", "")
+	cfg.StateDir = filepath.Join(filepath.Dir(configPath), "state")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg.HealthCheck = &config.HealthCheckConfig{URL: server.URL}
+
+	mockRepo := &mockRepository{
+		latestRelease:         &repository.Release{Version: "v2.0.0", FileName: "app"},
+		compareVersionsResult: true,
+	}
+
+	if err := performUpdate(mockRepo); err != nil {
+		t.Fatalf("performUpdate() failed: %v", err)
+	}
+	if cfg.CurrentVersion != "v2.0.0" {
+		t.Errorf("CurrentVersion = %s, want v2.0.0", cfg.CurrentVersion)
+	}
+
+	// The backup taken for the health check is not kept when backups is 0
+	if latest, _ := backupStore().Latest(); latest != nil {
+		t.Errorf("performUpdate() kept backup %s with backups set to 0", latest.ID)
+	}
+}
+
+func TestPerformUpdate_Hooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping shell hook test on Windows")
+	}
+
+	configPath := setupInstallTest(t, "v1.0.0", "")
+	logPath := filepath.Join(filepath.Dir(configPath), "hooks.log")
+
+	cfg.Hooks = &config.HooksConfig{
+		PreDownload: []string{`echo "pre_download $GUPPY_OLD_VERSION $GUPPY_NEW_VERSION" >> ` + logPath},
+		PreApply:    []string{`test -f "$GUPPY_DOWNLOAD_PATH" && echo "pre_apply" >> ` + logPath},
+		PostApply:   []string{`echo "post_apply $GUPPY_TARGET_PATH" >> ` + logPath},
+		OnFailure:   []string{`echo "on_failure" >> ` + logPath},
+	}
+
+	mockRepo := &mockRepository{
+		latestRelease:         &repository.Release{Version: "v2.0.0", FileName: "app"},
+		compareVersionsResult: true,
+	}
+
+	if err := performUpdate(mockRepo); err != nil {
+		t.Fatalf("performUpdate() failed: %v", err)
+	}
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read hook log: %v", err)
+	}
+	want := "pre_download v1.0.0 v2.0.0\npre_apply\npost_apply " + cfg.TargetPath + "\n"
+	if string(content) != want {
+		t.Errorf("Hook log = %q, want %q", string(content), want)
+	}
+}
+
+func TestPerformUpdate_PreApplyHookAborts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping shell hook test on Windows")
+	}
+
+	configPath := setupInstallTest(t, "v1.0.0", "")
+	logPath := filepath.Join(filepath.Dir(configPath), "hooks.log")
+
+	cfg.Hooks = &config.HooksConfig{
+		PreApply:  []string{"exit 1"},
+		PostApply: []string{`echo "post_apply" >> ` + logPath},
+		OnFailure: []string{`echo "on_failure $GUPPY_NEW_VERSION" >> ` + logPath},
+	}
+
+	mockRepo := &mockRepository{
+		latestRelease:         &repository.Release{Version: "v2.0.0", FileName: "app"},
+		compareVersionsResult: true,
+	}
+
+	err := performUpdate(mockRepo)
+	if err == nil {
+		t.Fatal("performUpdate() expected error for failing pre_apply hook, got nil")
+	}
+	if !strings.Contains(err.Error(), "pre_apply hook failed") {
+		t.Errorf("performUpdate() error = %v, want the pre_apply hook failure", err)
+	}
+
+	if content, _ := os.ReadFile(cfg.TargetPath); string(content) != "old version" {
+		t.Errorf("Target content after aborted update = %q, want %q", string(content), "old version")
+	}
+	if cfg.CurrentVersion != "v1.0.0" {
+		t.Errorf("CurrentVersion = %s, want v1.0.0 after aborted update", cfg.CurrentVersion)
+	}
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read hook log: %v", err)
+	}
+	if string(content) != "on_failure v2.0.0\n" {
+		t.Errorf("Hook log = %q, want only the on_failure hook", string(content))
+	}
+}
+
+func TestSameVersion(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"v1.2.0", "1.2.0", true},
+		{"1.2.0", "1.2.1", false},
+		{"", "1.2.0", false},
+		{"nightly", "nightly", true},
+	}
+
+	for _, tt := range tests {
+		if got := sameVersion(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameVersion(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPerformUpdate_DownloadError(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg = &config.Config{
+		CurrentVersion: "v1.0.0",
+		DownloadDir:    tempDir,
+		TargetPath:     filepath.Join(tempDir, "target"),
+		Applier:        "binary",
+	}
+	cfgFile = filepath.Join(tempDir, "config.json")
+
+	mockRepo := &mockRepository{
+		latestRelease: &repository.Release{
+			Version:  "v2.0.0",
+			FileName: "app.bin",
+		},
+		compareVersionsResult: true,
+		downloadErr:           os.ErrPermission,
+	}
+
+	err := performUpdate(mockRepo)
+	if err == nil {
+		t.Error("performUpdate() expected error when download fails, got nil")
+	}
+}
+
+func TestPerformUpdate_UnknownApplierType(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Create download directory and file
+	downloadDir := filepath.Join(tempDir, "downloads")
+	if err := os.MkdirAll(downloadDir, 0755); err != nil {
+		t.Fatalf("Failed to create download dir: %v", err)
+	}
+
+	cfg = &config.Config{
+		CurrentVersion: "v1.0.0",
+		DownloadDir:    downloadDir,
+		TargetPath:     filepath.Join(tempDir, "target"),
+		Applier:        "unknown_applier",
+	}
+	cfgFile = filepath.Join(tempDir, "config.json")
+
+	mockRepo := &mockRepository{
+		latestRelease: &repository.Release{
+			Version:  "v2.0.0",
+			FileName: "app.bin",
+		},
+		compareVersionsResult: true,
+	}
+
+	err := performUpdate(mockRepo)
+	if err == nil {
+		t.Error("performUpdate() expected error for unknown applier type, got nil")
+	}
+}
+
+func TestPerformUpdate_NoCurrentVersion(t *testing.T) {
+	tempDir := t.TempDir()
+
+	targetPath := filepath.Join(tempDir, "target")
+	configPath := filepath.Join(tempDir, "config.json")
+
+	cfg = &config.Config{
+		CurrentVersion: "", // No current version
+		DownloadDir:    filepath.Join(tempDir, "downloads"),
+		TargetPath:     targetPath,
+		Applier:        "binary",
+		Repository: config.RepositoryConfig{
+			Type:  "github",
+			Owner: "test",
+			Repo:  "test",
+		},
+	}
+	cfgFile = configPath
+
+	// Save config
+	if err := cfg.Save(configPath); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	mockRepo := &mockRepository{
+		latestRelease: &repository.Release{
+			Version:  "v1.0.0",
+			FileName: "app.bin",
+		},
+	}
+
+	err := performUpdate(mockRepo)
+	if err != nil {
+		t.Fatalf("performUpdate() failed when no current version: %v", err)
+	}
+
+	// Should proceed with download when no current version is set
 	if !mockRepo.downloadCalled {
 		t.Error("performUpdate() should download when no current version is set")
 	}
 }
 
+func TestPerformUpdate_FileRepositoryEndToEnd(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Lay out a local release share with a releases.json
+	releaseDir := filepath.Join(tempDir, "share")
+	if err := os.MkdirAll(filepath.Join(releaseDir, "2.0.0"), 0755); err != nil {
+		t.Fatalf("Failed to create release directory: %v", err)
+	}
+	newBinary := []byte("new binary")
+	if err := os.WriteFile(filepath.Join(releaseDir, "2.0.0", "app"), newBinary, 0755); err != nil {
+		t.Fatalf("Failed to write release: %v", err)
+	}
+	sum, err := checksum.CalculateSHA256(filepath.Join(releaseDir, "2.0.0", "app"))
+	if err != nil {
+		t.Fatalf("Failed to calculate checksum: %v", err)
+	}
+	releasesJSON := `[{"version": "v2.0.0", "url": "2.0.0/app", "sha256": "` + sum + `"}]`
+	if err := os.WriteFile(filepath.Join(releaseDir, "releases.json"), []byte(releasesJSON), 0644); err != nil {
+		t.Fatalf("Failed to write releases.json: %v", err)
+	}
+
+	targetPath := filepath.Join(tempDir, "app")
+	if err := os.WriteFile(targetPath, []byte("old binary"), 0755); err != nil {
+		t.Fatalf("Failed to create target file: %v", err)
+	}
+
+	configPath := filepath.Join(tempDir, "config.json")
+	cfg = &config.Config{
+		Repository: config.RepositoryConfig{
+			Type: "file",
+			Path: filepath.Join(releaseDir, "releases.json"),
+		},
+		CurrentVersion: "v1.0.0",
+		TargetPath:     targetPath,
+		Applier:        "binary",
+		DownloadDir:    filepath.Join(tempDir, "downloads"),
+	}
+	cfgFile = configPath
+	if err := cfg.Save(configPath); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	repo, err := createRepository()
+	if err != nil {
+		t.Fatalf("createRepository() failed: %v", err)
+	}
+
+	if err := performUpdate(repo); err != nil {
+		t.Fatalf("performUpdate() failed: %v", err)
+	}
+
+	content, err := os.ReadFile(targetPath)
+	if err != nil {
+		t.Fatalf("Failed to read target: %v", err)
+	}
+	if !bytes.Equal(content, newBinary) {
+		t.Errorf("Target content = %q, want %q", content, newBinary)
+	}
+
+	updatedCfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load updated config: %v", err)
+	}
+	if updatedCfg.CurrentVersion != "v2.0.0" {
+		t.Errorf("Config current_version = %s, want v2.0.0", updatedCfg.CurrentVersion)
+	}
+}
+
+func TestPerformUpdate_ChecksumEndToEnd(t *testing.T) {
+	newBinary := []byte("new binary")
+
+	tests := []struct {
+		name      string
+		repoType  string
+		algorithm string
+		corrupt   bool // Serve different content from what the checksum covers
+	}{
+		{name: "http sha256", repoType: "http", algorithm: checksum.SHA256},
+		{name: "http sha512", repoType: "http", algorithm: checksum.SHA512},
+		{name: "http sha1", repoType: "http", algorithm: checksum.SHA1},
+		{name: "http md5", repoType: "http", algorithm: checksum.MD5},
+		{name: "http blake2b", repoType: "http", algorithm: checksum.BLAKE2b},
+		{name: "http mismatch", repoType: "http", algorithm: checksum.SHA512, corrupt: true},
+		{name: "github sha256 digest", repoType: "github", algorithm: checksum.SHA256},
+		{name: "github mismatch", repoType: "github", algorithm: checksum.SHA256, corrupt: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := setupInstallTest(t, "v1.0.0", "")
+			tempDir := filepath.Dir(configPath)
+
+			releaseFile := filepath.Join(tempDir, "release.bin")
+			if err := os.WriteFile(releaseFile, newBinary, 0644); err != nil {
+				t.Fatalf("Failed to write release: %v", err)
+			}
+			sum, err := checksum.Calculate(releaseFile, tt.algorithm)
+			if err != nil {
+				t.Fatalf("Calculate() failed: %v", err)
+			}
+
+			served := newBinary
+			if tt.corrupt {
+				served = []byte("tampered binary")
+			}
+
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/releases.json":
+					_, _ = w.Write([]byte(`[{"version": "v2.0.0", "url": "` + server.URL + `/app", "` + tt.algorithm + `": "` + sum + `"}]`))
+				case "/repos/test/test/releases/latest":
+					_, _ = w.Write([]byte(`{"tag_name": "v2.0.0", "assets": [{"id": 1, "name": "app", "browser_download_url": "` + server.URL + `/app", "digest": "` + tt.algorithm + `:` + sum + `"}]}`))
+				case "/app":
+					_, _ = w.Write(served)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			cfg.Repository.Type = tt.repoType
+			cfg.Repository.URL = server.URL + "/releases.json"
+			cfg.Repository.APIURL = server.URL
+
+			repo, err := createRepository()
+			if err != nil {
+				t.Fatalf("createRepository() failed: %v", err)
+			}
+
+			err = performUpdate(repo)
+			content, _ := os.ReadFile(cfg.TargetPath)
+			if tt.corrupt {
+				if err == nil {
+					t.Fatal("performUpdate() expected error, got nil")
+				}
+				if string(content) != "old version" {
+					t.Errorf("Target content = %q, want it untouched after a checksum mismatch", content)
+				}
+				if _, err := os.Stat(filepath.Join(cfg.DownloadDir, "app")); !os.IsNotExist(err) {
+					t.Error("performUpdate() should remove a download that fails verification")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("performUpdate() failed: %v", err)
+			}
+			if !bytes.Equal(content, newBinary) {
+				t.Errorf("Target content = %q, want %q", content, newBinary)
+			}
+		})
+	}
+}
+
+// minisignFixture returns a minisign public key and a function producing
+// minisign signatures with the matching secret key
+func minisignFixture(seed byte) (string, func(data []byte) []byte) {
+	private := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+	keyID := bytes.Repeat([]byte{seed}, 8)
+	publicKey := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), private.Public().(ed25519.PublicKey)...))
+
+	sign := func(data []byte) []byte {
+		hash := blake2b.Sum512(data)
+		sig := ed25519.Sign(private, hash[:])
+		trustedComment := "timestamp:0\tfile:app"
+		global := ed25519.Sign(private, append(append([]byte{}, sig...), trustedComment...))
+		return []byte("untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte("ED"), keyID...), sig...)) + "\n" +
+			"trusted comment: " + trustedComment + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n")
+	}
+	return publicKey, sign
+}
+
+func TestPerformUpdate_SignatureVerification(t *testing.T) {
+	newBinary := []byte("new binary")
+	oldKey, signOld := minisignFixture(1)
+	newKey, signNew := minisignFixture(2)
+	_, signUntrusted := minisignFixture(3)
+	rawPrivate := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{4}, ed25519.SeedSize))
+	rawKey := base64.StdEncoding.EncodeToString(rawPrivate.Public().(ed25519.PublicKey))
+
+	tests := []struct {
+		name      string
+		repoType  string
+		keys      []string
+		sigPath   string // Where the server publishes the signature
+		signature []byte
+		wantErr   bool
+	}{
+		{name: "http minisig", repoType: "http", keys: []string{oldKey}, sigPath: "/app.minisig", signature: signOld(newBinary)},
+		{name: "http rotated key", repoType: "http", keys: []string{oldKey, newKey}, sigPath: "/app.minisig", signature: signNew(newBinary)},
+		{name: "http raw ed25519 sig", repoType: "http", keys: []string{rawKey}, sigPath: "/app.sig", signature: ed25519.Sign(rawPrivate, newBinary)},
+		{name: "github sig asset", repoType: "github", keys: []string{oldKey}, sigPath: "/assets/app.sig", signature: signOld(newBinary)},
+		{name: "untrusted key", repoType: "http", keys: []string{oldKey, newKey}, sigPath: "/app.minisig", signature: signUntrusted(newBinary), wantErr: true},
+		{name: "signature over other content", repoType: "http", keys: []string{oldKey}, sigPath: "/app.minisig", signature: signOld([]byte("other binary")), wantErr: true},
+		{name: "missing signature", repoType: "http", keys: []string{oldKey}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupInstallTest(t, "v1.0.0", "")
+
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/releases.json":
+					_, _ = w.Write([]byte(`[{"version": "v2.0.0", "url": "` + server.URL + `/app"}]`))
+				case "/repos/test/test/releases/latest":
+					_, _ = w.Write([]byte(`{"tag_name": "v2.0.0", "assets": [{"id": 1, "name": "app", "browser_download_url": "` + server.URL + `/app"}, {"id": 2, "name": "app.sig", "browser_download_url": "` + server.URL + `/assets/app.sig"}]}`))
+				case "/app":
+					_, _ = w.Write(newBinary)
+				case tt.sigPath:
+					_, _ = w.Write(tt.signature)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			cfg.Repository.Type = tt.repoType
+			cfg.Repository.URL = server.URL + "/releases.json"
+			cfg.Repository.APIURL = server.URL
+			cfg.Verify = &config.VerifyConfig{PublicKeys: tt.keys}
+
+			repo, err := createRepository()
+			if err != nil {
+				t.Fatalf("createRepository() failed: %v", err)
+			}
+
+			err = performUpdate(repo)
+			content, _ := os.ReadFile(cfg.TargetPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("performUpdate() expected error, got nil")
+				}
+				if string(content) != "old version" {
+					t.Errorf("Target content = %q, want it untouched after a failed signature check", content)
+				}
+				if _, err := os.Stat(filepath.Join(cfg.DownloadDir, "app")); !os.IsNotExist(err) {
+					t.Error("performUpdate() should remove a download that fails verification")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("performUpdate() failed: %v", err)
+			}
+			if !bytes.Equal(content, newBinary) {
+				t.Errorf("Target content = %q, want %q", content, newBinary)
+			}
+			if _, err := os.Stat(filepath.Join(cfg.DownloadDir, "app.minisig")); !os.IsNotExist(err) {
+				t.Error("performUpdate() should remove the downloaded signature")
+			}
+		})
+	}
+}
+
+// pgpFixture writes an armored keyring holding a new OpenPGP key and returns
+// its path, the key's fingerprint and a function producing .asc signatures
+func pgpFixture(t *testing.T, dir string) (string, string, func(data []byte) []byte) {
+	t.Helper()
+	entity, err := openpgp.NewEntity("release", "", "release@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	if err != nil {
+		t.Fatalf("NewEntity() failed: %v", err)
+	}
+
+	var keyring bytes.Buffer
+	w, _ := armor.Encode(&keyring, openpgp.PublicKeyType, nil)
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("Serialize() failed: %v", err)
+	}
+	_ = w.Close()
+	keyringPath := filepath.Join(dir, "keyring.asc")
+	if err := os.WriteFile(keyringPath, keyring.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write keyring: %v", err)
+	}
+
+	sign := func(data []byte) []byte {
+		var sig bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&sig, entity, bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("ArmoredDetachSign() failed: %v", err)
+		}
+		return sig.Bytes()
+	}
+	return keyringPath, fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint), sign
+}
+
+func TestPerformUpdate_OpenPGPVerification(t *testing.T) {
+	newBinary := []byte("new binary")
+
+	tests := []struct {
+		name      string
+		repoType  string
+		release   string // releases.json entry for http repositories
+		sigPath   string // Where the server publishes the signature
+		sigAsset  string // Name of the GitHub signature asset, app.asc if empty
+		token     string
+		untrusted bool // Sign with a key that is not in the keyring
+		wantErr   bool
+	}{
+		{name: "github asc asset", repoType: "github", sigPath: "/assets/app.asc"},
+		{name: "github sig asset through the asset API", repoType: "github", sigAsset: "app.sig", token: "secret", sigPath: "/repos/test/test/releases/assets/2"},
+		{name: "http signature_url", repoType: "http", release: `"signature_url": "sigs/app.asc"`, sigPath: "/sigs/app.asc"},
+		{name: "http asc next to download", repoType: "http", sigPath: "/app.asc"},
+		{name: "key not in keyring", repoType: "github", sigPath: "/assets/app.asc", untrusted: true, wantErr: true},
+		{name: "missing signature", repoType: "http", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := setupInstallTest(t, "v1.0.0", "")
+			keyringPath, fingerprint, sign := pgpFixture(t, filepath.Dir(configPath))
+			sig := sign(newBinary)
+			if tt.untrusted {
+				_, _, signUntrusted := pgpFixture(t, t.TempDir())
+				sig = signUntrusted(newBinary)
+			}
+
+			sigAsset := tt.sigAsset
+			if sigAsset == "" {
+				sigAsset = "app.asc"
+			}
+			release := `"version": "v2.0.0"`
+			if tt.release != "" {
+				release += ", " + tt.release
+			}
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/releases.json":
+					_, _ = w.Write([]byte(`[{` + release + `, "url": "` + server.URL + `/app"}]`))
+				case "/repos/test/test/releases/latest":
+					_, _ = w.Write([]byte(`{"tag_name": "v2.0.0", "assets": [{"id": 1, "name": "app", "browser_download_url": "` + server.URL + `/app"}, {"id": 2, "name": "` + sigAsset + `", "browser_download_url": "` + server.URL + `/assets/` + sigAsset + `"}]}`))
+				case "/app", "/repos/test/test/releases/assets/1":
+					_, _ = w.Write(newBinary)
+				case tt.sigPath:
+					_, _ = w.Write(sig)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			cfg.Repository.Type = tt.repoType
+			cfg.Repository.URL = server.URL + "/releases.json"
+			cfg.Repository.APIURL = server.URL
+			cfg.Repository.Token = tt.token
+			cfg.Verify = &config.VerifyConfig{Keyring: keyringPath}
+
+			repo, err := createRepository()
+			if err != nil {
+				t.Fatalf("createRepository() failed: %v", err)
+			}
+
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			err = performUpdate(repo)
+			_ = w.Close()
+			os.Stdout = oldStdout
+			var output bytes.Buffer
+			_, _ = output.ReadFrom(r)
+
+			content, _ := os.ReadFile(cfg.TargetPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("performUpdate() expected error, got nil")
+				}
+				if string(content) != "old version" {
+					t.Errorf("Target content = %q, want it untouched after a failed signature check", content)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("performUpdate() failed: %v", err)
+			}
+			if !bytes.Equal(content, newBinary) {
+				t.Errorf("Target content = %q, want %q", content, newBinary)
+			}
+			if !strings.Contains(output.String(), "key fingerprint "+fingerprint) {
+				t.Errorf("performUpdate() output = %q, want the signing key fingerprint %s", output.String(), fingerprint)
+			}
+		})
+	}
+}
+
+const (
+	sigstoreIdentity = "https://github.com/test/test/.github/workflows/release.yml@refs/tags/v2.0.0"
+	sigstoreIssuer   = "https://token.actions.githubusercontent.com"
+)
+
+// sigstoreFixture writes a trusted root for a throwaway certificate authority
+// and transparency log, and returns it with a function that signs data as
+// identity and returns the Sigstore bundle, as cosign sign-blob --bundle does
+func sigstoreFixture(t *testing.T, dir, identity string) (string, func(data []byte) []byte) {
+	t.Helper()
+	now := time.Now().Truncate(time.Second)
+
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate() failed: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	logKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	logPublic, _ := x509.MarshalPKIXPublicKey(&logKey.PublicKey)
+	logID := sha256.Sum256(logPublic)
+
+	validFor := map[string]any{"start": now.Add(-time.Hour).UTC().Format(time.RFC3339)}
+	root, _ := json.Marshal(map[string]any{
+		"tlogs": []any{map[string]any{
+			"publicKey": map[string]any{"rawBytes": logPublic, "validFor": validFor},
+			"logId":     map[string]any{"keyId": logID[:]},
+		}},
+		"certificateAuthorities": []any{map[string]any{
+			"certChain": map[string]any{"certificates": []any{map[string]any{"rawBytes": caDER}}},
+			"validFor":  validFor,
+		}},
+	})
+	rootPath := filepath.Join(dir, "trusted_root.json")
+	if err := os.WriteFile(rootPath, root, 0644); err != nil {
+		t.Fatalf("Failed to write trusted root: %v", err)
+	}
+
+	sign := func(data []byte) []byte {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		uri, _ := url.Parse(identity)
+		issuer, _ := asn1.MarshalWithParams(sigstoreIssuer, "utf8")
+		template := &x509.Certificate{
+			SerialNumber:    big.NewInt(2),
+			NotBefore:       now.Add(-time.Minute),
+			NotAfter:        now.Add(10 * time.Minute),
+			ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+			URIs:            []*url.URL{uri},
+			ExtraExtensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}, Value: issuer}},
+		}
+		certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("CreateCertificate() failed: %v", err)
+		}
+
+		digest := sha256.Sum256(data)
+		sig, _ := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		body, _ := json.Marshal(map[string]any{
+			"kind": "hashedrekord",
+			"spec": map[string]any{
+				"data": map[string]any{"hash": map[string]any{"algorithm": "sha256", "value": hex.EncodeToString(digest[:])}},
+				"signature": map[string]any{
+					"content":   sig,
+					"publicKey": map[string]any{"content": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})},
+				},
+			},
+		})
+		payload := fmt.Sprintf(`{"body":"%s","integratedTime":%d,"logID":"%x","logIndex":1}`, base64.StdEncoding.EncodeToString(body), now.Unix(), logID)
+		payloadDigest := sha256.Sum256([]byte(payload))
+		set, _ := ecdsa.SignASN1(rand.Reader, logKey, payloadDigest[:])
+
+		bundle, _ := json.Marshal(map[string]any{
+			"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json",
+			"verificationMaterial": map[string]any{
+				"certificate": map[string]any{"rawBytes": certDER},
+				"tlogEntries": []any{map[string]any{
+					"logIndex":          "1",
+					"logId":             map[string]any{"keyId": logID[:]},
+					"kindVersion":       map[string]any{"kind": "hashedrekord", "version": "0.0.1"},
+					"integratedTime":    fmt.Sprint(now.Unix()),
+					"inclusionPromise":  map[string]any{"signedEntryTimestamp": set},
+					"canonicalizedBody": body,
+				}},
+			},
+			"messageSignature": map[string]any{
+				"messageDigest": map[string]any{"algorithm": "SHA2_256", "digest": digest[:]},
+				"signature":     sig,
+			},
+		})
+		return bundle
+	}
+	return rootPath, sign
+}
+
+func TestPerformUpdate_SigstoreVerification(t *testing.T) {
+	newBinary := []byte("new binary")
+
+	tests := []struct {
+		name       string
+		repoType   string
+		release    string // releases.json entry for http repositories
+		bundlePath string // Where the server publishes the bundle
+		identity   string // Identity the release is signed as, default sigstoreIdentity
+		tampered   bool   // Serve a bundle for other data
+		wantErr    bool
+	}{
+		{name: "github bundle asset", repoType: "github", bundlePath: "/assets/app.sigstore.json"},
+		{name: "http signature_url", repoType: "http", release: `"signature_url": "sigs/app.sigstore.json"`, bundlePath: "/sigs/app.sigstore.json"},
+		{name: "http bundle next to download", repoType: "http", bundlePath: "/app.sigstore.json"},
+		{name: "other workflow", repoType: "github", bundlePath: "/assets/app.sigstore.json", identity: "https://github.com/test/test/.github/workflows/other.yml@refs/heads/main", wantErr: true},
+		{name: "bundle for other file", repoType: "github", bundlePath: "/assets/app.sigstore.json", tampered: true, wantErr: true},
+		{name: "missing bundle", repoType: "http", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := setupInstallTest(t, "v1.0.0", "")
+			identity := sigstoreIdentity
+			if tt.identity != "" {
+				identity = tt.identity
+			}
+			rootPath, sign := sigstoreFixture(t, filepath.Dir(configPath), identity)
+			bundle := sign(newBinary)
+			if tt.tampered {
+				bundle = sign([]byte("tampered"))
+			}
+
+			release := `"version": "v2.0.0"`
+			if tt.release != "" {
+				release += ", " + tt.release
+			}
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/releases.json":
+					_, _ = w.Write([]byte(`[{` + release + `, "url": "` + server.URL + `/app"}]`))
+				case "/repos/test/test/releases/latest":
+					_, _ = w.Write([]byte(`{"tag_name": "v2.0.0", "assets": [{"id": 1, "name": "app", "browser_download_url": "` + server.URL + `/app"}, {"id": 2, "name": "app.sigstore.json", "browser_download_url": "` + server.URL + `/assets/app.sigstore.json"}]}`))
+				case "/app":
+					_, _ = w.Write(newBinary)
+				case tt.bundlePath:
+					_, _ = w.Write(bundle)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			cfg.Repository.Type = tt.repoType
+			cfg.Repository.URL = server.URL + "/releases.json"
+			cfg.Repository.APIURL = server.URL
+			cfg.Verify = &config.VerifyConfig{
+				TrustedRoot:           rootPath,
+				CertificateIdentity:   sigstoreIdentity,
+				CertificateOIDCIssuer: sigstoreIssuer,
+			}
+
+			repo, err := createRepository()
+			if err != nil {
+				t.Fatalf("createRepository() failed: %v", err)
+			}
+
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			err = performUpdate(repo)
+			_ = w.Close()
+			os.Stdout = oldStdout
+			var output bytes.Buffer
+			_, _ = output.ReadFrom(r)
+
+			content, _ := os.ReadFile(cfg.TargetPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("performUpdate() expected error, got nil")
+				}
+				if string(content) != "old version" {
+					t.Errorf("Target content = %q, want it untouched after a failed bundle check", content)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("performUpdate() failed: %v", err)
+			}
+			if !bytes.Equal(content, newBinary) {
+				t.Errorf("Target content = %q, want %q", content, newBinary)
+			}
+			if !strings.Contains(output.String(), "identity "+sigstoreIdentity) {
+				t.Errorf("performUpdate() output = %q, want the signing identity %s", output.String(), sigstoreIdentity)
+			}
+		})
+	}
+}
+
+func TestListReleases(t *testing.T) {
+	mockRepo := &mockRepository{
+		releases: []*repository.Release{
+			{
+				Version:     "v1.2.0",
+				FileName:    "app-linux-amd64",
+				DownloadURL: "https://example.com/v1.2.0/app-linux-amd64",
+				Checksum:    "abc123",
+				ReleaseDate: time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC),
+			},
+			{
+				Version:     "v1.1.0",
+				FileName:    "app-linux-amd64",
+				DownloadURL: "https://example.com/v1.1.0/app-linux-amd64",
+			},
+		},
+	}
+
+	var table bytes.Buffer
+	if err := listReleases(mockRepo, &table, false); err != nil {
+		t.Fatalf("listReleases() failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("listReleases() printed %d lines, want header and 2 releases:\n%s", len(lines), table.String())
+	}
+	if fields := strings.Fields(lines[1]); len(fields) != 4 || fields[0] != "v1.2.0" || fields[1] != "2025-04-01" || fields[3] != "yes" {
+		t.Errorf("listReleases() first row = %q, want version, date, asset and checksum yes", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); len(fields) != 4 || fields[1] != "-" || fields[3] != "no" {
+		t.Errorf("listReleases() second row = %q, want no date and checksum no", lines[2])
+	}
+
+	var out bytes.Buffer
+	if err := listReleases(mockRepo, &out, true); err != nil {
+		t.Fatalf("listReleases() with JSON failed: %v", err)
+	}
+
+	var entries []releaseListEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("listReleases() did not print valid JSON: %v", err)
+	}
+	if len(entries) != 2 || !entries[0].HasChecksum || entries[1].HasChecksum || entries[1].ReleaseDate != nil {
+		t.Errorf("listReleases() JSON = %+v, want checksum flags and omitted zero date", entries)
+	}
+}
+
+func TestListReleases_Error(t *testing.T) {
+	mockRepo := &mockRepository{listReleasesErr: errors.New("API unavailable")}
+
+	var out bytes.Buffer
+	if err := listReleases(mockRepo, &out, false); err == nil {
+		t.Error("listReleases() expected error, got nil")
+	}
+}
+
 func TestDebugLog(t *testing.T) {
 	// Save original stderr
 	oldStderr := os.Stderr
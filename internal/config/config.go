@@ -4,8 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
+	"github.com/jaredhaight/guppy/internal/util"
+	"github.com/jaredhaight/guppy/pkg/signature"
 	"github.com/spf13/viper"
 )
 
@@ -13,19 +17,75 @@ import (
 type Config struct {
 	Repository   RepositoryConfig `json:"repository" mapstructure:"repository"`
 	CurrentVersion string         `json:"current_version" mapstructure:"current_version"`
+	PinnedVersion  string         `json:"pinned_version,omitempty" mapstructure:"pinned_version"`
 	TargetPath   string           `json:"target_path" mapstructure:"target_path"`
 	Applier      string           `json:"applier" mapstructure:"applier"`
+	BinaryPath   string           `json:"binary_path,omitempty" mapstructure:"binary_path"`
+	StripComponents int           `json:"strip_components,omitempty" mapstructure:"strip_components"`
+	Include      []string         `json:"include,omitempty" mapstructure:"include"`
+	Exclude      []string         `json:"exclude,omitempty" mapstructure:"exclude"`
+	Preserve     []string         `json:"preserve,omitempty" mapstructure:"preserve"`
+	PreserveMode string           `json:"preserve_mode,omitempty" mapstructure:"preserve_mode"`
 	DownloadDir  string           `json:"download_dir" mapstructure:"download_dir"`
+	StateDir     string           `json:"state_dir,omitempty" mapstructure:"state_dir"`
+	Backups      int              `json:"backups" mapstructure:"backups"`
+	KeepReleases int              `json:"keep_releases,omitempty" mapstructure:"keep_releases"`
+	HealthCheck  *HealthCheckConfig `json:"health_check,omitempty" mapstructure:"health_check"`
+	Hooks        *HooksConfig       `json:"hooks,omitempty" mapstructure:"hooks"`
+	Verify       *VerifyConfig      `json:"verify,omitempty" mapstructure:"verify"`
 }
 
 // RepositoryConfig represents repository configuration
 type RepositoryConfig struct {
-	Type      string `json:"type" mapstructure:"type"`
-	Owner     string `json:"owner,omitempty" mapstructure:"owner"`
-	Repo      string `json:"repo,omitempty" mapstructure:"repo"`
-	Token     string `json:"token,omitempty" mapstructure:"token"`
-	AssetName string `json:"asset_name,omitempty" mapstructure:"asset_name"`
-	URL       string `json:"url,omitempty" mapstructure:"url"`
+	Type            string   `json:"type" mapstructure:"type"`
+	Owner           string   `json:"owner,omitempty" mapstructure:"owner"`
+	Repo            string   `json:"repo,omitempty" mapstructure:"repo"`
+	Token           string   `json:"token,omitempty" mapstructure:"token"`
+	AssetName       string   `json:"asset_name,omitempty" mapstructure:"asset_name"`
+	URL             string   `json:"url,omitempty" mapstructure:"url"`
+	BaseURL         string   `json:"base_url,omitempty" mapstructure:"base_url"`
+	TokenType       string   `json:"token_type,omitempty" mapstructure:"token_type"`
+	APIURL          string   `json:"api_url,omitempty" mapstructure:"api_url"`
+	Bucket          string   `json:"bucket,omitempty" mapstructure:"bucket"`
+	Region          string   `json:"region,omitempty" mapstructure:"region"`
+	KeyPattern      string   `json:"key_pattern,omitempty" mapstructure:"key_pattern"`
+	AccessKeyID     string   `json:"access_key_id,omitempty" mapstructure:"access_key_id"`
+	SecretAccessKey string   `json:"secret_access_key,omitempty" mapstructure:"secret_access_key"`
+	Path            string   `json:"path,omitempty" mapstructure:"path"`
+	MediaType       string   `json:"media_type,omitempty" mapstructure:"media_type"`
+	Mirrors         []string `json:"mirrors,omitempty" mapstructure:"mirrors"`
+	MirrorStrategy  string   `json:"mirror_strategy,omitempty" mapstructure:"mirror_strategy"`
+	Channel         string   `json:"channel,omitempty" mapstructure:"channel"`
+}
+
+// HealthCheckConfig describes a check that must pass after an update is applied
+type HealthCheckConfig struct {
+	Command          string `json:"command,omitempty" mapstructure:"command"`
+	ExpectedExitCode int    `json:"expected_exit_code,omitempty" mapstructure:"expected_exit_code"`
+	URL              string `json:"url,omitempty" mapstructure:"url"`
+	Timeout          string `json:"timeout,omitempty" mapstructure:"timeout"`
+	Retries          int    `json:"retries,omitempty" mapstructure:"retries"`
+	RetryDelay       string `json:"retry_delay,omitempty" mapstructure:"retry_delay"`
+}
+
+// HooksConfig lists commands to run at each stage of an update
+type HooksConfig struct {
+	PreDownload []string `json:"pre_download,omitempty" mapstructure:"pre_download"`
+	PreApply    []string `json:"pre_apply,omitempty" mapstructure:"pre_apply"`
+	PostApply   []string `json:"post_apply,omitempty" mapstructure:"post_apply"`
+	OnFailure   []string `json:"on_failure,omitempty" mapstructure:"on_failure"`
+	Timeout     string   `json:"timeout,omitempty" mapstructure:"timeout"`
+}
+
+// VerifyConfig lists the keys trusted to sign releases, and the Sigstore
+// identity trusted to sign them keylessly
+type VerifyConfig struct {
+	PublicKeys                []string `json:"public_keys,omitempty" mapstructure:"public_keys"`
+	Keyring                   string   `json:"keyring,omitempty" mapstructure:"keyring"`
+	TrustedRoot               string   `json:"trusted_root,omitempty" mapstructure:"trusted_root"`
+	CertificateIdentity       string   `json:"certificate_identity,omitempty" mapstructure:"certificate_identity"`
+	CertificateIdentityRegexp string   `json:"certificate_identity_regexp,omitempty" mapstructure:"certificate_identity_regexp"`
+	CertificateOIDCIssuer     string   `json:"certificate_oidc_issuer,omitempty" mapstructure:"certificate_oidc_issuer"`
 }
 
 // Load loads configuration from a JSON file
@@ -50,6 +110,8 @@ func Load(configPath string) (*Config, error) {
 	v.SetDefault("applier", "binary")
 	v.SetDefault("download_dir", filepath.Join(os.TempDir(), "guppy"))
 	v.SetDefault("repository.type", "github")
+	v.SetDefault("backups", 1)
+	v.SetDefault("keep_releases", 3)
 
 	// Read config file
 	if err := v.ReadInConfig(); err != nil {
@@ -91,11 +153,24 @@ func validateConfigKeys(configPath string) error {
 
 	// Define valid top-level keys
 	validKeys := map[string]bool{
-		"repository":      true,
-		"current_version": true,
-		"target_path":     true,
-		"applier":         true,
-		"download_dir":    true,
+		"repository":       true,
+		"current_version":  true,
+		"pinned_version":   true,
+		"target_path":      true,
+		"applier":          true,
+		"binary_path":      true,
+		"strip_components": true,
+		"include":          true,
+		"exclude":          true,
+		"preserve":         true,
+		"preserve_mode":    true,
+		"download_dir":     true,
+		"state_dir":        true,
+		"backups":          true,
+		"keep_releases":    true,
+		"health_check":     true,
+		"hooks":            true,
+		"verify":           true,
 	}
 
 	// Check for unknown top-level keys
@@ -108,12 +183,25 @@ func validateConfigKeys(configPath string) error {
 	// Validate repository keys if present
 	if repo, ok := rawConfig["repository"].(map[string]interface{}); ok {
 		validRepoKeys := map[string]bool{
-			"type":       true,
-			"owner":      true,
-			"repo":       true,
-			"token":      true,
-			"asset_name": true,
-			"url":        true,
+			"type":              true,
+			"owner":             true,
+			"repo":              true,
+			"token":             true,
+			"asset_name":        true,
+			"url":               true,
+			"base_url":          true,
+			"token_type":        true,
+			"api_url":           true,
+			"bucket":            true,
+			"region":            true,
+			"key_pattern":       true,
+			"access_key_id":     true,
+			"secret_access_key": true,
+			"path":              true,
+			"media_type":        true,
+			"mirrors":           true,
+			"mirror_strategy":   true,
+			"channel":           true,
 		}
 
 		for key := range repo {
@@ -123,6 +211,59 @@ func validateConfigKeys(configPath string) error {
 		}
 	}
 
+	// Validate health check keys if present
+	if healthCheck, ok := rawConfig["health_check"].(map[string]interface{}); ok {
+		validHealthCheckKeys := map[string]bool{
+			"command":            true,
+			"expected_exit_code": true,
+			"url":                true,
+			"timeout":            true,
+			"retries":            true,
+			"retry_delay":        true,
+		}
+
+		for key := range healthCheck {
+			if !validHealthCheckKeys[key] {
+				return fmt.Errorf("unknown configuration key in health_check: %s", key)
+			}
+		}
+	}
+
+	// Validate hook keys if present
+	if hooks, ok := rawConfig["hooks"].(map[string]interface{}); ok {
+		validHookKeys := map[string]bool{
+			"pre_download": true,
+			"pre_apply":    true,
+			"post_apply":   true,
+			"on_failure":   true,
+			"timeout":      true,
+		}
+
+		for key := range hooks {
+			if !validHookKeys[key] {
+				return fmt.Errorf("unknown configuration key in hooks: %s", key)
+			}
+		}
+	}
+
+	// Validate verify keys if present
+	if verify, ok := rawConfig["verify"].(map[string]interface{}); ok {
+		validVerifyKeys := map[string]bool{
+			"public_keys":                 true,
+			"keyring":                     true,
+			"trusted_root":                true,
+			"certificate_identity":        true,
+			"certificate_identity_regexp": true,
+			"certificate_oidc_issuer":     true,
+		}
+
+		for key := range verify {
+			if !validVerifyKeys[key] {
+				return fmt.Errorf("unknown configuration key in verify: %s", key)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -133,8 +274,21 @@ func (c *Config) Validate() error {
 	}
 
 	// Validate repository type
-	if c.Repository.Type != "github" && c.Repository.Type != "http" {
-		return fmt.Errorf("invalid repository type: %s (valid values: github, http)", c.Repository.Type)
+	switch c.Repository.Type {
+	case "github", "http", "gitlab", "gitea", "s3", "file", "oci":
+	default:
+		return fmt.Errorf("invalid repository type: %s (valid values: github, http, gitlab, gitea, s3, file, oci)", c.Repository.Type)
+	}
+
+	if c.Repository.Channel != "" {
+		if c.Repository.Type != "github" && c.Repository.Type != "http" {
+			return fmt.Errorf("repository channel is only supported for github and http repositories")
+		}
+		switch c.Repository.Channel {
+		case "stable", "beta", "nightly":
+		default:
+			return fmt.Errorf("invalid channel: %s (valid values: stable, beta, nightly)", c.Repository.Channel)
+		}
 	}
 
 	if c.Repository.Type == "github" {
@@ -147,8 +301,65 @@ func (c *Config) Validate() error {
 	}
 
 	if c.Repository.Type == "http" {
-		if c.Repository.URL == "" {
-			return fmt.Errorf("repository url is required for HTTP")
+		if c.Repository.URL == "" && len(c.Repository.Mirrors) == 0 {
+			return fmt.Errorf("repository url or mirrors is required for HTTP")
+		}
+		if c.Repository.MirrorStrategy != "" && c.Repository.MirrorStrategy != "ordered" && c.Repository.MirrorStrategy != "latency" {
+			return fmt.Errorf("invalid mirror_strategy: %s (valid values: ordered, latency)", c.Repository.MirrorStrategy)
+		}
+	}
+
+	if c.Repository.Type == "gitlab" {
+		if c.Repository.Owner == "" {
+			return fmt.Errorf("repository owner is required for GitLab")
+		}
+		if c.Repository.Repo == "" {
+			return fmt.Errorf("repository repo is required for GitLab")
+		}
+		if c.Repository.TokenType != "" && c.Repository.TokenType != "private" && c.Repository.TokenType != "job" {
+			return fmt.Errorf("invalid token_type: %s (valid values: private, job)", c.Repository.TokenType)
+		}
+	}
+
+	if c.Repository.Type == "gitea" {
+		if c.Repository.BaseURL == "" {
+			return fmt.Errorf("repository base_url is required for Gitea")
+		}
+		if c.Repository.Owner == "" {
+			return fmt.Errorf("repository owner is required for Gitea")
+		}
+		if c.Repository.Repo == "" {
+			return fmt.Errorf("repository repo is required for Gitea")
+		}
+	}
+
+	if c.Repository.Type == "s3" {
+		if c.Repository.Bucket == "" {
+			return fmt.Errorf("repository bucket is required for S3")
+		}
+		if !strings.Contains(c.Repository.KeyPattern, "{version}") {
+			return fmt.Errorf("repository key_pattern is required for S3 and must contain {version}")
+		}
+		if (c.Repository.AccessKeyID == "") != (c.Repository.SecretAccessKey == "") {
+			return fmt.Errorf("repository access_key_id and secret_access_key must be set together")
+		}
+	}
+
+	if c.Repository.Type == "file" {
+		if c.Repository.Path == "" {
+			return fmt.Errorf("repository path is required for file")
+		}
+		if c.Repository.KeyPattern != "" && !strings.Contains(c.Repository.KeyPattern, "{version}") {
+			return fmt.Errorf("repository key_pattern must contain {version}")
+		}
+	}
+
+	if c.Repository.Type == "oci" {
+		if c.Repository.BaseURL == "" {
+			return fmt.Errorf("repository base_url is required for OCI")
+		}
+		if c.Repository.Repo == "" {
+			return fmt.Errorf("repository repo is required for OCI")
 		}
 	}
 
@@ -161,13 +372,127 @@ func (c *Config) Validate() error {
 	}
 
 	// Validate applier type
-	if c.Applier != "binary" && c.Applier != "archive" {
-		return fmt.Errorf("invalid applier type: %s (valid values: binary, archive)", c.Applier)
+	if c.Applier != "binary" && c.Applier != "archive" && c.Applier != "versioned" {
+		return fmt.Errorf("invalid applier type: %s (valid values: binary, archive, versioned)", c.Applier)
+	}
+
+	if c.BinaryPath != "" {
+		if c.Applier != "binary" {
+			return fmt.Errorf("binary_path is only supported with the binary applier")
+		}
+		if _, err := path.Match(c.BinaryPath, ""); err != nil {
+			return fmt.Errorf("invalid binary_path: %w", err)
+		}
+	}
+
+	if c.StripComponents != 0 || len(c.Include) > 0 || len(c.Exclude) > 0 {
+		if c.Applier != "archive" && c.Applier != "versioned" {
+			return fmt.Errorf("strip_components, include and exclude are only supported with the archive and versioned appliers")
+		}
+		if c.StripComponents < 0 {
+			return fmt.Errorf("strip_components must not be negative")
+		}
+		for _, pattern := range append(append([]string{}, c.Include...), c.Exclude...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid include or exclude pattern %q: %w", pattern, err)
+			}
+		}
+	}
+
+	if len(c.Preserve) > 0 || c.PreserveMode != "" {
+		if c.Applier != "archive" {
+			return fmt.Errorf("preserve is only supported with the archive applier")
+		}
+		if c.PreserveMode != "" && c.PreserveMode != "keep" && c.PreserveMode != "new" {
+			return fmt.Errorf("invalid preserve_mode: %s (valid values: keep, new)", c.PreserveMode)
+		}
+		for _, pattern := range c.Preserve {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid preserve pattern %q: %w", pattern, err)
+			}
+		}
+	}
+
+	if c.Applier == "versioned" && c.KeepReleases < 1 {
+		return fmt.Errorf("keep_releases must be at least 1")
+	}
+
+	if c.Backups < 0 {
+		return fmt.Errorf("backups must not be negative")
+	}
+
+	if c.HealthCheck != nil {
+		if err := c.HealthCheck.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Hooks != nil && c.Hooks.Timeout != "" {
+		if _, err := util.ParseInterval(c.Hooks.Timeout); err != nil {
+			return fmt.Errorf("invalid hooks timeout: %w", err)
+		}
+	}
+
+	if c.Verify != nil {
+		if err := c.Verify.Validate(); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// Validate validates the health check configuration
+func (h *HealthCheckConfig) Validate() error {
+	if h.Command == "" && h.URL == "" {
+		return fmt.Errorf("health_check requires a command or url")
+	}
+	if h.Retries < 0 {
+		return fmt.Errorf("health_check retries must not be negative")
+	}
+	if h.Timeout != "" {
+		if _, err := util.ParseInterval(h.Timeout); err != nil {
+			return fmt.Errorf("invalid health_check timeout: %w", err)
+		}
+	}
+	if h.RetryDelay != "" {
+		if _, err := util.ParseInterval(h.RetryDelay); err != nil {
+			return fmt.Errorf("invalid health_check retry_delay: %w", err)
+		}
+	}
+	return nil
+}
+
+// Validate validates the signature verification configuration
+func (v *VerifyConfig) Validate() error {
+	if len(v.PublicKeys) == 0 && v.Keyring == "" && v.TrustedRoot == "" {
+		return fmt.Errorf("verify requires public_keys, a keyring or a trusted_root")
+	}
+	if _, err := signature.NewVerifier(v.PublicKeys); err != nil {
+		return fmt.Errorf("invalid verify public_keys: %w", err)
+	}
+	if v.Keyring != "" {
+		if _, err := signature.LoadKeyring(v.Keyring); err != nil {
+			return fmt.Errorf("invalid verify keyring: %w", err)
+		}
+	}
+	if v.TrustedRoot != "" || v.CertificateIdentity != "" || v.CertificateIdentityRegexp != "" || v.CertificateOIDCIssuer != "" {
+		if v.TrustedRoot == "" {
+			return fmt.Errorf("verify certificate_identity and certificate_oidc_issuer require a trusted_root")
+		}
+		if _, err := v.SigstoreVerifier(); err != nil {
+			return fmt.Errorf("invalid verify Sigstore settings: %w", err)
+		}
+	}
+	return nil
+}
+
+// SigstoreVerifier returns a verifier for Sigstore bundles signed by the
+// configured certificate identity and OIDC issuer
+func (v *VerifyConfig) SigstoreVerifier() (*signature.SigstoreVerifier, error) {
+	return signature.NewSigstoreVerifier(v.TrustedRoot, v.CertificateIdentity, v.CertificateIdentityRegexp, v.CertificateOIDCIssuer)
+}
+
 // Save saves the configuration to a JSON file
 func (c *Config) Save(configPath string) error {
 	v := viper.New()
@@ -176,9 +501,46 @@ func (c *Config) Save(configPath string) error {
 	// Set all config values
 	v.Set("repository", c.Repository)
 	v.Set("current_version", c.CurrentVersion)
+	if c.PinnedVersion != "" {
+		v.Set("pinned_version", c.PinnedVersion)
+	}
 	v.Set("target_path", c.TargetPath)
 	v.Set("applier", c.Applier)
+	if c.BinaryPath != "" {
+		v.Set("binary_path", c.BinaryPath)
+	}
+	if c.StripComponents != 0 {
+		v.Set("strip_components", c.StripComponents)
+	}
+	if len(c.Include) > 0 {
+		v.Set("include", c.Include)
+	}
+	if len(c.Exclude) > 0 {
+		v.Set("exclude", c.Exclude)
+	}
+	if len(c.Preserve) > 0 {
+		v.Set("preserve", c.Preserve)
+	}
+	if c.PreserveMode != "" {
+		v.Set("preserve_mode", c.PreserveMode)
+	}
 	v.Set("download_dir", c.DownloadDir)
+	v.Set("backups", c.Backups)
+	if c.Applier == "versioned" {
+		v.Set("keep_releases", c.KeepReleases)
+	}
+	if c.StateDir != "" {
+		v.Set("state_dir", c.StateDir)
+	}
+	if c.HealthCheck != nil {
+		v.Set("health_check", c.HealthCheck)
+	}
+	if c.Hooks != nil {
+		v.Set("hooks", c.Hooks)
+	}
+	if c.Verify != nil {
+		v.Set("verify", c.Verify)
+	}
 
 	// Create directory if it doesn't exist
 	dir := filepath.Dir(configPath)
@@ -1,9 +1,21 @@
 package config
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"math/big"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
 )
 
 func TestLoad_GitHubConfig(t *testing.T) {
@@ -100,6 +112,468 @@ func TestLoad_HTTPConfig(t *testing.T) {
 	}
 }
 
+func TestLoad_HTTPMirrors(t *testing.T) {
+	tempDir := t.TempDir()
+
+	configPath := filepath.Join(tempDir, "guppy.json")
+	configContent := `{
+  "repository": {
+    "type": "http",
+    "mirrors": [
+      "https://mirror1.example.com/releases.json",
+      "https://mirror2.example.com/releases.json"
+    ],
+    "mirror_strategy": "latency"
+  },
+  "target_path": "/opt/myapp/bin/app"
+}`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	config, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if len(config.Repository.Mirrors) != 2 || config.Repository.Mirrors[1] != "https://mirror2.example.com/releases.json" {
+		t.Errorf("Repository.Mirrors = %v, want both mirrors in order", config.Repository.Mirrors)
+	}
+	if config.Repository.MirrorStrategy != "latency" {
+		t.Errorf("Repository.MirrorStrategy = %s, want latency", config.Repository.MirrorStrategy)
+	}
+}
+
+func TestLoad_PinnedVersion(t *testing.T) {
+	tempDir := t.TempDir()
+
+	configPath := filepath.Join(tempDir, "guppy.json")
+	configContent := `{
+  "repository": {
+    "type": "http",
+    "url": "https://example.com/releases.json"
+  },
+  "current_version": "1.2.0",
+  "pinned_version": "1.1.0",
+  "target_path": "/opt/myapp/bin/app"
+}`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	config, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if config.PinnedVersion != "1.1.0" {
+		t.Errorf("PinnedVersion = %s, want 1.1.0", config.PinnedVersion)
+	}
+
+	// The pin survives a save, e.g. after an update changes current_version
+	config.CurrentVersion = "1.1.0"
+	if err := config.Save(configPath); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	saved, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() after Save() failed: %v", err)
+	}
+	if saved.PinnedVersion != "1.1.0" {
+		t.Errorf("PinnedVersion after save = %s, want 1.1.0", saved.PinnedVersion)
+	}
+}
+
+func TestLoad_Backups(t *testing.T) {
+	tempDir := t.TempDir()
+
+	configPath := filepath.Join(tempDir, "guppy.json")
+	configContent := `{
+  "repository": {
+    "type": "http",
+    "url": "https://example.com/releases.json"
+  },
+  "target_path": "/opt/myapp/bin/app"
+}`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	config, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if config.Backups != 1 {
+		t.Errorf("Backups = %d, want default of 1", config.Backups)
+	}
+
+	// Disabling backups survives a save
+	config.Backups = 0
+	config.StateDir = "/var/lib/guppy"
+	if err := config.Save(configPath); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	saved, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() after Save() failed: %v", err)
+	}
+	if saved.Backups != 0 {
+		t.Errorf("Backups after save = %d, want 0", saved.Backups)
+	}
+	if saved.StateDir != "/var/lib/guppy" {
+		t.Errorf("StateDir after save = %s, want /var/lib/guppy", saved.StateDir)
+	}
+
+	saved.Backups = -1
+	if err := saved.Validate(); err == nil {
+		t.Error("Validate() expected error for negative backups, got nil")
+	}
+}
+
+func TestLoad_HealthCheck(t *testing.T) {
+	tempDir := t.TempDir()
+
+	configPath := filepath.Join(tempDir, "guppy.json")
+	configContent := `{
+  "repository": {
+    "type": "http",
+    "url": "https://example.com/releases.json"
+  },
+  "target_path": "/opt/myapp/bin/app",
+  "health_check": {
+    "command": "/opt/myapp/bin/app --version",
+    "expected_exit_code": 0,
+    "url": "http://localhost:8080/healthz",
+    "timeout": "10s",
+    "retries": 3,
+    "retry_delay": "2s"
+  }
+}`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	config, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if config.HealthCheck == nil {
+		t.Fatal("HealthCheck = nil, want a health check")
+	}
+	if config.HealthCheck.URL != "http://localhost:8080/healthz" {
+		t.Errorf("HealthCheck.URL = %s, want http://localhost:8080/healthz", config.HealthCheck.URL)
+	}
+	if config.HealthCheck.Retries != 3 {
+		t.Errorf("HealthCheck.Retries = %d, want 3", config.HealthCheck.Retries)
+	}
+
+	if err := config.Save(configPath); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	saved, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() after Save() failed: %v", err)
+	}
+	if saved.HealthCheck == nil || *saved.HealthCheck != *config.HealthCheck {
+		t.Errorf("HealthCheck after save = %+v, want %+v", saved.HealthCheck, config.HealthCheck)
+	}
+}
+
+func TestLoad_Hooks(t *testing.T) {
+	tempDir := t.TempDir()
+
+	configPath := filepath.Join(tempDir, "guppy.json")
+	configContent := `{
+  "repository": {
+    "type": "http",
+    "url": "https://example.com/releases.json"
+  },
+  "target_path": "/opt/myapp/bin/app",
+  "hooks": {
+    "pre_apply": ["systemctl stop myapp"],
+    "post_apply": ["/opt/myapp/bin/app migrate", "systemctl start myapp"],
+    "on_failure": ["systemctl start myapp"],
+    "timeout": "2m"
+  }
+}`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	config, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if config.Hooks == nil {
+		t.Fatal("Hooks = nil, want hooks")
+	}
+	if len(config.Hooks.PostApply) != 2 || config.Hooks.PostApply[1] != "systemctl start myapp" {
+		t.Errorf("Hooks.PostApply = %v, want two commands", config.Hooks.PostApply)
+	}
+	if len(config.Hooks.PreDownload) != 0 {
+		t.Errorf("Hooks.PreDownload = %v, want none", config.Hooks.PreDownload)
+	}
+
+	if err := config.Save(configPath); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	saved, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() after Save() failed: %v", err)
+	}
+	if saved.Hooks == nil || len(saved.Hooks.PreApply) != 1 || saved.Hooks.Timeout != "2m" {
+		t.Errorf("Hooks after save = %+v, want %+v", saved.Hooks, config.Hooks)
+	}
+
+	saved.Hooks.Timeout = "eventually"
+	if err := saved.Validate(); err == nil {
+		t.Error("Validate() expected error for invalid hooks timeout, got nil")
+	}
+}
+
+func TestLoad_Verify(t *testing.T) {
+	tempDir := t.TempDir()
+
+	configPath := filepath.Join(tempDir, "guppy.json")
+	configContent := `{
+  "repository": {
+    "type": "http",
+    "url": "https://example.com/releases.json"
+  },
+  "target_path": "/opt/myapp/bin/app",
+  "verify": {
+    "public_keys": [
+      "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3",
+      "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="
+    ]
+  }
+}`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	config, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if config.Verify == nil || len(config.Verify.PublicKeys) != 2 {
+		t.Fatalf("Verify = %+v, want two public keys", config.Verify)
+	}
+
+	if err := config.Save(configPath); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	saved, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() after Save() failed: %v", err)
+	}
+	if saved.Verify == nil || len(saved.Verify.PublicKeys) != 2 {
+		t.Errorf("Verify after save = %+v, want %+v", saved.Verify, config.Verify)
+	}
+
+	saved.Verify.PublicKeys = append(saved.Verify.PublicKeys, "not a key")
+	if err := saved.Validate(); err == nil {
+		t.Error("Validate() expected error for invalid public key, got nil")
+	}
+
+	saved.Verify.PublicKeys = nil
+	if err := saved.Validate(); err == nil {
+		t.Error("Validate() expected error for no public keys, got nil")
+	}
+}
+
+func TestValidate_VerifyKeyring(t *testing.T) {
+	tempDir := t.TempDir()
+
+	entity, err := openpgp.NewEntity("release", "", "release@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	if err != nil {
+		t.Fatalf("NewEntity() failed: %v", err)
+	}
+	var keyring bytes.Buffer
+	w, _ := armor.Encode(&keyring, openpgp.PublicKeyType, nil)
+	_ = entity.Serialize(w)
+	_ = w.Close()
+	keyringPath := filepath.Join(tempDir, "keyring.asc")
+	if err := os.WriteFile(keyringPath, keyring.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write keyring: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		verify  VerifyConfig
+		wantErr bool
+	}{
+		{name: "keyring only", verify: VerifyConfig{Keyring: keyringPath}},
+		{name: "keyring and public keys", verify: VerifyConfig{Keyring: keyringPath, PublicKeys: []string{"11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="}}},
+		{name: "missing keyring", verify: VerifyConfig{Keyring: filepath.Join(tempDir, "missing.asc")}, wantErr: true},
+		{name: "keyring is not armored", verify: VerifyConfig{Keyring: filepath.Join(tempDir, "bogus.asc")}, wantErr: true},
+		{name: "nothing trusted", verify: VerifyConfig{}, wantErr: true},
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "bogus.asc"), []byte("not a keyring"), 0644); err != nil {
+		t.Fatalf("Failed to write keyring: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.verify.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_VerifySigstore(t *testing.T) {
+	tempDir := t.TempDir()
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() failed: %v", err)
+	}
+	logPublic, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	root, _ := json.Marshal(map[string]any{
+		"tlogs":                  []any{map[string]any{"publicKey": map[string]any{"rawBytes": logPublic}, "logId": map[string]any{"keyId": []byte("log")}}},
+		"certificateAuthorities": []any{map[string]any{"certChain": map[string]any{"certificates": []any{map[string]any{"rawBytes": caDER}}}}},
+	})
+	rootPath := filepath.Join(tempDir, "trusted_root.json")
+	if err := os.WriteFile(rootPath, root, 0644); err != nil {
+		t.Fatalf("Failed to write trusted root: %v", err)
+	}
+
+	identity := "https://github.com/owner/repo/.github/workflows/release.yml@refs/tags/v1.0.0"
+	issuer := "https://token.actions.githubusercontent.com"
+
+	tests := []struct {
+		name    string
+		verify  VerifyConfig
+		wantErr bool
+	}{
+		{name: "identity", verify: VerifyConfig{TrustedRoot: rootPath, CertificateIdentity: identity, CertificateOIDCIssuer: issuer}},
+		{name: "identity regexp", verify: VerifyConfig{TrustedRoot: rootPath, CertificateIdentityRegexp: `^https://github\.com/owner/repo/`, CertificateOIDCIssuer: issuer}},
+		{name: "missing identity", verify: VerifyConfig{TrustedRoot: rootPath, CertificateOIDCIssuer: issuer}, wantErr: true},
+		{name: "missing issuer", verify: VerifyConfig{TrustedRoot: rootPath, CertificateIdentity: identity}, wantErr: true},
+		{name: "invalid identity regexp", verify: VerifyConfig{TrustedRoot: rootPath, CertificateIdentityRegexp: "(", CertificateOIDCIssuer: issuer}, wantErr: true},
+		{name: "identity without trusted root", verify: VerifyConfig{CertificateIdentity: identity, CertificateOIDCIssuer: issuer}, wantErr: true},
+		{name: "missing trusted root", verify: VerifyConfig{TrustedRoot: filepath.Join(tempDir, "missing.json"), CertificateIdentity: identity, CertificateOIDCIssuer: issuer}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.verify.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_HealthCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		healthCheck HealthCheckConfig
+		wantErr     bool
+	}{
+		{
+			name:        "command only",
+			healthCheck: HealthCheckConfig{Command: "true"},
+			wantErr:     false,
+		},
+		{
+			name:        "url only",
+			healthCheck: HealthCheckConfig{URL: "http://localhost/healthz", Timeout: "5s"},
+			wantErr:     false,
+		},
+		{
+			name:        "no command or url",
+			healthCheck: HealthCheckConfig{Timeout: "5s"},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid timeout",
+			healthCheck: HealthCheckConfig{Command: "true", Timeout: "soon"},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid retry delay",
+			healthCheck: HealthCheckConfig{Command: "true", RetryDelay: "later"},
+			wantErr:     true,
+		},
+		{
+			name:        "negative retries",
+			healthCheck: HealthCheckConfig{Command: "true", Retries: -1},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			healthCheck := tt.healthCheck
+			config := &Config{
+				Repository:  RepositoryConfig{Type: "http", URL: "https://example.com/releases.json"},
+				TargetPath:  "/opt/myapp/bin/app",
+				Applier:     "binary",
+				HealthCheck: &healthCheck,
+			}
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_GitLabConfig(t *testing.T) {
+	tempDir := t.TempDir()
+
+	configPath := filepath.Join(tempDir, "guppy.json")
+	configContent := `{
+  "repository": {
+    "type": "gitlab",
+    "base_url": "https://gitlab.example.com",
+    "owner": "group/subgroup",
+    "repo": "project",
+    "token": "glpat-token",
+    "token_type": "private",
+    "asset_name": "app-linux-amd64"
+  },
+  "target_path": "/usr/local/bin/app",
+  "applier": "binary"
+}`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	config, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if config.Repository.Type != "gitlab" {
+		t.Errorf("Repository.Type = %s, want gitlab", config.Repository.Type)
+	}
+	if config.Repository.BaseURL != "https://gitlab.example.com" {
+		t.Errorf("Repository.BaseURL = %s, want https://gitlab.example.com", config.Repository.BaseURL)
+	}
+	if config.Repository.TokenType != "private" {
+		t.Errorf("Repository.TokenType = %s, want private", config.Repository.TokenType)
+	}
+}
+
 func TestLoad_WithDefaults(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -239,6 +713,206 @@ func TestValidate_ValidHTTPConfig(t *testing.T) {
 	}
 }
 
+func TestValidate_ValidGitLabConfig(t *testing.T) {
+	config := &Config{
+		Repository: RepositoryConfig{
+			Type:      "gitlab",
+			Owner:     "group/subgroup",
+			Repo:      "project",
+			BaseURL:   "https://gitlab.example.com",
+			TokenType: "job",
+		},
+		TargetPath: "/usr/local/bin/app",
+		Applier:    "binary",
+	}
+
+	err := config.Validate()
+	if err != nil {
+		t.Errorf("Validate() failed for valid GitLab config: %v", err)
+	}
+}
+
+func TestValidate_GitLabMissingRepo(t *testing.T) {
+	config := &Config{
+		Repository: RepositoryConfig{
+			Type:  "gitlab",
+			Owner: "group",
+		},
+		TargetPath: "/usr/local/bin/app",
+		Applier:    "binary",
+	}
+
+	err := config.Validate()
+	if err == nil {
+		t.Error("Validate() expected error for missing GitLab repo, got nil")
+	}
+}
+
+func TestValidate_GitLabInvalidTokenType(t *testing.T) {
+	config := &Config{
+		Repository: RepositoryConfig{
+			Type:      "gitlab",
+			Owner:     "group",
+			Repo:      "project",
+			TokenType: "oauth",
+		},
+		TargetPath: "/usr/local/bin/app",
+		Applier:    "binary",
+	}
+
+	err := config.Validate()
+	if err == nil {
+		t.Error("Validate() expected error for invalid token_type, got nil")
+	}
+}
+
+func TestValidate_ValidGiteaConfig(t *testing.T) {
+	config := &Config{
+		Repository: RepositoryConfig{
+			Type:    "gitea",
+			BaseURL: "https://forgejo.example.com",
+			Owner:   "tools",
+			Repo:    "app",
+		},
+		TargetPath: "/usr/local/bin/app",
+		Applier:    "binary",
+	}
+
+	err := config.Validate()
+	if err != nil {
+		t.Errorf("Validate() failed for valid Gitea config: %v", err)
+	}
+}
+
+func TestValidate_GiteaMissingBaseURL(t *testing.T) {
+	config := &Config{
+		Repository: RepositoryConfig{
+			Type:  "gitea",
+			Owner: "tools",
+			Repo:  "app",
+		},
+		TargetPath: "/usr/local/bin/app",
+		Applier:    "binary",
+	}
+
+	err := config.Validate()
+	if err == nil {
+		t.Error("Validate() expected error for missing Gitea base_url, got nil")
+	}
+}
+
+func TestValidate_S3Config(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    RepositoryConfig
+		wantErr bool
+	}{
+		{
+			name: "valid anonymous bucket",
+			repo: RepositoryConfig{Type: "s3", Bucket: "releases", KeyPattern: "myapp/{version}/myapp"},
+		},
+		{
+			name: "valid with credentials",
+			repo: RepositoryConfig{
+				Type:            "s3",
+				BaseURL:         "https://minio.example.com",
+				Bucket:          "releases",
+				Region:          "eu-west-1",
+				KeyPattern:      "myapp/{version}/myapp",
+				AccessKeyID:     "AKID",
+				SecretAccessKey: "secret",
+			},
+		},
+		{
+			name:    "missing bucket",
+			repo:    RepositoryConfig{Type: "s3", KeyPattern: "myapp/{version}/myapp"},
+			wantErr: true,
+		},
+		{
+			name:    "key pattern without version",
+			repo:    RepositoryConfig{Type: "s3", Bucket: "releases", KeyPattern: "myapp/latest/myapp"},
+			wantErr: true,
+		},
+		{
+			name:    "access key without secret",
+			repo:    RepositoryConfig{Type: "s3", Bucket: "releases", KeyPattern: "myapp/{version}/myapp", AccessKeyID: "AKID"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Repository: tt.repo,
+				TargetPath: "/usr/local/bin/app",
+				Applier:    "binary",
+			}
+
+			err := config.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("Validate() expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Validate() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_FileConfig(t *testing.T) {
+	valid := &Config{
+		Repository: RepositoryConfig{Type: "file", Path: "/mnt/releases", KeyPattern: "{version}/app"},
+		TargetPath: "/usr/local/bin/app",
+		Applier:    "binary",
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() failed for valid file config: %v", err)
+	}
+
+	missingPath := &Config{
+		Repository: RepositoryConfig{Type: "file"},
+		TargetPath: "/usr/local/bin/app",
+		Applier:    "binary",
+	}
+	if err := missingPath.Validate(); err == nil {
+		t.Error("Validate() expected error for missing path, got nil")
+	}
+
+	badPattern := &Config{
+		Repository: RepositoryConfig{Type: "file", Path: "/mnt/releases", KeyPattern: "latest/app"},
+		TargetPath: "/usr/local/bin/app",
+		Applier:    "binary",
+	}
+	if err := badPattern.Validate(); err == nil {
+		t.Error("Validate() expected error for key_pattern without {version}, got nil")
+	}
+}
+
+func TestValidate_OCIConfig(t *testing.T) {
+	valid := &Config{
+		Repository: RepositoryConfig{
+			Type:      "oci",
+			BaseURL:   "https://registry.example.com",
+			Repo:      "team/myapp",
+			MediaType: "application/vnd.myapp.binary",
+		},
+		TargetPath: "/usr/local/bin/app",
+		Applier:    "binary",
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() failed for valid OCI config: %v", err)
+	}
+
+	missingRepo := &Config{
+		Repository: RepositoryConfig{Type: "oci", BaseURL: "https://registry.example.com"},
+		TargetPath: "/usr/local/bin/app",
+		Applier:    "binary",
+	}
+	if err := missingRepo.Validate(); err == nil {
+		t.Error("Validate() expected error for missing OCI repo, got nil")
+	}
+}
+
 func TestValidate_MissingRepositoryType(t *testing.T) {
 	config := &Config{
 		TargetPath: "/usr/local/bin/app",
@@ -313,6 +987,62 @@ func TestValidate_HTTPMissingURL(t *testing.T) {
 	}
 }
 
+func TestValidate_HTTPInvalidMirrorStrategy(t *testing.T) {
+	config := &Config{
+		Repository: RepositoryConfig{
+			Type:           "http",
+			URL:            "https://example.com/releases.json",
+			MirrorStrategy: "random",
+		},
+		TargetPath: "/usr/local/bin/app",
+		Applier:    "binary",
+	}
+
+	err := config.Validate()
+	if err == nil {
+		t.Error("Validate() expected error for invalid mirror_strategy, got nil")
+	}
+}
+
+func TestValidate_Channel(t *testing.T) {
+	tests := []struct {
+		name     string
+		repoType string
+		channel  string
+		wantErr  bool
+	}{
+		{name: "github beta", repoType: "github", channel: "beta"},
+		{name: "http nightly", repoType: "http", channel: "nightly"},
+		{name: "unknown channel", repoType: "github", channel: "canary", wantErr: true},
+		{name: "unsupported provider", repoType: "gitea", channel: "beta", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Repository: RepositoryConfig{
+					Type:    tt.repoType,
+					Owner:   "owner",
+					Repo:    "repo",
+					URL:     "https://example.com/releases.json",
+					BaseURL: "https://gitea.example.com",
+					Channel: tt.channel,
+				},
+				TargetPath: "/usr/local/bin/app",
+				Applier:    "binary",
+			}
+
+			err := config.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("Validate() expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Validate() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
 func TestValidate_MissingTargetPath(t *testing.T) {
 	config := &Config{
 		Repository: RepositoryConfig{
@@ -362,6 +1092,138 @@ func TestValidate_InvalidApplierType(t *testing.T) {
 	}
 }
 
+func TestValidate_VersionedApplier(t *testing.T) {
+	config := &Config{
+		Repository: RepositoryConfig{
+			Type:  "github",
+			Owner: "testowner",
+			Repo:  "testrepo",
+		},
+		TargetPath:   "/opt/myapp",
+		Applier:      "versioned",
+		KeepReleases: 3,
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() failed for versioned applier: %v", err)
+	}
+
+	config.KeepReleases = 0
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() expected error for keep_releases of 0, got nil")
+	}
+}
+
+func TestValidate_BinaryPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		applier    string
+		binaryPath string
+		wantErr    bool
+	}{
+		{"glob with binary applier", "binary", "*/bin/gh", false},
+		{"exact path", "binary", "gh_linux_amd64/bin/gh", false},
+		{"archive applier", "archive", "*/bin/gh", true},
+		{"malformed glob", "binary", "[bin/gh", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Repository: RepositoryConfig{
+					Type:  "github",
+					Owner: "cli",
+					Repo:  "cli",
+				},
+				TargetPath: "/usr/local/bin/gh",
+				Applier:    tt.applier,
+				BinaryPath: tt.binaryPath,
+			}
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_ArchiveFilters(t *testing.T) {
+	tests := []struct {
+		name            string
+		applier         string
+		stripComponents int
+		include         []string
+		exclude         []string
+		wantErr         bool
+	}{
+		{"archive applier", "archive", 1, []string{"bin"}, []string{"docs"}, false},
+		{"versioned applier", "versioned", 1, nil, nil, false},
+		{"binary applier", "binary", 1, nil, nil, true},
+		{"negative strip_components", "archive", -1, nil, nil, true},
+		{"malformed include", "archive", 0, []string{"[bin"}, nil, true},
+		{"malformed exclude", "archive", 0, nil, []string{"docs/["}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Repository: RepositoryConfig{
+					Type:  "github",
+					Owner: "testowner",
+					Repo:  "testrepo",
+				},
+				TargetPath:      "/opt/myapp/bin/myapp",
+				Applier:         tt.applier,
+				KeepReleases:    3,
+				StripComponents: tt.stripComponents,
+				Include:         tt.include,
+				Exclude:         tt.exclude,
+			}
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Preserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		applier  string
+		preserve []string
+		mode     string
+		wantErr  bool
+	}{
+		{"archive applier", "archive", []string{"config.yaml", "conf.d"}, "", false},
+		{"keep mode", "archive", []string{"*.yaml"}, "keep", false},
+		{"new mode", "archive", []string{"*.yaml"}, "new", false},
+		{"invalid mode", "archive", []string{"*.yaml"}, "overwrite", true},
+		{"binary applier", "binary", []string{"*.yaml"}, "", true},
+		{"malformed pattern", "archive", []string{"[conf"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Repository: RepositoryConfig{
+					Type:  "github",
+					Owner: "testowner",
+					Repo:  "testrepo",
+				},
+				TargetPath:   "/opt/myapp/bin/myapp",
+				Applier:      tt.applier,
+				Preserve:     tt.preserve,
+				PreserveMode: tt.mode,
+			}
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestSave(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -565,6 +1427,31 @@ func TestValidateConfigKeys_UnknownRepoKey(t *testing.T) {
 	}
 }
 
+func TestValidateConfigKeys_UnknownHealthCheckKey(t *testing.T) {
+	tempDir := t.TempDir()
+
+	configPath := filepath.Join(tempDir, "unknown-health-check.json")
+	configContent := `{
+  "repository": {
+    "type": "github"
+  },
+  "target_path": "/usr/local/bin/app",
+  "health_check": {
+    "command": "true",
+    "interval": "5s"
+  }
+}`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	err := validateConfigKeys(configPath)
+	if err == nil {
+		t.Error("validateConfigKeys() expected error for unknown health_check key, got nil")
+	}
+}
+
 func TestLoad_EmptyConfigFile(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -590,7 +1477,8 @@ func TestLoad_AllRepositoryFields(t *testing.T) {
     "repo": "testrepo",
     "token": "ghp_token",
     "asset_name": "app-linux",
-    "url": "https://example.com"
+    "url": "https://example.com",
+    "api_url": "https://github.example.com/api/v3"
   },
   "target_path": "/usr/local/bin/app",
   "applier": "binary"
@@ -624,4 +1512,7 @@ func TestLoad_AllRepositoryFields(t *testing.T) {
 	if config.Repository.URL != "https://example.com" {
 		t.Errorf("Repository.URL = %s, want https://example.com", config.Repository.URL)
 	}
+	if config.Repository.APIURL != "https://github.example.com/api/v3" {
+		t.Errorf("Repository.APIURL = %s, want https://github.example.com/api/v3", config.Repository.APIURL)
+	}
 }
@@ -0,0 +1,21 @@
+package util
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+)
+
+// ShellCommand returns a command that runs the given command line through the
+// system shell (sh on Unix, cmd on Windows)
+// When the context is done, the shell and any processes it started are killed
+func ShellCommand(ctx context.Context, command string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	killProcessGroup(cmd)
+	return cmd
+}
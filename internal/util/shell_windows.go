@@ -0,0 +1,9 @@
+//go:build windows
+
+package util
+
+import "os/exec"
+
+// killProcessGroup is a no-op on Windows, where cancellation kills only the
+// shell process
+func killProcessGroup(cmd *exec.Cmd) {}
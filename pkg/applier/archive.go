@@ -3,10 +3,12 @@ package applier
 import (
 	"archive/tar"
 	"archive/zip"
-	"compress/gzip"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -16,31 +18,241 @@ type ArchiveApplier struct {
 	// ExtractPath is the path where the archive will be extracted
 	// If empty, extracts to the directory containing the target
 	ExtractPath string
+
+	// StripComponents removes this many leading path components from each
+	// member, like tar --strip-components
+	StripComponents int
+
+	// Include and Exclude are globs matched against member paths after
+	// stripping; a pattern that matches a directory applies to its contents
+	// When Include is set, only matching members are extracted
+	Include []string
+	Exclude []string
+
+	// Preserve lists globs, matched like Include, for files such as
+	// configuration that should not be replaced if they already exist
+	Preserve []string
+	// PreserveMode is PreserveKeep or PreserveNew; empty means PreserveNew
+	PreserveMode string
+
+	// PreviousFiles lists the files, relative to the extract path, that the
+	// previously installed release put there
+	// Apply removes those the new release no longer contains, other than
+	// files matching Preserve; files in neither release are never touched
+	PreviousFiles []string
+
+	// Preserved lists the files, relative to the extract path, that the last
+	// Apply left in place because they differ from the release
+	Preserved []string
+	// Installed lists the files, relative to the extract path and separated
+	// by slashes, that the last Apply extracted, including the new versions
+	// of preserved files written in PreserveNew mode
+	Installed []string
+	// Removed lists the files from PreviousFiles that the last Apply removed
+	Removed []string
+
+	// live is the extract path while Apply extracts into a staging directory,
+	// where existing files are looked up for Preserve
+	live string
 }
 
+// Preserve modes
+const (
+	// PreserveKeep leaves the existing file and discards the new version
+	PreserveKeep = "keep"
+	// PreserveNew leaves the existing file and writes the new version next to it
+	PreserveNew = "new"
+)
+
+// PreservedSuffix is appended to the new version of a preserved file in PreserveNew mode
+const PreservedSuffix = ".guppy-new"
+
 // NewArchiveApplier creates a new archive applier
 func NewArchiveApplier() *ArchiveApplier {
 	return &ArchiveApplier{}
 }
 
 // Apply extracts an archive to the target location
+// The archive is extracted into a staging directory first and only the files
+// it contains, and the stale files it removes, are then moved into place, so
+// a failure partway through leaves the extract path as it was. Other files in
+// the extract path are never touched.
 func (a *ArchiveApplier) Apply(source string, target string) error {
-	// Determine extract path
-	extractPath := a.ExtractPath
-	if extractPath == "" {
-		extractPath = filepath.Dir(target)
+	extractPath := a.extractPath(target)
+
+	if !isArchive(source) {
+		return fmt.Errorf("unsupported archive format: %s", source)
 	}
 
-	// Determine archive type by extension
-	if strings.HasSuffix(source, ".zip") {
-		return a.extractZip(source, extractPath)
-	} else if strings.HasSuffix(source, ".tar.gz") || strings.HasSuffix(source, ".tgz") {
-		return a.extractTarGz(source, extractPath)
-	} else {
+	a.Preserved = nil
+	a.Installed = nil
+	a.Removed = nil
+	a.live = extractPath
+	defer func() { a.live = "" }()
+
+	removed, err := stageFiles(extractPath, func(staging string) ([]string, error) {
+		if err := a.extract(source, staging); err != nil {
+			return nil, err
+		}
+		return a.staleFiles(a.Installed), nil
+	})
+	if err != nil {
+		return err
+	}
+	a.Removed = removed
+	return nil
+}
+
+// Recover undoes an Apply to the target that was interrupted, such as by a
+// crash, and reports whether there was one
+func (a *ArchiveApplier) Recover(target string) (bool, error) {
+	return recoverStaged(a.extractPath(target))
+}
+
+// extractPath returns the directory the archive is extracted into
+func (a *ArchiveApplier) extractPath(target string) string {
+	if a.ExtractPath != "" {
+		return a.ExtractPath
+	}
+	return filepath.Dir(target)
+}
+
+// staleFiles returns the files in PreviousFiles that are not in installed,
+// leaving out any that match Preserve
+func (a *ArchiveApplier) staleFiles(installed []string) []string {
+	current := make(map[string]bool, len(installed))
+	for _, name := range installed {
+		current[name] = true
+	}
+
+	var stale []string
+	for _, name := range a.PreviousFiles {
+		if !current[name] && !matchesAny(a.Preserve, name) {
+			stale = append(stale, name)
+		}
+	}
+	return stale
+}
+
+// extract writes the contents of an archive into dest
+func (a *ArchiveApplier) extract(source string, dest string) error {
+	f, err := detectFormat(source)
+	if err != nil {
+		return err
+	}
+
+	switch f.Archive {
+	case archiveZip:
+		return a.extractZip(source, dest)
+	case archiveTar:
+		return a.extractTar(source, f.Compression, dest)
+	default:
 		return fmt.Errorf("unsupported archive format: %s", source)
 	}
 }
 
+// Targets returns the paths of the files and symlinks in the archive, joined to
+// the extract path, along with the stale files from PreviousFiles that Apply removes
+func (a *ArchiveApplier) Targets(source string, target string) ([]string, error) {
+	extractPath := a.extractPath(target)
+
+	f, err := detectFormat(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	switch f.Archive {
+	case archiveZip:
+		reader, err := zip.OpenReader(source)
+		if err != nil {
+			return nil, fmt.Errorf("error opening zip file: %w", err)
+		}
+		defer func() { _ = reader.Close() }()
+
+		for _, file := range reader.File {
+			if name, ok := a.memberPath(file.Name); ok && !file.FileInfo().IsDir() {
+				names = append(names, name)
+			}
+		}
+	case archiveTar:
+		err := readTar(source, f.Compression, func(header *tar.Header, _ io.Reader) error {
+			if name, ok := a.memberPath(header.Name); ok && header.Typeflag == tar.TypeReg {
+				names = append(names, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", source)
+	}
+
+	installed := make([]string, 0, len(names))
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		installed = append(installed, filepath.ToSlash(name))
+		path := filepath.Join(extractPath, name)
+		if !strings.HasPrefix(path, filepath.Clean(extractPath)+string(os.PathSeparator)) {
+			return nil, fmt.Errorf("illegal file path: %s", path)
+		}
+		if a.shouldPreserve(path, name) {
+			// The existing file is left alone; only the new version may be written
+			if a.PreserveMode == PreserveKeep {
+				continue
+			}
+			path += PreservedSuffix
+		}
+		paths = append(paths, path)
+	}
+
+	for _, name := range a.staleFiles(installed) {
+		path := filepath.Join(extractPath, filepath.FromSlash(name))
+		if _, err := os.Lstat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// memberPath maps an archive member name to its path under the extract
+// directory, applying StripComponents, Include and Exclude
+// It returns false for members that should not be extracted
+func (a *ArchiveApplier) memberPath(name string) (string, bool) {
+	// Leading ".." components are kept so the caller's traversal check rejects them
+	name = strings.TrimPrefix(path.Clean(name), "/")
+	if name == "." || name == "" {
+		return "", false
+	}
+
+	parts := strings.Split(name, "/")
+	if len(parts) <= a.StripComponents {
+		return "", false
+	}
+	name = strings.Join(parts[a.StripComponents:], "/")
+
+	if len(a.Include) > 0 && !matchesAny(a.Include, name) {
+		return "", false
+	}
+	if matchesAny(a.Exclude, name) {
+		return "", false
+	}
+	return filepath.FromSlash(name), true
+}
+
+// matchesAny reports whether name, or any directory containing it, matches one of the patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		for candidate := name; candidate != "."; candidate = path.Dir(candidate) {
+			if matched, _ := path.Match(pattern, candidate); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // extractZip extracts a zip archive
 func (a *ArchiveApplier) extractZip(source string, dest string) error {
 	reader, err := zip.OpenReader(source)
@@ -50,7 +262,11 @@ func (a *ArchiveApplier) extractZip(source string, dest string) error {
 	defer func() { _ = reader.Close() }()
 
 	for _, file := range reader.File {
-		path := filepath.Join(dest, file.Name)
+		name, ok := a.memberPath(file.Name)
+		if !ok {
+			continue
+		}
+		path := filepath.Join(dest, name)
 
 		// Check for ZipSlip vulnerability
 		if !strings.HasPrefix(path, filepath.Clean(dest)+string(os.PathSeparator)) {
@@ -70,7 +286,7 @@ func (a *ArchiveApplier) extractZip(source string, dest string) error {
 		}
 
 		// Extract file
-		if err := a.extractZipFile(file, path); err != nil {
+		if err := a.extractZipFile(file, path, name); err != nil {
 			return err
 		}
 	}
@@ -79,87 +295,196 @@ func (a *ArchiveApplier) extractZip(source string, dest string) error {
 }
 
 // extractZipFile extracts a single file from a zip archive
-func (a *ArchiveApplier) extractZipFile(file *zip.File, dest string) error {
+// name is the file's path relative to the extract directory
+func (a *ArchiveApplier) extractZipFile(file *zip.File, dest string, name string) error {
 	rc, err := file.Open()
 	if err != nil {
 		return fmt.Errorf("error opening file in archive: %w", err)
 	}
 	defer func() { _ = rc.Close() }()
 
-	outFile, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.Mode())
+	return a.writeFile(dest, name, rc, file.Mode())
+}
+
+// extractTar extracts a tar archive with the given compression
+func (a *ArchiveApplier) extractTar(source string, compression string, dest string) error {
+	return readTar(source, compression, func(header *tar.Header, contents io.Reader) error {
+		name, ok := a.memberPath(header.Name)
+		if !ok {
+			return nil
+		}
+		path := filepath.Join(dest, name)
+
+		// Check for path traversal
+		if !strings.HasPrefix(path, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal file path: %s", path)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, os.FileMode(header.Mode)); err != nil {
+				return fmt.Errorf("error creating directory: %w", err)
+			}
+		case tar.TypeReg:
+			// Create parent directories
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("error creating parent directory: %w", err)
+			}
+
+			if err := a.writeFile(path, name, contents, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		default:
+			// Skip other types (symlinks, etc.)
+		}
+		return nil
+	})
+}
+
+// writeFile writes an extracted file to path, honouring Preserve
+// name is the file's path relative to the extract directory
+func (a *ArchiveApplier) writeFile(path string, name string, contents io.Reader, mode os.FileMode) error {
+	a.Installed = append(a.Installed, filepath.ToSlash(name))
+
+	if !a.shouldPreserve(path, name) {
+		return writeNewFile(path, contents, mode)
+	}
+
+	// Write the new version alongside, then decide whether anything needs preserving
+	newPath := path + PreservedSuffix
+	if err := writeNewFile(newPath, contents, mode); err != nil {
+		return err
+	}
+
+	same, err := sameContents(a.livePath(path, name), newPath)
 	if err != nil {
-		return fmt.Errorf("error creating output file: %w", err)
+		return fmt.Errorf("error comparing %s: %w", name, err)
+	}
+	if same || a.PreserveMode == PreserveKeep {
+		if err := os.Remove(newPath); err != nil {
+			return fmt.Errorf("error removing %s: %w", newPath, err)
+		}
+	} else {
+		a.Installed = append(a.Installed, filepath.ToSlash(name)+PreservedSuffix)
+	}
+	if !same {
+		a.Preserved = append(a.Preserved, name)
+	}
+	return nil
+}
+
+// Preserves reports whether the file name, relative to the extract path,
+// matches Preserve
+func (a *ArchiveApplier) Preserves(name string) bool {
+	return matchesAny(a.Preserve, filepath.ToSlash(name))
+}
+
+// shouldPreserve reports whether the file at path matches Preserve and already exists
+func (a *ArchiveApplier) shouldPreserve(path string, name string) bool {
+	if !matchesAny(a.Preserve, filepath.ToSlash(name)) {
+		return false
+	}
+	info, err := os.Lstat(a.livePath(path, name))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// livePath returns where the file being written to path already exists,
+// which is in the extract path rather than the staging directory during Apply
+func (a *ArchiveApplier) livePath(path string, name string) string {
+	if a.live == "" {
+		return path
+	}
+	return filepath.Join(a.live, name)
+}
+
+// openFile is os.OpenFile, replaced in tests to fail partway through an archive
+var openFile = os.OpenFile
+
+// writeNewFile replaces path with a new file holding contents
+func writeNewFile(path string, contents io.Reader, mode os.FileMode) error {
+	if err := removeExisting(path); err != nil {
+		return err
 	}
-	defer func() { _ = outFile.Close() }()
 
-	_, err = io.Copy(outFile, rc)
+	outFile, err := openFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
 	if err != nil {
-		return fmt.Errorf("error extracting file: %w", err)
+		return fmt.Errorf("error creating file: %w", err)
 	}
 
+	if _, err := io.Copy(outFile, contents); err != nil {
+		_ = outFile.Close()
+		return fmt.Errorf("error extracting file: %w", err)
+	}
+	if err := outFile.Close(); err != nil {
+		return fmt.Errorf("error closing file: %w", err)
+	}
 	return nil
 }
 
-// extractTarGz extracts a tar.gz archive
-func (a *ArchiveApplier) extractTarGz(source string, dest string) error {
+// sameContents reports whether two files hold the same bytes
+func sameContents(a string, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	if infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+
+	dataA, err := os.ReadFile(a)
+	if err != nil {
+		return false, err
+	}
+	dataB, err := os.ReadFile(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(dataA, dataB), nil
+}
+
+// errStopReading is returned by a readTar callback to stop early without an error
+var errStopReading = errors.New("stop reading")
+
+// readTar calls fn for each entry in a tar archive with the given compression
+func readTar(source string, compression string, fn func(header *tar.Header, contents io.Reader) error) error {
 	file, err := os.Open(source)
 	if err != nil {
-		return fmt.Errorf("error opening tar.gz file: %w", err)
+		return fmt.Errorf("error opening tar file: %w", err)
 	}
 	defer func() { _ = file.Close() }()
 
-	gzipReader, err := gzip.NewReader(file)
+	reader, err := decompress(file, compression)
 	if err != nil {
-		return fmt.Errorf("error creating gzip reader: %w", err)
+		return err
 	}
-	defer func() { _ = gzipReader.Close() }()
+	defer func() { _ = reader.Close() }()
 
-	tarReader := tar.NewReader(gzipReader)
+	tarReader := tar.NewReader(reader)
 
 	for {
 		header, err := tarReader.Next()
 		if err == io.EOF {
-			break
+			return nil
 		}
 		if err != nil {
 			return fmt.Errorf("error reading tar: %w", err)
 		}
-
-		path := filepath.Join(dest, header.Name)
-
-		// Check for path traversal
-		if !strings.HasPrefix(path, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return fmt.Errorf("illegal file path: %s", path)
-		}
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(path, os.FileMode(header.Mode)); err != nil {
-				return fmt.Errorf("error creating directory: %w", err)
-			}
-		case tar.TypeReg:
-			// Create parent directories
-			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-				return fmt.Errorf("error creating parent directory: %w", err)
-			}
-
-			outFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(header.Mode))
-			if err != nil {
-				return fmt.Errorf("error creating file: %w", err)
-			}
-
-			if _, err := io.Copy(outFile, tarReader); err != nil {
-				_ = outFile.Close()
-				return fmt.Errorf("error extracting file: %w", err)
-			}
-			if err := outFile.Close(); err != nil {
-				return fmt.Errorf("error closing file: %w", err)
-			}
-		default:
-			// Skip other types (symlinks, etc.)
-			continue
+		if err := fn(header, tarReader); err == errStopReading {
+			return nil
+		} else if err != nil {
+			return err
 		}
 	}
+}
 
+// removeExisting removes a file that is about to be replaced
+func removeExisting(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error replacing file: %w", err)
+	}
 	return nil
 }
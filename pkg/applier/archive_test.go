@@ -230,6 +230,317 @@ func TestArchiveApplier_Apply_TarGz(t *testing.T) {
 	}
 }
 
+func TestArchiveApplier_Targets(t *testing.T) {
+	tempDir := t.TempDir()
+	extractDir := filepath.Join(tempDir, "extract")
+	files := map[string]string{
+		"bin/app":    "binary",
+		"README.txt": "readme",
+	}
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, files)
+	tarPath := filepath.Join(tempDir, "test.tar.gz")
+	createTestTarGz(t, tarPath, files)
+
+	applier := &ArchiveApplier{ExtractPath: extractDir}
+	for _, source := range []string{zipPath, tarPath} {
+		targets, err := applier.Targets(source, filepath.Join(extractDir, "dummy"))
+		if err != nil {
+			t.Fatalf("Targets(%s) failed: %v", filepath.Base(source), err)
+		}
+		if len(targets) != len(files) {
+			t.Fatalf("Targets(%s) returned %d paths, want %d", filepath.Base(source), len(targets), len(files))
+		}
+		for _, target := range targets {
+			rel, err := filepath.Rel(extractDir, target)
+			if err != nil {
+				t.Fatalf("Targets(%s) returned path outside extract dir: %s", filepath.Base(source), target)
+			}
+			if _, ok := files[filepath.ToSlash(rel)]; !ok {
+				t.Errorf("Targets(%s) returned unexpected path %s", filepath.Base(source), target)
+			}
+		}
+	}
+
+	evilPath := filepath.Join(tempDir, "evil.zip")
+	createTestZip(t, evilPath, map[string]string{"../../etc/passwd": "evil"})
+	if _, err := applier.Targets(evilPath, filepath.Join(extractDir, "dummy")); err == nil {
+		t.Error("Targets() expected error for path traversal, got nil")
+	}
+}
+
+func TestArchiveApplier_Apply_Filters(t *testing.T) {
+	files := map[string]string{
+		"myapp-1.2.3/bin/myapp":         "binary",
+		"myapp-1.2.3/lib/helper.so":     "helper",
+		"myapp-1.2.3/docs/README.md":    "readme",
+		"myapp-1.2.3/docs/api/index.md": "api docs",
+		"myapp-1.2.3/config.yaml":       "config",
+	}
+
+	tests := []struct {
+		name    string
+		applier ArchiveApplier
+		want    []string
+	}{
+		{
+			name:    "strip components",
+			applier: ArchiveApplier{StripComponents: 1},
+			want:    []string{"bin/myapp", "lib/helper.so", "docs/README.md", "docs/api/index.md", "config.yaml"},
+		},
+		{
+			name:    "strip more components than some members have",
+			applier: ArchiveApplier{StripComponents: 2},
+			want:    []string{"myapp", "helper.so", "README.md", "api/index.md"},
+		},
+		{
+			name:    "include",
+			applier: ArchiveApplier{StripComponents: 1, Include: []string{"bin", "*.yaml"}},
+			want:    []string{"bin/myapp", "config.yaml"},
+		},
+		{
+			name:    "exclude directory",
+			applier: ArchiveApplier{StripComponents: 1, Exclude: []string{"docs"}},
+			want:    []string{"bin/myapp", "lib/helper.so", "config.yaml"},
+		},
+		{
+			name:    "include and exclude",
+			applier: ArchiveApplier{StripComponents: 1, Include: []string{"docs"}, Exclude: []string{"docs/api"}},
+			want:    []string{"docs/README.md"},
+		},
+		{
+			name:    "exclude without stripping",
+			applier: ArchiveApplier{Exclude: []string{"*/lib/*.so"}},
+			want:    []string{"myapp-1.2.3/bin/myapp", "myapp-1.2.3/docs/README.md", "myapp-1.2.3/docs/api/index.md", "myapp-1.2.3/config.yaml"},
+		},
+	}
+
+	formats := []struct {
+		name   string
+		file   string
+		create func(t *testing.T, path string, files map[string]string)
+	}{
+		{"zip", "myapp.zip", createTestZip},
+		{"tar.gz", "myapp.tar.gz", createTestTarGz},
+	}
+
+	for _, format := range formats {
+		for _, tt := range tests {
+			t.Run(format.name+"/"+tt.name, func(t *testing.T) {
+				tempDir := t.TempDir()
+				source := filepath.Join(tempDir, format.file)
+				format.create(t, source, files)
+
+				extractDir := filepath.Join(tempDir, "myapp")
+				applier := tt.applier
+				applier.ExtractPath = extractDir
+
+				if err := applier.Apply(source, filepath.Join(extractDir, "bin", "myapp")); err != nil {
+					t.Fatalf("Apply() failed: %v", err)
+				}
+
+				var got []string
+				err := filepath.Walk(extractDir, func(path string, info os.FileInfo, err error) error {
+					if err != nil {
+						return err
+					}
+					if !info.IsDir() {
+						rel, _ := filepath.Rel(extractDir, path)
+						got = append(got, filepath.ToSlash(rel))
+					}
+					return nil
+				})
+				if err != nil {
+					t.Fatalf("Failed to walk extract directory: %v", err)
+				}
+
+				if len(got) != len(tt.want) {
+					t.Fatalf("Extracted %v, want %v", got, tt.want)
+				}
+				for _, name := range tt.want {
+					if _, err := os.Stat(filepath.Join(extractDir, filepath.FromSlash(name))); err != nil {
+						t.Errorf("Expected %s to be extracted: %v", name, err)
+					}
+				}
+
+				targets, err := applier.Targets(source, filepath.Join(extractDir, "bin", "myapp"))
+				if err != nil {
+					t.Fatalf("Targets() failed: %v", err)
+				}
+				if len(targets) != len(tt.want) {
+					t.Errorf("Targets() = %v, want %d paths", targets, len(tt.want))
+				}
+			})
+		}
+	}
+}
+
+func TestArchiveApplier_Apply_Preserve(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		wantConfig   string
+		wantNewFile  bool
+		wantReported []string
+	}{
+		{
+			name:         "new mode",
+			mode:         PreserveNew,
+			wantConfig:   "customised",
+			wantNewFile:  true,
+			wantReported: []string{"config.yaml"},
+		},
+		{
+			name:         "default mode",
+			mode:         "",
+			wantConfig:   "customised",
+			wantNewFile:  true,
+			wantReported: []string{"config.yaml"},
+		},
+		{
+			name:         "keep mode",
+			mode:         PreserveKeep,
+			wantConfig:   "customised",
+			wantNewFile:  false,
+			wantReported: []string{"config.yaml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			extractDir := filepath.Join(tempDir, "myapp")
+			if err := os.MkdirAll(filepath.Join(extractDir, "conf.d"), 0755); err != nil {
+				t.Fatalf("Failed to create directory: %v", err)
+			}
+			existing := map[string]string{
+				"config.yaml":        "customised",
+				"conf.d/default.yml": "default",
+			}
+			for name, content := range existing {
+				if err := os.WriteFile(filepath.Join(extractDir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to write %s: %v", name, err)
+				}
+			}
+
+			source := filepath.Join(tempDir, "myapp.tar.gz")
+			createTestTarGz(t, source, map[string]string{
+				"bin/myapp":          "new binary",
+				"config.yaml":        "new default",
+				"conf.d/default.yml": "default",
+				"conf.d/extra.yml":   "extra",
+			})
+
+			applier := &ArchiveApplier{
+				ExtractPath:  extractDir,
+				Preserve:     []string{"*.yaml", "conf.d"},
+				PreserveMode: tt.mode,
+			}
+			if err := applier.Apply(source, filepath.Join(extractDir, "bin", "myapp")); err != nil {
+				t.Fatalf("Apply() failed: %v", err)
+			}
+
+			if content, _ := os.ReadFile(filepath.Join(extractDir, "config.yaml")); string(content) != tt.wantConfig {
+				t.Errorf("config.yaml = %q, want %q", string(content), tt.wantConfig)
+			}
+
+			newContent, err := os.ReadFile(filepath.Join(extractDir, "config.yaml"+PreservedSuffix))
+			if tt.wantNewFile {
+				if err != nil || string(newContent) != "new default" {
+					t.Errorf("config.yaml%s = %q (%v), want the new version", PreservedSuffix, string(newContent), err)
+				}
+			} else if !os.IsNotExist(err) {
+				t.Errorf("config.yaml%s should not exist in keep mode", PreservedSuffix)
+			}
+
+			// Unchanged preserved files are not reported and get no .guppy-new
+			if _, err := os.Stat(filepath.Join(extractDir, "conf.d", "default.yml"+PreservedSuffix)); !os.IsNotExist(err) {
+				t.Error("Unchanged preserved file should not get a new version written")
+			}
+
+			// Preserved patterns only protect files that already exist
+			if content, _ := os.ReadFile(filepath.Join(extractDir, "conf.d", "extra.yml")); string(content) != "extra" {
+				t.Errorf("conf.d/extra.yml = %q, want it installed", string(content))
+			}
+			if content, _ := os.ReadFile(filepath.Join(extractDir, "bin", "myapp")); string(content) != "new binary" {
+				t.Errorf("bin/myapp = %q, want %q", string(content), "new binary")
+			}
+
+			if len(applier.Preserved) != len(tt.wantReported) || applier.Preserved[0] != tt.wantReported[0] {
+				t.Errorf("Preserved = %v, want %v", applier.Preserved, tt.wantReported)
+			}
+		})
+	}
+}
+
+func TestArchiveApplier_Apply_RemovesStaleFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	extractDir := filepath.Join(tempDir, "myapp")
+
+	existing := map[string]string{
+		"bin/myapp":           "old binary",
+		"plugins/old/main.so": "old plugin",
+		"config.yaml":         "customised",
+		"notes.txt":           "user file",
+	}
+	for name, content := range existing {
+		path := filepath.Join(extractDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	source := filepath.Join(tempDir, "myapp.zip")
+	createTestZip(t, source, map[string]string{
+		"bin/myapp":       "new binary",
+		"plugins/main.so": "new plugin",
+	})
+
+	applier := &ArchiveApplier{
+		ExtractPath:   extractDir,
+		Preserve:      []string{"*.yaml"},
+		PreviousFiles: []string{"bin/myapp", "plugins/old/main.so", "config.yaml", "gone.txt"},
+	}
+	target := filepath.Join(extractDir, "bin", "myapp")
+
+	targets, err := applier.Targets(source, target)
+	if err != nil {
+		t.Fatalf("Targets() failed: %v", err)
+	}
+	staleTarget := filepath.Join(extractDir, "plugins", "old", "main.so")
+	found := false
+	for _, path := range targets {
+		found = found || path == staleTarget
+	}
+	if !found {
+		t.Errorf("Targets() = %v, want it to include the stale file %s", targets, staleTarget)
+	}
+
+	if err := applier.Apply(source, target); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(extractDir, "plugins", "old")); !os.IsNotExist(err) {
+		t.Error("Apply() should remove files and directories dropped from the release")
+	}
+	if content, _ := os.ReadFile(filepath.Join(extractDir, "config.yaml")); string(content) != "customised" {
+		t.Errorf("config.yaml = %q, want preserved files kept", string(content))
+	}
+	if content, _ := os.ReadFile(filepath.Join(extractDir, "notes.txt")); string(content) != "user file" {
+		t.Errorf("notes.txt = %q, want untracked files left alone", string(content))
+	}
+	if len(applier.Removed) != 1 || applier.Removed[0] != "plugins/old/main.so" {
+		t.Errorf("Removed = %v, want [plugins/old/main.so]", applier.Removed)
+	}
+	if len(applier.Installed) != 2 {
+		t.Errorf("Installed = %v, want the 2 files in the release", applier.Installed)
+	}
+}
+
 func TestArchiveApplier_Apply_TgzExtension(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -1,13 +1,21 @@
 package applier
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"strings"
 )
 
 // BinaryApplier applies updates by replacing binary files
-type BinaryApplier struct{}
+type BinaryApplier struct {
+	// BinaryPath is a glob matching the binary inside an archive, e.g. */bin/gh
+	// If empty, the downloaded file itself is installed
+	BinaryPath string
+}
 
 // NewBinaryApplier creates a new binary applier
 func NewBinaryApplier() *BinaryApplier {
@@ -15,6 +23,8 @@ func NewBinaryApplier() *BinaryApplier {
 }
 
 // Apply replaces the target binary with the source binary
+// A source that is a single compressed file is decompressed into the target
+// When BinaryPath is set and the source is an archive, the matching member is installed
 func (b *BinaryApplier) Apply(source string, target string) error {
 	// Open source file
 	sourceFile, err := os.Open(source)
@@ -29,38 +39,139 @@ func (b *BinaryApplier) Apply(source string, target string) error {
 		return fmt.Errorf("error getting source file info: %w", err)
 	}
 
+	// Single compressed binaries (e.g. myapp.gz) are decompressed as they are copied
+	var contents io.Reader = sourceFile
+	if !sourceInfo.IsDir() {
+		f, err := detectFormat(source)
+		if err != nil {
+			return err
+		}
+		if b.BinaryPath != "" && f.Archive != archiveNone {
+			return b.applyFromArchive(source, f, target)
+		}
+		if f.Archive == archiveNone && f.Compression != compressionNone {
+			reader, err := decompress(sourceFile, f.Compression)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = reader.Close() }()
+			contents = reader
+		}
+	}
+
+	return replaceTarget(contents, sourceInfo.Mode(), target)
+}
+
+// Targets returns the target binary, which is the only file Apply replaces
+func (b *BinaryApplier) Targets(source string, target string) ([]string, error) {
+	return []string{target}, nil
+}
+
+// applyFromArchive installs the single archive member matching BinaryPath
+func (b *BinaryApplier) applyFromArchive(source string, f format, target string) error {
+	var matches []string
+	switch f.Archive {
+	case archiveZip:
+		reader, err := zip.OpenReader(source)
+		if err != nil {
+			return fmt.Errorf("error opening zip file: %w", err)
+		}
+		defer func() { _ = reader.Close() }()
+
+		var member *zip.File
+		for _, file := range reader.File {
+			if !file.FileInfo().IsDir() && b.matches(file.Name) {
+				matches = append(matches, file.Name)
+				member = file
+			}
+		}
+		if err := b.checkMatches(matches); err != nil {
+			return err
+		}
+
+		rc, err := member.Open()
+		if err != nil {
+			return fmt.Errorf("error opening file in archive: %w", err)
+		}
+		defer func() { _ = rc.Close() }()
+		return replaceTarget(rc, 0755, target)
+	case archiveTar:
+		// Find the member first, so an ambiguous match is reported before anything is written
+		err := readTar(source, f.Compression, func(header *tar.Header, _ io.Reader) error {
+			if header.Typeflag == tar.TypeReg && b.matches(header.Name) {
+				matches = append(matches, header.Name)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := b.checkMatches(matches); err != nil {
+			return err
+		}
+
+		return readTar(source, f.Compression, func(header *tar.Header, contents io.Reader) error {
+			if header.Typeflag != tar.TypeReg || header.Name != matches[0] {
+				return nil
+			}
+			if err := replaceTarget(contents, 0755, target); err != nil {
+				return err
+			}
+			return errStopReading
+		})
+	default:
+		return fmt.Errorf("unsupported archive format: %s", source)
+	}
+}
+
+// matches reports whether an archive member name matches BinaryPath
+func (b *BinaryApplier) matches(name string) bool {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	matched, err := path.Match(b.BinaryPath, name)
+	return err == nil && matched
+}
+
+// checkMatches requires exactly one archive member to match BinaryPath
+func (b *BinaryApplier) checkMatches(matches []string) error {
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("no file in archive matches binary_path %q", b.BinaryPath)
+	case 1:
+		return nil
+	default:
+		return fmt.Errorf("binary_path %q matches %d files in archive: %s", b.BinaryPath, len(matches), strings.Join(matches, ", "))
+	}
+}
+
+// replaceTarget writes contents to a temporary file next to target and renames it into place
+func replaceTarget(contents io.Reader, mode os.FileMode, target string) error {
 	// Create temporary target file
 	tempTarget := target + ".tmp"
-	targetFile, err := os.OpenFile(tempTarget, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, sourceInfo.Mode())
+	targetFile, err := os.OpenFile(tempTarget, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
 	if err != nil {
 		return fmt.Errorf("error creating temporary target file: %w", err)
 	}
 
 	// Copy source to temp target
-	_, err = io.Copy(targetFile, sourceFile)
+	_, err = io.Copy(targetFile, contents)
 	_ = targetFile.Close()
 	if err != nil {
 		_ = os.Remove(tempTarget)
 		return fmt.Errorf("error copying file: %w", err)
 	}
 
-	// Remove old target if it exists
-	if _, err := os.Stat(target); err == nil {
-		if err := os.Remove(target); err != nil {
-			_ = os.Remove(tempTarget)
-			return fmt.Errorf("error removing old target: %w", err)
-		}
+	// Make the new file executable (on Unix systems)
+	if err := os.Chmod(tempTarget, 0755); err != nil {
+		_ = os.Remove(tempTarget)
+		return fmt.Errorf("error setting executable permissions: %w", err)
 	}
 
-	// Rename temp to target
-	if err := os.Rename(tempTarget, target); err != nil {
+	// Rename temp over target, which replaces an existing file in a single
+	// step, so the target is never missing
+	if err := rename(tempTarget, target); err != nil {
+		_ = os.Remove(tempTarget)
 		return fmt.Errorf("error renaming temporary file: %w", err)
 	}
 
-	// Ensure target is executable (on Unix systems)
-	if err := os.Chmod(target, 0755); err != nil {
-		return fmt.Errorf("error setting executable permissions: %w", err)
-	}
-
 	return nil
 }
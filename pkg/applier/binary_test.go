@@ -1,6 +1,7 @@
 package applier
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -68,6 +69,113 @@ func TestBinaryApplier_Apply(t *testing.T) {
 	}
 }
 
+func TestBinaryApplier_Targets(t *testing.T) {
+	targets, err := NewBinaryApplier().Targets("/tmp/download/app", "/usr/local/bin/app")
+	if err != nil {
+		t.Fatalf("Targets() failed: %v", err)
+	}
+	if len(targets) != 1 || targets[0] != "/usr/local/bin/app" {
+		t.Errorf("Targets() = %v, want only the target binary", targets)
+	}
+}
+
+func TestBinaryApplier_Apply_BinaryPath(t *testing.T) {
+	files := map[string]string{
+		"gh_2.0.0_linux_amd64/bin/gh":        "gh binary",
+		"gh_2.0.0_linux_amd64/LICENSE":       "license",
+		"gh_2.0.0_linux_amd64/share/man/gh":  "man page",
+		"gh_2.0.0_linux_amd64/completion/gh": "completion",
+	}
+
+	tests := []struct {
+		name       string
+		fileName   string
+		create     func(t *testing.T, path string, files map[string]string)
+		binaryPath string
+		wantErr    bool
+	}{
+		{
+			name:       "tar.gz with glob",
+			fileName:   "gh.tar.gz",
+			create:     createTestTarGz,
+			binaryPath: "*/bin/gh",
+		},
+		{
+			name:       "zip with exact path",
+			fileName:   "gh.zip",
+			create:     createTestZip,
+			binaryPath: "gh_2.0.0_linux_amd64/bin/gh",
+		},
+		{
+			name:       "no match",
+			fileName:   "gh.tar.gz",
+			create:     createTestTarGz,
+			binaryPath: "*/bin/missing",
+			wantErr:    true,
+		},
+		{
+			name:       "ambiguous match",
+			fileName:   "gh.zip",
+			create:     createTestZip,
+			binaryPath: "*/*/gh",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			source := filepath.Join(tempDir, tt.fileName)
+			tt.create(t, source, files)
+
+			target := filepath.Join(tempDir, "gh")
+			if err := os.WriteFile(target, []byte("old binary"), 0755); err != nil {
+				t.Fatalf("Failed to create target file: %v", err)
+			}
+
+			applier := &BinaryApplier{BinaryPath: tt.binaryPath}
+			err := applier.Apply(source, target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			want := "gh binary"
+			if tt.wantErr {
+				want = "old binary"
+			}
+			content, err := os.ReadFile(target)
+			if err != nil {
+				t.Fatalf("Failed to read target: %v", err)
+			}
+			if string(content) != want {
+				t.Errorf("Target content = %q, want %q", string(content), want)
+			}
+
+			if _, err := os.Stat(target + ".tmp"); !os.IsNotExist(err) {
+				t.Error("Temporary file should not exist after Apply()")
+			}
+		})
+	}
+}
+
+func TestBinaryApplier_Apply_BinaryPathPlainBinary(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "app")
+	if err := os.WriteFile(source, []byte("plain binary"), 0644); err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+
+	// binary_path only applies to archives; a plain binary is installed as is
+	target := filepath.Join(tempDir, "installed")
+	applier := &BinaryApplier{BinaryPath: "*/bin/app"}
+	if err := applier.Apply(source, target); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "plain binary" {
+		t.Errorf("Target content = %q, want %q", string(content), "plain binary")
+	}
+}
+
 func TestBinaryApplier_Apply_NewTarget(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -341,6 +449,42 @@ func TestBinaryApplier_Apply_CleanupOnCopyFailure(t *testing.T) {
 	}
 }
 
+func TestBinaryApplier_Apply_RenameFailure(t *testing.T) {
+	tempDir := t.TempDir()
+
+	sourceFile := filepath.Join(tempDir, "source.bin")
+	if err := os.WriteFile(sourceFile, []byte("New version"), 0755); err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+
+	targetFile := filepath.Join(tempDir, "target.bin")
+	if err := os.WriteFile(targetFile, []byte("Old version"), 0755); err != nil {
+		t.Fatalf("Failed to create target file: %v", err)
+	}
+
+	defer func() { rename = os.Rename }()
+	rename = func(oldpath, newpath string) error {
+		return errors.New("injected rename failure")
+	}
+
+	applier := NewBinaryApplier()
+	if err := applier.Apply(sourceFile, targetFile); err == nil {
+		t.Fatal("Apply() expected error for failed rename, got nil")
+	}
+
+	// The old binary is still in place and the temp file is cleaned up
+	content, err := os.ReadFile(targetFile)
+	if err != nil {
+		t.Fatalf("Target file missing after failed rename: %v", err)
+	}
+	if string(content) != "Old version" {
+		t.Errorf("Target file = %q, want %q", string(content), "Old version")
+	}
+	if _, err := os.Stat(targetFile + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Apply() left temp file after failed rename")
+	}
+}
+
 func TestBinaryApplier_Apply_PermissionError_ReadOnly(t *testing.T) {
 	// Skip on Windows where permission handling is different
 	if os.Getenv("GOOS") == "windows" {
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package applier
+
+// exchangeDirs reports that directories cannot be swapped atomically, so
+// swapDir falls back to two renames
+func exchangeDirs(a string, b string) error {
+	return errExchangeUnsupported
+}
@@ -0,0 +1,182 @@
+package applier
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Compression formats for downloaded files
+const (
+	compressionNone  = ""
+	compressionGzip  = "gzip"
+	compressionBzip2 = "bzip2"
+	compressionXz    = "xz"
+	compressionZstd  = "zstd"
+)
+
+// Archive formats for downloaded files
+const (
+	archiveNone = ""
+	archiveZip  = "zip"
+	archiveTar  = "tar"
+)
+
+// format describes how a downloaded file is packaged
+type format struct {
+	Archive     string // zip, tar, or empty for a single file
+	Compression string // Compression around a tar archive or single file
+}
+
+// magic numbers for each compression format
+var compressionMagic = []struct {
+	compression string
+	magic       []byte
+}{
+	{compressionGzip, []byte{0x1f, 0x8b}},
+	{compressionBzip2, []byte("BZh")},
+	{compressionXz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{compressionZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// extensions recognised when a file has no magic number, longest first
+var formatExtensions = []struct {
+	ext    string
+	format format
+}{
+	{".tar.gz", format{archiveTar, compressionGzip}},
+	{".tar.bz2", format{archiveTar, compressionBzip2}},
+	{".tar.xz", format{archiveTar, compressionXz}},
+	{".tar.zst", format{archiveTar, compressionZstd}},
+	{".tgz", format{archiveTar, compressionGzip}},
+	{".tbz2", format{archiveTar, compressionBzip2}},
+	{".txz", format{archiveTar, compressionXz}},
+	{".tzst", format{archiveTar, compressionZstd}},
+	{".tar", format{archiveTar, compressionNone}},
+	{".zip", format{archiveZip, compressionNone}},
+	{".gz", format{archiveNone, compressionGzip}},
+	{".bz2", format{archiveNone, compressionBzip2}},
+	{".xz", format{archiveNone, compressionXz}},
+	{".zst", format{archiveNone, compressionZstd}},
+}
+
+// tarMagicOffset is where the ustar magic appears in a tar header
+const tarMagicOffset = 257
+
+// detectFormat identifies how the file at path is packaged from its magic
+// bytes, falling back to its extension when the contents are not recognised
+// Files that are neither archives nor compressed return an empty format
+func detectFormat(path string) (format, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return format{}, fmt.Errorf("error opening file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	header := make([]byte, 512)
+	n, err := file.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return format{}, fmt.Errorf("error reading file: %w", err)
+	}
+	header = header[:n]
+
+	if bytes.HasPrefix(header, []byte("PK\x03\x04")) || bytes.HasPrefix(header, []byte("PK\x05\x06")) {
+		return format{Archive: archiveZip}, nil
+	}
+	if isTarHeader(header) {
+		return format{Archive: archiveTar}, nil
+	}
+
+	for _, c := range compressionMagic {
+		if !bytes.HasPrefix(header, c.magic) {
+			continue
+		}
+
+		// Look inside the compressed stream to tell a tarball from a single file
+		f := format{Archive: archiveNone, Compression: c.compression}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return format{}, fmt.Errorf("error reading file: %w", err)
+		}
+		reader, err := decompress(file, c.compression)
+		if err == nil {
+			inner := make([]byte, 512)
+			n, _ := io.ReadFull(reader, inner)
+			_ = reader.Close()
+			if isTarHeader(inner[:n]) {
+				f.Archive = archiveTar
+			}
+		}
+
+		// Tarballs without a ustar header are only recognisable by name
+		if f.Archive == archiveNone {
+			if byExt, ok := formatFromExtension(path); ok && byExt.Compression == c.compression {
+				f.Archive = byExt.Archive
+			}
+		}
+		return f, nil
+	}
+
+	if byExt, ok := formatFromExtension(path); ok {
+		return byExt, nil
+	}
+	return format{}, nil
+}
+
+// formatFromExtension returns the format implied by the file name
+func formatFromExtension(path string) (format, bool) {
+	lower := strings.ToLower(path)
+	for _, e := range formatExtensions {
+		if strings.HasSuffix(lower, e.ext) {
+			return e.format, true
+		}
+	}
+	return format{}, false
+}
+
+// isTarHeader reports whether data starts with a POSIX or GNU tar header
+func isTarHeader(data []byte) bool {
+	return len(data) >= tarMagicOffset+5 && bytes.Equal(data[tarMagicOffset:tarMagicOffset+5], []byte("ustar"))
+}
+
+// decompress wraps r in a reader for the given compression
+func decompress(r io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case compressionNone:
+		return io.NopCloser(r), nil
+	case compressionGzip:
+		reader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("error creating gzip reader: %w", err)
+		}
+		return reader, nil
+	case compressionBzip2:
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case compressionXz:
+		reader, err := xz.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("error creating xz reader: %w", err)
+		}
+		return io.NopCloser(reader), nil
+	case compressionZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("error creating zstd reader: %w", err)
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+}
+
+// isArchive reports whether source is an archive Apply can extract
+func isArchive(source string) bool {
+	f, err := detectFormat(source)
+	return err == nil && f.Archive != archiveNone
+}
@@ -0,0 +1,272 @@
+package applier
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// tarBytes returns an uncompressed tar archive holding files
+func tarBytes(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tarWriter := tar.NewWriter(&buf)
+	for name, content := range files {
+		header := &tar.Header{
+			Name: name,
+			Mode: 0644,
+			Size: int64(len(content)),
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write tar header %s: %v", name, err)
+		}
+		if _, err := tarWriter.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write tar entry %s: %v", name, err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// compressBytes compresses data with the given compression
+func compressBytes(t *testing.T, data []byte, compression string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	var err error
+	switch compression {
+	case compressionNone:
+		return data
+	case compressionGzip:
+		writer = gzip.NewWriter(&buf)
+	case compressionXz:
+		writer, err = xz.NewWriter(&buf)
+	case compressionZstd:
+		writer, err = zstd.NewWriter(&buf)
+	default:
+		t.Fatalf("No test writer for %s", compression)
+	}
+	if err != nil {
+		t.Fatalf("Failed to create %s writer: %v", compression, err)
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("Failed to compress data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close %s writer: %v", compression, err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	tarball := tarBytes(t, map[string]string{"app": "binary"})
+
+	tests := []struct {
+		name     string
+		fileName string
+		contents []byte
+		want     format
+	}{
+		{"tar.gz", "app.tar.gz", compressBytes(t, tarball, compressionGzip), format{archiveTar, compressionGzip}},
+		{"tar.xz", "app.tar.xz", compressBytes(t, tarball, compressionXz), format{archiveTar, compressionXz}},
+		{"tar.zst", "app.tar.zst", compressBytes(t, tarball, compressionZstd), format{archiveTar, compressionZstd}},
+		{"plain tar", "app.tar", tarball, format{archiveTar, compressionNone}},
+		{"single gzip file", "app.gz", compressBytes(t, []byte("binary"), compressionGzip), format{archiveNone, compressionGzip}},
+		{"single xz file", "app.xz", compressBytes(t, []byte("binary"), compressionXz), format{archiveNone, compressionXz}},
+		{"tar.xz without extension", "download", compressBytes(t, tarball, compressionXz), format{archiveTar, compressionXz}},
+		{"tar.zst with wrong extension", "app.zip", compressBytes(t, tarball, compressionZstd), format{archiveTar, compressionZstd}},
+		{"plain binary", "app", []byte("\x7fELF binary"), format{archiveNone, compressionNone}},
+		{"unknown contents with archive extension", "broken.zip", []byte("not a zip"), format{archiveZip, compressionNone}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, tt.fileName)
+			if err := os.WriteFile(path, tt.contents, 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+
+			got, err := detectFormat(path)
+			if err != nil {
+				t.Fatalf("detectFormat() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("detectFormat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectFormat_Fixtures(t *testing.T) {
+	tests := []struct {
+		path string
+		want format
+	}{
+		{filepath.Join("testdata", "app.tar.bz2"), format{archiveTar, compressionBzip2}},
+		{filepath.Join("testdata", "app.bz2"), format{archiveNone, compressionBzip2}},
+	}
+
+	for _, tt := range tests {
+		got, err := detectFormat(tt.path)
+		if err != nil {
+			t.Fatalf("detectFormat(%s) failed: %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Errorf("detectFormat(%s) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestArchiveApplier_Apply_CompressedTarballs(t *testing.T) {
+	tarball := tarBytes(t, map[string]string{
+		"bin/app":    "binary",
+		"README.txt": "readme",
+	})
+
+	tests := []struct {
+		name        string
+		fileName    string
+		compression string
+	}{
+		{"tar.xz", "app.tar.xz", compressionXz},
+		{"tar.zst", "app.tar.zst", compressionZstd},
+		{"plain tar", "app.tar", compressionNone},
+		{"tar.xz without extension", "app-latest", compressionXz},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			source := filepath.Join(tempDir, tt.fileName)
+			if err := os.WriteFile(source, compressBytes(t, tarball, tt.compression), 0644); err != nil {
+				t.Fatalf("Failed to write archive: %v", err)
+			}
+
+			extractDir := filepath.Join(tempDir, "extract")
+			applier := &ArchiveApplier{ExtractPath: extractDir}
+			if err := applier.Apply(source, filepath.Join(extractDir, "bin", "app")); err != nil {
+				t.Fatalf("Apply() failed: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(extractDir, "bin", "app"))
+			if err != nil || string(content) != "binary" {
+				t.Errorf("bin/app = %q (%v), want %q", string(content), err, "binary")
+			}
+
+			targets, err := applier.Targets(source, filepath.Join(extractDir, "bin", "app"))
+			if err != nil {
+				t.Fatalf("Targets() failed: %v", err)
+			}
+			if len(targets) != 2 {
+				t.Errorf("Targets() = %v, want 2 files", targets)
+			}
+		})
+	}
+}
+
+func TestArchiveApplier_Apply_TarBz2(t *testing.T) {
+	extractDir := filepath.Join(t.TempDir(), "extract")
+
+	applier := &ArchiveApplier{ExtractPath: extractDir}
+	if err := applier.Apply(filepath.Join("testdata", "app.tar.bz2"), filepath.Join(extractDir, "bin", "app")); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(extractDir, "bin", "app"))
+	if err != nil || string(content) != "bzip2 app\n" {
+		t.Errorf("bin/app = %q (%v), want %q", string(content), err, "bzip2 app\n")
+	}
+}
+
+func TestArchiveApplier_Apply_SingleCompressedFile(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "app.gz")
+	if err := os.WriteFile(source, compressBytes(t, []byte("binary"), compressionGzip), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	applier := &ArchiveApplier{ExtractPath: filepath.Join(tempDir, "extract")}
+	if err := applier.Apply(source, filepath.Join(tempDir, "extract", "app")); err == nil {
+		t.Error("Apply() expected error for a compressed file that is not an archive, got nil")
+	}
+}
+
+func TestBinaryApplier_Apply_Compressed(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileName    string
+		compression string
+	}{
+		{"gzip", "app.gz", compressionGzip},
+		{"xz", "app.xz", compressionXz},
+		{"zstd", "app.zst", compressionZstd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			source := filepath.Join(tempDir, tt.fileName)
+			if err := os.WriteFile(source, compressBytes(t, []byte("decompressed binary"), tt.compression), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+
+			target := filepath.Join(tempDir, "app")
+			if err := NewBinaryApplier().Apply(source, target); err != nil {
+				t.Fatalf("Apply() failed: %v", err)
+			}
+
+			content, err := os.ReadFile(target)
+			if err != nil || string(content) != "decompressed binary" {
+				t.Errorf("Target content = %q (%v), want %q", string(content), err, "decompressed binary")
+			}
+		})
+	}
+}
+
+func TestBinaryApplier_Apply_Bzip2(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "app")
+	if err := NewBinaryApplier().Apply(filepath.Join("testdata", "app.bz2"), target); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	content, err := os.ReadFile(target)
+	if err != nil || string(content) != "single bzip2 binary\n" {
+		t.Errorf("Target content = %q (%v), want %q", string(content), err, "single bzip2 binary\n")
+	}
+}
+
+func TestVersionedApplier_Apply_CompressedBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping symlink test on Windows")
+	}
+
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "myapp.xz")
+	if err := os.WriteFile(source, compressBytes(t, []byte("binary"), compressionXz), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	target := filepath.Join(tempDir, "myapp")
+	if err := NewVersionedApplier("1.0.0", 3).Apply(source, target); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(target, "releases", "1.0.0", "myapp"))
+	if err != nil || string(content) != "binary" {
+		t.Errorf("releases/1.0.0/myapp = %q (%v), want %q", string(content), err, "binary")
+	}
+}
@@ -6,4 +6,15 @@ type Applier interface {
 	// source is the path to the downloaded update file
 	// target is the path where the update should be applied
 	Apply(source string, target string) error
+
+	// Targets returns the paths Apply would create or overwrite, so they can
+	// be backed up before the update is applied
+	Targets(source string, target string) ([]string, error)
+}
+
+// Recoverer is implemented by appliers that stage updates on disk
+type Recoverer interface {
+	// Recover rolls back an update to target that was interrupted, such as by
+	// a crash, and reports whether there was one to roll back
+	Recover(target string) (bool, error)
 }
@@ -0,0 +1,364 @@
+package applier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jaredhaight/guppy/pkg/manifest"
+)
+
+// rename is os.Rename, replaced in tests to inject failures when files are moved into place
+var rename = os.Rename
+
+// exchange atomically swaps two directories, replaced in tests
+// It returns errExchangeUnsupported where the platform or filesystem cannot.
+var exchange = exchangeDirs
+
+// errExchangeUnsupported is returned by exchange when directories cannot be
+// swapped in a single step
+var errExchangeUnsupported = errors.New("atomic directory exchange is not supported")
+
+const (
+	// stagingDirName is the directory inside the live directory that an
+	// update is extracted into before it is committed
+	stagingDirName = ".guppy-staging"
+	// journalName is the file in the staging directory that lists the steps
+	// of a commit in progress
+	journalName = "journal.json"
+	// oldSuffix names the copy of a directory that swapDir moves aside
+	oldSuffix = ".guppy-old"
+)
+
+// Commit steps
+const (
+	stepDir    = "dir"    // Create a directory the update adds
+	stepFile   = "file"   // Move a staged file into place, keeping any file it replaces
+	stepRemove = "remove" // Move a file the update drops out of the way
+)
+
+// stagedStep is one change a commit makes to the live directory
+type stagedStep struct {
+	Op   string `json:"op"`
+	Name string `json:"name"` // Slash-separated path relative to the live directory
+}
+
+// stageFiles updates dir from a staging directory inside it. update extracts
+// the new files into the staging directory and returns the files, relative to
+// dir, that the update removes. The staged files are then moved into place one
+// rename at a time and the removed ones out of the way, and the files they
+// displace are kept until every move has succeeded.
+// The commit is not atomic: another process can see some files replaced and
+// others not while it runs. dir is not swapped for a sibling directory because
+// it may hold files guppy does not own, be a mount point, or sit in a parent
+// guppy cannot write to; the versioned applier is the choice when readers must
+// never see a mixed tree.
+// The steps are written to a journal before the first live file changes, so a
+// failed commit is undone at once and one interrupted by a crash is undone by
+// recoverStaged. It returns the files that were removed.
+func stageFiles(dir string, update func(staging string) ([]string, error)) ([]string, error) {
+	if _, err := recoverStaged(dir); err != nil {
+		return nil, err
+	}
+
+	staging := filepath.Join(dir, stagingDirName)
+	newDir := filepath.Join(staging, "new")
+	if err := os.MkdirAll(newDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating staging directory: %w", err)
+	}
+
+	remove, err := update(newDir)
+	if err != nil {
+		_ = os.RemoveAll(staging)
+		return nil, err
+	}
+
+	steps, removed, err := planSteps(dir, newDir, remove)
+	if err == nil {
+		err = writeJournal(staging, steps)
+	}
+	if err != nil {
+		_ = os.RemoveAll(staging)
+		return nil, err
+	}
+
+	for _, step := range steps {
+		if err := commitStep(dir, staging, step); err != nil {
+			if undoErr := undoSteps(dir, staging, steps); undoErr != nil {
+				return nil, fmt.Errorf("error committing update: %w (undoing it also failed: %v; run guppy again to retry)", err, undoErr)
+			}
+			_ = os.RemoveAll(staging)
+			return nil, fmt.Errorf("error committing update: %w", err)
+		}
+	}
+
+	// Removing the journal completes the commit; from here on the update
+	// is kept even if guppy is interrupted
+	if err := os.Remove(filepath.Join(staging, journalName)); err != nil {
+		if undoErr := undoSteps(dir, staging, steps); undoErr != nil {
+			return nil, fmt.Errorf("error completing update: %w (undoing it also failed: %v; run guppy again to retry)", err, undoErr)
+		}
+		_ = os.RemoveAll(staging)
+		return nil, fmt.Errorf("error completing update: %w", err)
+	}
+
+	for _, name := range removed {
+		manifest.RemoveEmptyParents(dir, filepath.Join(dir, filepath.FromSlash(name)))
+	}
+	_ = os.RemoveAll(staging)
+	return removed, nil
+}
+
+// planSteps lists the steps that move the files staged in newDir into dir and
+// remove the given files from it, along with the files that will be removed
+// Directories come before their contents; files that are already gone are skipped.
+func planSteps(dir string, newDir string, remove []string) ([]stagedStep, []string, error) {
+	var steps []stagedStep
+	err := filepath.Walk(newDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(newDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if name == stagingDirName || strings.HasPrefix(name, stagingDirName+"/") {
+			return fmt.Errorf("illegal file path: %s", filepath.Join(dir, rel))
+		}
+
+		liveInfo, err := os.Lstat(filepath.Join(dir, rel))
+		if !info.IsDir() {
+			if err == nil && liveInfo.IsDir() {
+				return fmt.Errorf("cannot replace directory %s with a file", filepath.Join(dir, rel))
+			}
+			steps = append(steps, stagedStep{Op: stepFile, Name: name})
+			return nil
+		}
+		if os.IsNotExist(err) {
+			steps = append(steps, stagedStep{Op: stepDir, Name: name})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error staging update: %w", err)
+	}
+
+	var removed []string
+	for _, name := range remove {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return nil, nil, fmt.Errorf("illegal file path: %s", path)
+		}
+		if info, err := os.Lstat(path); err != nil || info.IsDir() {
+			continue
+		}
+		steps = append(steps, stagedStep{Op: stepRemove, Name: name})
+		removed = append(removed, name)
+	}
+	return steps, removed, nil
+}
+
+// writeJournal records the steps of a commit and flushes them to disk
+func writeJournal(staging string, steps []stagedStep) error {
+	data, err := json.Marshal(steps)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filepath.Join(staging, journalName))
+	if err != nil {
+		return fmt.Errorf("error writing update journal: %w", err)
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing update journal: %w", err)
+	}
+	return nil
+}
+
+// commitStep applies one step of a commit to dir
+func commitStep(dir string, staging string, step stagedStep) error {
+	rel := filepath.FromSlash(step.Name)
+	live := filepath.Join(dir, rel)
+	staged := filepath.Join(staging, "new", rel)
+	old := filepath.Join(staging, "old", rel)
+
+	switch step.Op {
+	case stepDir:
+		info, err := os.Stat(staged)
+		if err != nil {
+			return err
+		}
+		return os.Mkdir(live, info.Mode().Perm())
+	case stepFile:
+		if _, err := os.Lstat(live); err == nil {
+			if err := keepOld(live, old); err != nil {
+				return err
+			}
+		}
+		return rename(staged, live)
+	case stepRemove:
+		if err := os.MkdirAll(filepath.Dir(old), 0755); err != nil {
+			return err
+		}
+		return rename(live, old)
+	default:
+		return fmt.Errorf("unknown update step %q", step.Op)
+	}
+}
+
+// keepOld keeps the file at live under old before it is replaced
+// A hard link keeps live in place until the new file is renamed over it; where
+// links are not supported the file is moved aside instead.
+func keepOld(live string, old string) error {
+	if err := os.MkdirAll(filepath.Dir(old), 0755); err != nil {
+		return err
+	}
+	if err := os.Link(live, old); err == nil {
+		return nil
+	}
+	return rename(live, old)
+}
+
+// undoSteps reverses the steps of a commit in dir, whether or not each one
+// was applied, by moving the kept files back and removing the added ones
+func undoSteps(dir string, staging string, steps []stagedStep) error {
+	var firstErr error
+	for i := len(steps) - 1; i >= 0; i-- {
+		rel := filepath.FromSlash(steps[i].Name)
+		live := filepath.Join(dir, rel)
+		staged := filepath.Join(staging, "new", rel)
+		old := filepath.Join(staging, "old", rel)
+
+		var err error
+		switch steps[i].Op {
+		case stepDir:
+			// Fails harmlessly if the directory was never created or is in use
+			_ = os.Remove(live)
+		case stepFile, stepRemove:
+			if _, statErr := os.Lstat(old); statErr == nil {
+				err = os.Rename(old, live)
+			} else if _, statErr := os.Lstat(staged); steps[i].Op == stepFile && os.IsNotExist(statErr) {
+				// The file was added by the update
+				if err = os.Remove(live); os.IsNotExist(err) {
+					err = nil
+				}
+			}
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// recoverStaged cleans up after an update to dir that was interrupted
+// A commit that was in progress is undone from its journal, and a staging
+// directory left by an interrupted extraction is removed. It reports whether
+// there was anything to clean up.
+func recoverStaged(dir string) (bool, error) {
+	staging := filepath.Join(dir, stagingDirName)
+	if _, err := os.Lstat(staging); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(staging, journalName))
+	if err != nil && !os.IsNotExist(err) {
+		return true, fmt.Errorf("error reading update journal: %w", err)
+	}
+	if err == nil {
+		var steps []stagedStep
+		if err := json.Unmarshal(data, &steps); err != nil {
+			return true, fmt.Errorf("error reading update journal %s: %w", filepath.Join(staging, journalName), err)
+		}
+		if err := undoSteps(dir, staging, steps); err != nil {
+			return true, fmt.Errorf("error undoing interrupted update: %w", err)
+		}
+	}
+
+	if err := os.RemoveAll(staging); err != nil {
+		return true, fmt.Errorf("error removing staging directory: %w", err)
+	}
+	return true, nil
+}
+
+// swapDir moves src to dest, replacing any existing directory at dest in a
+// single step
+// Where the platform supports it the two directories are exchanged atomically,
+// and the old tree, now at src, is removed. Otherwise dest is moved aside
+// before src takes its place, and recoverSwap undoes a swap that was
+// interrupted between the two renames.
+func swapDir(src string, dest string) error {
+	if _, err := recoverSwap(dest); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(dest); os.IsNotExist(err) {
+		if err := rename(src, dest); err != nil {
+			return fmt.Errorf("error moving %s into place: %w", dest, err)
+		}
+		return nil
+	}
+
+	err := exchange(src, dest)
+	if err == nil {
+		_ = os.RemoveAll(src)
+		return nil
+	}
+	if !errors.Is(err, errExchangeUnsupported) {
+		return fmt.Errorf("error moving %s into place: %w", dest, err)
+	}
+
+	old := swapOldPath(dest)
+	if err := os.RemoveAll(old); err != nil {
+		return fmt.Errorf("error removing previous copy of %s: %w", dest, err)
+	}
+	if err := rename(dest, old); err != nil {
+		return fmt.Errorf("error moving %s aside: %w", dest, err)
+	}
+	if err := rename(src, dest); err != nil {
+		if restoreErr := os.Rename(old, dest); restoreErr != nil {
+			return fmt.Errorf("error moving %s into place: %w (restoring the previous copy from %s also failed: %v)", dest, err, old, restoreErr)
+		}
+		return fmt.Errorf("error moving %s into place: %w", dest, err)
+	}
+
+	// The swap is confirmed; the old tree is no longer needed
+	_ = os.RemoveAll(old)
+	return nil
+}
+
+// swapOldPath returns where swapDir moves dest aside to
+func swapOldPath(dest string) string {
+	return filepath.Join(filepath.Dir(dest), "."+filepath.Base(dest)+oldSuffix)
+}
+
+// recoverSwap cleans up after a swapDir into dest that was interrupted
+// If dest is missing the old tree is moved back; otherwise the swap completed
+// and the old tree is removed. It reports whether there was anything to clean up.
+func recoverSwap(dest string) (bool, error) {
+	old := swapOldPath(dest)
+	if _, err := os.Lstat(old); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if _, err := os.Lstat(dest); os.IsNotExist(err) {
+		if err := os.Rename(old, dest); err != nil {
+			return true, fmt.Errorf("error restoring %s: %w", dest, err)
+		}
+		return true, nil
+	}
+	if err := os.RemoveAll(old); err != nil {
+		return true, fmt.Errorf("error removing previous copy of %s: %w", dest, err)
+	}
+	return true, nil
+}
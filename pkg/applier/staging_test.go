@@ -0,0 +1,414 @@
+package applier
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createLiveDir creates an installed application directory for staging tests
+func createLiveDir(t *testing.T, dir string) {
+	t.Helper()
+
+	files := map[string]string{
+		"app":              "old app",
+		"lib/helper.so":    "old helper",
+		"config/local.yml": "local settings",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// assertLiveDirUnchanged checks that a failed update left the directory as createLiveDir made it
+func assertLiveDirUnchanged(t *testing.T, dir string) {
+	t.Helper()
+
+	for name, want := range map[string]string{
+		"app":              "old app",
+		"lib/helper.so":    "old helper",
+		"config/local.yml": "local settings",
+	} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("Failed to read %s: %v", name, err)
+			continue
+		}
+		if string(content) != want {
+			t.Errorf("%s = %q, want %q", name, string(content), want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.txt")); !os.IsNotExist(err) {
+		t.Error("new.txt should not exist after a failed update")
+	}
+
+	// No staging directory is left behind, in the directory or next to it
+	for _, parent := range []string{dir, filepath.Dir(dir)} {
+		entries, err := os.ReadDir(parent)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", parent, err)
+		}
+		for _, entry := range entries {
+			if strings.Contains(entry.Name(), ".guppy-") {
+				t.Errorf("Leftover %s after a failed update", filepath.Join(parent, entry.Name()))
+			}
+		}
+	}
+}
+
+func TestArchiveApplier_Apply_KeepsUnpackagedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	live := filepath.Join(tempDir, "myapp")
+	createLiveDir(t, live)
+
+	// A hard link outside the tree shares the live file's contents
+	outside := filepath.Join(tempDir, "app-link")
+	if err := os.Link(filepath.Join(live, "app"), outside); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+
+	archive := filepath.Join(tempDir, "update.tar.gz")
+	createTestTarGz(t, archive, map[string]string{
+		"app":     "new app",
+		"new.txt": "added",
+	})
+
+	applier := &ArchiveApplier{ExtractPath: live}
+	if err := applier.Apply(archive, filepath.Join(live, "app")); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"app":              "new app",
+		"new.txt":          "added",
+		"lib/helper.so":    "old helper",
+		"config/local.yml": "local settings",
+	} {
+		content, err := os.ReadFile(filepath.Join(live, name))
+		if err != nil || string(content) != want {
+			t.Errorf("%s = %q (%v), want %q", name, string(content), err, want)
+		}
+	}
+
+	// Replacing the file must not write through the hard link
+	if content, _ := os.ReadFile(outside); string(content) != "old app" {
+		t.Errorf("Hard-linked copy = %q, want %q", string(content), "old app")
+	}
+}
+
+func TestArchiveApplier_Apply_FailureMidwayTarGz(t *testing.T) {
+	tempDir := t.TempDir()
+	live := filepath.Join(tempDir, "myapp")
+	createLiveDir(t, live)
+
+	// Random contents don't compress, so truncating the archive cuts it off
+	// after the first files have been extracted
+	random := rand.New(rand.NewSource(1))
+	files := map[string]string{"new.txt": "added"}
+	for _, name := range []string{"app", "lib/helper.so", "data/a.bin", "data/b.bin"} {
+		content := make([]byte, 64*1024)
+		random.Read(content)
+		files[name] = string(content)
+	}
+
+	archive := filepath.Join(tempDir, "update.tar.gz")
+	createTestTarGz(t, archive, files)
+
+	info, err := os.Stat(archive)
+	if err != nil {
+		t.Fatalf("Failed to stat archive: %v", err)
+	}
+	if err := os.Truncate(archive, info.Size()*3/4); err != nil {
+		t.Fatalf("Failed to truncate archive: %v", err)
+	}
+
+	applier := &ArchiveApplier{ExtractPath: live}
+	if err := applier.Apply(archive, filepath.Join(live, "app")); err == nil {
+		t.Fatal("Apply() expected error for truncated archive, got nil")
+	}
+
+	assertLiveDirUnchanged(t, live)
+}
+
+func TestArchiveApplier_Apply_IllegalPathLeavesLiveDir(t *testing.T) {
+	tempDir := t.TempDir()
+	live := filepath.Join(tempDir, "myapp")
+	createLiveDir(t, live)
+
+	archive := filepath.Join(tempDir, "update.zip")
+	createTestZip(t, archive, map[string]string{
+		"app":           "new app",
+		"new.txt":       "added",
+		"../escape.txt": "outside the extract path",
+	})
+
+	applier := &ArchiveApplier{ExtractPath: live}
+	if err := applier.Apply(archive, filepath.Join(live, "app")); err == nil {
+		t.Fatal("Apply() expected error for illegal path, got nil")
+	}
+
+	assertLiveDirUnchanged(t, live)
+}
+
+func TestArchiveApplier_Apply_FailureMidwayExtraction(t *testing.T) {
+	tempDir := t.TempDir()
+	live := filepath.Join(tempDir, "myapp")
+	createLiveDir(t, live)
+
+	archive := filepath.Join(tempDir, "update.tar.gz")
+	createTestTarGz(t, archive, map[string]string{
+		"app":           "new app",
+		"lib/helper.so": "new helper",
+		"new.txt":       "added",
+	})
+
+	// Fail creating the second file, after the first has been extracted
+	defer func() { openFile = os.OpenFile }()
+	calls := 0
+	openFile = func(name string, flag int, perm os.FileMode) (*os.File, error) {
+		calls++
+		if calls == 2 {
+			return nil, errors.New("injected write failure")
+		}
+		return os.OpenFile(name, flag, perm)
+	}
+
+	applier := &ArchiveApplier{ExtractPath: live}
+	err := applier.Apply(archive, filepath.Join(live, "app"))
+	if err == nil {
+		t.Fatal("Apply() expected error for failed extraction, got nil")
+	}
+	if !strings.Contains(err.Error(), "injected write failure") {
+		t.Errorf("Apply() error = %v, want the injected failure", err)
+	}
+
+	assertLiveDirUnchanged(t, live)
+}
+
+func TestArchiveApplier_Apply_CommitFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	live := filepath.Join(tempDir, "myapp")
+	createLiveDir(t, live)
+
+	archive := filepath.Join(tempDir, "update.tar.gz")
+	createTestTarGz(t, archive, map[string]string{
+		"app":          "new app",
+		"new.txt":      "added",
+		"zzz/last.txt": "last",
+	})
+
+	// Fail moving the stale helper out of the way, the last step, after
+	// every new file has been moved into place
+	defer func() { rename = os.Rename }()
+	rename = func(oldpath, newpath string) error {
+		if oldpath == filepath.Join(live, "lib", "helper.so") {
+			return errors.New("injected rename failure")
+		}
+		return os.Rename(oldpath, newpath)
+	}
+
+	applier := &ArchiveApplier{ExtractPath: live, PreviousFiles: []string{"app", "lib/helper.so"}}
+	err := applier.Apply(archive, filepath.Join(live, "app"))
+	if err == nil {
+		t.Fatal("Apply() expected error for failed commit, got nil")
+	}
+	if !strings.Contains(err.Error(), "injected rename failure") {
+		t.Errorf("Apply() error = %v, want the injected failure", err)
+	}
+
+	assertLiveDirUnchanged(t, live)
+	if _, err := os.Stat(filepath.Join(live, "zzz")); !os.IsNotExist(err) {
+		t.Error("zzz should not exist after a failed update")
+	}
+}
+
+func TestArchiveApplier_Recover_InterruptedCommit(t *testing.T) {
+	tempDir := t.TempDir()
+	live := filepath.Join(tempDir, "myapp")
+	createLiveDir(t, live)
+
+	archive := filepath.Join(tempDir, "update.tar.gz")
+	createTestTarGz(t, archive, map[string]string{
+		"app":          "new app",
+		"new.txt":      "added",
+		"zzz/last.txt": "last",
+	})
+
+	// Simulate a crash partway through moving files into place
+	rename = func(oldpath, newpath string) error {
+		if filepath.Base(oldpath) == "last.txt" {
+			panic("crash")
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	func() {
+		defer func() { _ = recover() }()
+		applier := &ArchiveApplier{ExtractPath: live, PreviousFiles: []string{"app", "lib/helper.so"}}
+		_ = applier.Apply(archive, filepath.Join(live, "app"))
+	}()
+	rename = os.Rename
+
+	if content, _ := os.ReadFile(filepath.Join(live, "app")); string(content) != "new app" {
+		t.Fatalf("app = %q before recovery, want the interrupted update in place", string(content))
+	}
+
+	recovered, err := NewArchiveApplier().Recover(filepath.Join(live, "app"))
+	if err != nil {
+		t.Fatalf("Recover() failed: %v", err)
+	}
+	if !recovered {
+		t.Error("Recover() = false, want true")
+	}
+
+	assertLiveDirUnchanged(t, live)
+	if _, err := os.Stat(filepath.Join(live, "zzz")); !os.IsNotExist(err) {
+		t.Error("zzz should not exist after recovery")
+	}
+
+	// Nothing is left to recover
+	recovered, err = NewArchiveApplier().Recover(filepath.Join(live, "app"))
+	if err != nil || recovered {
+		t.Errorf("Recover() = %v, %v on a clean directory, want false, nil", recovered, err)
+	}
+}
+
+func TestArchiveApplier_Recover_InterruptedExtraction(t *testing.T) {
+	tempDir := t.TempDir()
+	live := filepath.Join(tempDir, "myapp")
+	createLiveDir(t, live)
+
+	archive := filepath.Join(tempDir, "update.tar.gz")
+	createTestTarGz(t, archive, map[string]string{
+		"app":     "new app",
+		"new.txt": "added",
+	})
+
+	// Simulate a crash while the archive is being extracted
+	openFile = func(name string, flag int, perm os.FileMode) (*os.File, error) {
+		panic("crash")
+	}
+	func() {
+		defer func() { _ = recover() }()
+		_ = (&ArchiveApplier{ExtractPath: live}).Apply(archive, filepath.Join(live, "app"))
+	}()
+	openFile = os.OpenFile
+
+	recovered, err := NewArchiveApplier().Recover(filepath.Join(live, "app"))
+	if err != nil {
+		t.Fatalf("Recover() failed: %v", err)
+	}
+	if !recovered {
+		t.Error("Recover() = false, want true")
+	}
+	assertLiveDirUnchanged(t, live)
+}
+
+func TestSwapDir(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "staging")
+	dest := filepath.Join(tempDir, "release")
+	for dir, content := range map[string]string{src: "new", dest: "old"} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "app"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	if err := swapDir(src, dest); err != nil {
+		t.Fatalf("swapDir() failed: %v", err)
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(dest, "app")); string(content) != "new" {
+		t.Errorf("app = %q, want %q", string(content), "new")
+	}
+	for _, path := range []string{src, swapOldPath(dest)} {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should not exist after swapDir()", path)
+		}
+	}
+}
+
+func TestVersionedApplier_Recover_InterruptedSwap(t *testing.T) {
+	tempDir := t.TempDir()
+	target := filepath.Join(tempDir, "myapp")
+	releaseDir := filepath.Join(target, releasesDir, "1.0.0")
+	if err := os.MkdirAll(releaseDir, 0755); err != nil {
+		t.Fatalf("Failed to create release directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(releaseDir, "app"), []byte("installed"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	source := filepath.Join(tempDir, "update.tar.gz")
+	createTestTarGz(t, source, map[string]string{"app": "reinstalled"})
+
+	// Without an atomic exchange the release is moved aside first; simulate
+	// a crash before the new copy takes its place
+	defer func() { exchange = exchangeDirs }()
+	exchange = func(a string, b string) error { return errExchangeUnsupported }
+	rename = func(oldpath, newpath string) error {
+		if strings.HasSuffix(oldpath, ".partial") {
+			panic("crash")
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	func() {
+		defer func() { _ = recover() }()
+		_ = NewVersionedApplier("1.0.0", 3).Apply(source, target)
+	}()
+	rename = os.Rename
+
+	if _, err := os.Stat(releaseDir); !os.IsNotExist(err) {
+		t.Fatalf("Release directory should be missing before recovery, got %v", err)
+	}
+
+	recovered, err := NewVersionedApplier("", 3).Recover(target)
+	if err != nil {
+		t.Fatalf("Recover() failed: %v", err)
+	}
+	if !recovered {
+		t.Error("Recover() = false, want true")
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(releaseDir, "app")); string(content) != "installed" {
+		t.Errorf("app = %q, want %q", string(content), "installed")
+	}
+	entries, err := os.ReadDir(filepath.Join(target, releasesDir))
+	if err != nil {
+		t.Fatalf("Failed to read releases directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("releases directory has %d entries after recovery, want 1", len(entries))
+	}
+}
+
+func TestArchiveApplier_Apply_NewExtractPath(t *testing.T) {
+	tempDir := t.TempDir()
+	live := filepath.Join(tempDir, "missing", "myapp")
+	if err := os.MkdirAll(filepath.Dir(live), 0755); err != nil {
+		t.Fatalf("Failed to create parent directory: %v", err)
+	}
+
+	archive := filepath.Join(tempDir, "update.zip")
+	createTestZip(t, archive, map[string]string{"app": "new app"})
+
+	applier := &ArchiveApplier{ExtractPath: live}
+	if err := applier.Apply(archive, filepath.Join(live, "app")); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(live, "app")); string(content) != "new app" {
+		t.Errorf("app = %q, want %q", string(content), "new app")
+	}
+}
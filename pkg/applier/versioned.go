@@ -0,0 +1,223 @@
+package applier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// releasesDir is the directory under the target that holds each release
+	releasesDir = "releases"
+	// currentLink is the symlink under the target that points at the active release
+	currentLink = "current"
+)
+
+// VersionedApplier installs each release into its own directory under
+// <target>/releases and then switches the <target>/current symlink to it
+type VersionedApplier struct {
+	// Version is the version being installed, used as its directory name
+	Version string
+	// Keep is the number of releases Prune leaves in place, including the current one
+	Keep int
+	// Archive holds the options used to extract archives; nil uses the defaults
+	Archive *ArchiveApplier
+}
+
+// NewVersionedApplier creates a new versioned applier
+func NewVersionedApplier(version string, keep int) *VersionedApplier {
+	return &VersionedApplier{
+		Version: version,
+		Keep:    keep,
+	}
+}
+
+// Apply installs the release into its own directory and points current at it
+// Archives are extracted into the release directory; any other file is copied into it
+func (v *VersionedApplier) Apply(source string, target string) error {
+	releaseDir, err := v.releaseDir(target)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(releaseDir), 0755); err != nil {
+		return fmt.Errorf("error creating releases directory: %w", err)
+	}
+
+	// Install into a staging directory first, so an interrupted extraction
+	// never leaves a partial release where current could point at it
+	staging := releaseDir + ".partial"
+	if err := os.RemoveAll(staging); err != nil {
+		return fmt.Errorf("error removing previous staging directory: %w", err)
+	}
+	if err := v.install(source, staging); err != nil {
+		_ = os.RemoveAll(staging)
+		return err
+	}
+	if err := swapDir(staging, releaseDir); err != nil {
+		_ = os.RemoveAll(staging)
+		return err
+	}
+
+	// Record the install time, which Prune uses to order releases
+	now := time.Now()
+	_ = os.Chtimes(releaseDir, now, now)
+
+	return switchLink(filepath.Join(target, currentLink), filepath.Join(releasesDir, filepath.Base(releaseDir)))
+}
+
+// Targets returns the current symlink, which is the only path Apply replaces
+// Restoring a backup of it switches back to the previous release
+func (v *VersionedApplier) Targets(source string, target string) ([]string, error) {
+	if _, err := v.releaseDir(target); err != nil {
+		return nil, err
+	}
+	return []string{filepath.Join(target, currentLink)}, nil
+}
+
+// Recover cleans up after an Apply to the target that was interrupted, such
+// as by a crash, and reports whether there was one
+// Partly installed releases are removed, and a release that was being
+// reinstalled is restored if it was moved aside.
+func (v *VersionedApplier) Recover(target string) (bool, error) {
+	dir := filepath.Join(target, releasesDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error reading releases directory: %w", err)
+	}
+
+	recovered := false
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case strings.HasSuffix(name, ".partial"):
+			if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+				return recovered, fmt.Errorf("error removing partial release %s: %w", name, err)
+			}
+			recovered = true
+		case strings.HasPrefix(name, ".") && strings.HasSuffix(name, oldSuffix):
+			release := strings.TrimSuffix(strings.TrimPrefix(name, "."), oldSuffix)
+			ok, err := recoverSwap(filepath.Join(dir, release))
+			if err != nil {
+				return recovered, err
+			}
+			recovered = recovered || ok
+		}
+	}
+	return recovered, nil
+}
+
+// Prune removes the oldest releases so that at most Keep remain
+// The release current points at is never removed, and neither are the releases
+// named in keep, such as those a backup of current would switch back to
+func (v *VersionedApplier) Prune(target string, keep []string) error {
+	dir := filepath.Join(target, releasesDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("error reading releases directory: %w", err)
+	}
+
+	current := ""
+	if link, err := os.Readlink(filepath.Join(target, currentLink)); err == nil {
+		current = filepath.Base(link)
+	}
+
+	type release struct {
+		name    string
+		modTime time.Time
+	}
+
+	var releases []release
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || strings.HasSuffix(entry.Name(), ".partial") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("error reading release %s: %w", entry.Name(), err)
+		}
+		releases = append(releases, release{name: entry.Name(), modTime: info.ModTime()})
+	}
+
+	// Newest first, with the current release ahead of everything else
+	sort.SliceStable(releases, func(i, j int) bool {
+		if (releases[i].name == current) != (releases[j].name == current) {
+			return releases[i].name == current
+		}
+		return releases[i].modTime.After(releases[j].modTime)
+	})
+
+	for i := v.Keep; i < len(releases); i++ {
+		if slices.Contains(keep, releases[i].name) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, releases[i].name)); err != nil {
+			return fmt.Errorf("error removing release %s: %w", releases[i].name, err)
+		}
+	}
+	return nil
+}
+
+// releaseDir returns the directory the release is installed into
+func (v *VersionedApplier) releaseDir(target string) (string, error) {
+	name := v.Version
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid release version for directory name: %q", v.Version)
+	}
+	return filepath.Join(target, releasesDir, name), nil
+}
+
+// install puts the contents of source into dir
+// Archives are extracted into dir; any other file is copied into it
+func (v *VersionedApplier) install(source string, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating release directory: %w", err)
+	}
+
+	f, err := detectFormat(source)
+	if err != nil {
+		return err
+	}
+	if f.Archive != archiveNone {
+		archive := v.Archive
+		if archive == nil {
+			archive = NewArchiveApplier()
+		}
+		return archive.extract(source, dir)
+	}
+
+	// Single files are installed under their asset name, without the
+	// compression extension if they are decompressed
+	name := filepath.Base(source)
+	if f.Compression != compressionNone {
+		if byExt, ok := formatFromExtension(name); ok && byExt == f {
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+	}
+	return NewBinaryApplier().Apply(source, filepath.Join(dir, name))
+}
+
+// switchLink atomically points link at dest by renaming a new symlink over it
+func switchLink(link string, dest string) error {
+	if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("cannot switch %s: it exists and is not a symlink", link)
+	}
+
+	temp := link + ".tmp"
+	_ = os.Remove(temp)
+	if err := os.Symlink(dest, temp); err != nil {
+		return fmt.Errorf("error creating symlink: %w", err)
+	}
+	if err := os.Rename(temp, link); err != nil {
+		_ = os.Remove(temp)
+		return fmt.Errorf("error switching %s: %w", link, err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package applier
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestNewVersionedApplier(t *testing.T) {
+	applier := NewVersionedApplier("1.0.0", 3)
+	if applier == nil {
+		t.Fatal("NewVersionedApplier() returned nil")
+	}
+	if applier.Version != "1.0.0" || applier.Keep != 3 {
+		t.Errorf("NewVersionedApplier() = %+v, want version 1.0.0 keeping 3", applier)
+	}
+}
+
+func TestVersionedApplier_Apply(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping symlink test on Windows")
+	}
+
+	tempDir := t.TempDir()
+	target := filepath.Join(tempDir, "myapp")
+
+	for _, v := range []string{"1.0.0", "1.1.0"} {
+		archive := filepath.Join(tempDir, "myapp-"+v+".tar.gz")
+		createTestTarGz(t, archive, map[string]string{
+			"bin/myapp":   "binary " + v,
+			"config.yaml": "config " + v,
+		})
+
+		if err := NewVersionedApplier(v, 3).Apply(archive, target); err != nil {
+			t.Fatalf("Apply(%s) failed: %v", v, err)
+		}
+
+		content, err := os.ReadFile(filepath.Join(target, "current", "bin", "myapp"))
+		if err != nil {
+			t.Fatalf("Failed to read through current symlink: %v", err)
+		}
+		if string(content) != "binary "+v {
+			t.Errorf("current/bin/myapp = %q, want %q", string(content), "binary "+v)
+		}
+	}
+
+	link, err := os.Readlink(filepath.Join(target, "current"))
+	if err != nil {
+		t.Fatalf("current is not a symlink: %v", err)
+	}
+	if link != filepath.Join("releases", "1.1.0") {
+		t.Errorf("current -> %s, want releases/1.1.0", link)
+	}
+
+	// The previous release stays in place for rollback
+	if _, err := os.Stat(filepath.Join(target, "releases", "1.0.0", "bin", "myapp")); err != nil {
+		t.Errorf("Previous release was removed: %v", err)
+	}
+}
+
+func TestVersionedApplier_Apply_Binary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping symlink test on Windows")
+	}
+
+	tempDir := t.TempDir()
+	target := filepath.Join(tempDir, "myapp")
+	source := filepath.Join(tempDir, "myapp-linux-amd64")
+	if err := os.WriteFile(source, []byte("binary"), 0644); err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+
+	if err := NewVersionedApplier("2.0.0", 3).Apply(source, target); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(target, "current", "myapp-linux-amd64"))
+	if err != nil {
+		t.Fatalf("Binary not installed under current: %v", err)
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		t.Errorf("Installed binary mode = %v, want executable", info.Mode().Perm())
+	}
+}
+
+func TestVersionedApplier_Apply_FailureKeepsCurrent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping symlink test on Windows")
+	}
+
+	tempDir := t.TempDir()
+	target := filepath.Join(tempDir, "myapp")
+
+	good := filepath.Join(tempDir, "good.tar.gz")
+	createTestTarGz(t, good, map[string]string{"app": "good"})
+	if err := NewVersionedApplier("1.0.0", 3).Apply(good, target); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	corrupt := filepath.Join(tempDir, "corrupt.tar.gz")
+	if err := os.WriteFile(corrupt, []byte("not a tar.gz file"), 0644); err != nil {
+		t.Fatalf("Failed to create corrupt archive: %v", err)
+	}
+	if err := NewVersionedApplier("2.0.0", 3).Apply(corrupt, target); err == nil {
+		t.Fatal("Apply() expected error for corrupt archive, got nil")
+	}
+
+	if link, _ := os.Readlink(filepath.Join(target, "current")); link != filepath.Join("releases", "1.0.0") {
+		t.Errorf("current -> %s after failed Apply(), want releases/1.0.0", link)
+	}
+	for _, name := range []string{"2.0.0", "2.0.0.partial"} {
+		if _, err := os.Stat(filepath.Join(target, "releases", name)); !os.IsNotExist(err) {
+			t.Errorf("releases/%s should not exist after failed Apply()", name)
+		}
+	}
+}
+
+func TestVersionedApplier_Apply_InvalidVersion(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "app")
+	if err := os.WriteFile(source, []byte("binary"), 0644); err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+
+	for _, v := range []string{"", "..", "../escape", `1.0\0`} {
+		if err := NewVersionedApplier(v, 3).Apply(source, tempDir); err == nil {
+			t.Errorf("Apply() with version %q expected error, got nil", v)
+		}
+	}
+}
+
+func TestVersionedApplier_Targets(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "myapp")
+
+	paths, err := NewVersionedApplier("1.0.0", 3).Targets("myapp.tar.gz", target)
+	if err != nil {
+		t.Fatalf("Targets() failed: %v", err)
+	}
+	if len(paths) != 1 || paths[0] != filepath.Join(target, "current") {
+		t.Errorf("Targets() = %v, want only the current symlink", paths)
+	}
+}
+
+func TestVersionedApplier_Prune(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping symlink test on Windows")
+	}
+
+	tempDir := t.TempDir()
+	target := filepath.Join(tempDir, "myapp")
+	source := filepath.Join(tempDir, "app")
+	if err := os.WriteFile(source, []byte("binary"), 0644); err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+
+	versions := []string{"1.0.0", "1.1.0", "1.2.0", "1.3.0"}
+	base := time.Now().Add(-time.Hour)
+	for i, v := range versions {
+		if err := NewVersionedApplier(v, 2).Apply(source, target); err != nil {
+			t.Fatalf("Apply(%s) failed: %v", v, err)
+		}
+		// Space out install times so the order is deterministic
+		installed := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(filepath.Join(target, "releases", v), installed, installed); err != nil {
+			t.Fatalf("Failed to set install time: %v", err)
+		}
+	}
+
+	// Roll current back to the oldest release; it must survive pruning
+	if err := switchLink(filepath.Join(target, "current"), filepath.Join("releases", "1.0.0")); err != nil {
+		t.Fatalf("switchLink() failed: %v", err)
+	}
+
+	if err := NewVersionedApplier("1.3.0", 2).Prune(target, nil); err != nil {
+		t.Fatalf("Prune() failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(target, "releases"))
+	if err != nil {
+		t.Fatalf("Failed to read releases: %v", err)
+	}
+	var remaining []string
+	for _, entry := range entries {
+		remaining = append(remaining, entry.Name())
+	}
+	if len(remaining) != 2 || remaining[0] != "1.0.0" || remaining[1] != "1.3.0" {
+		t.Errorf("Releases after Prune() = %v, want [1.0.0 1.3.0]", remaining)
+	}
+}
+
+func TestVersionedApplier_SwitchLink_NotSymlink(t *testing.T) {
+	tempDir := t.TempDir()
+	current := filepath.Join(tempDir, "current")
+	if err := os.Mkdir(current, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	if err := switchLink(current, "releases/1.0.0"); err == nil {
+		t.Error("switchLink() expected error when current is a directory, got nil")
+	}
+}
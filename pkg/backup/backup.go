@@ -0,0 +1,296 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// metadataFile is the name of the file describing a generation
+const metadataFile = "backup.json"
+
+// Entry is a single path saved in a backup generation
+type Entry struct {
+	Path    string      `json:"path"`             // Absolute path of the file
+	Existed bool        `json:"existed"`          // False if the update created the file
+	Mode    os.FileMode `json:"mode,omitempty"`   // File mode of the saved file
+	Link    string      `json:"link,omitempty"`   // Symlink target, if the path was a symlink
+	Stored  string      `json:"stored,omitempty"` // Name of the saved copy within the generation
+}
+
+// Generation is a snapshot of the files an update replaced
+type Generation struct {
+	ID      string    `json:"id"`
+	Version string    `json:"version"` // Version that was installed before the update
+	Created time.Time `json:"created"`
+	Entries []Entry   `json:"entries"`
+	dir     string
+}
+
+// Dir returns the directory holding the generation
+func (g *Generation) Dir() string {
+	return g.dir
+}
+
+// Store keeps previous generations of applied files under a state directory
+type Store struct {
+	Dir  string
+	Keep int // Number of generations to keep
+}
+
+// NewStore creates a new backup store
+func NewStore(dir string, keep int) *Store {
+	return &Store{
+		Dir:  dir,
+		Keep: keep,
+	}
+}
+
+// Create saves the current contents of paths as a new generation
+// Paths that do not exist yet are recorded so that a restore removes them
+func (s *Store) Create(version string, paths []string) (*Generation, error) {
+	id, err := s.nextID()
+	if err != nil {
+		return nil, err
+	}
+
+	gen := &Generation{
+		ID:      id,
+		Version: version,
+		Created: time.Now().UTC(),
+		dir:     filepath.Join(s.Dir, id),
+	}
+
+	if err := os.MkdirAll(gen.dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating backup directory: %w", err)
+	}
+
+	for i, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			_ = os.RemoveAll(gen.dir)
+			return nil, fmt.Errorf("error resolving path %s: %w", path, err)
+		}
+
+		entry, err := saveEntry(absPath, gen.dir, strconv.Itoa(i))
+		if err != nil {
+			_ = os.RemoveAll(gen.dir)
+			return nil, err
+		}
+		gen.Entries = append(gen.Entries, entry)
+	}
+
+	if err := writeMetadata(gen); err != nil {
+		_ = os.RemoveAll(gen.dir)
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// saveEntry copies path into the generation directory under the given name
+func saveEntry(path, dir, name string) (Entry, error) {
+	entry := Entry{Path: path}
+
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return entry, nil
+	}
+	if err != nil {
+		return entry, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	entry.Existed = true
+	entry.Mode = info.Mode()
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		entry.Link, err = os.Readlink(path)
+		if err != nil {
+			return entry, fmt.Errorf("error reading symlink %s: %w", path, err)
+		}
+		return entry, nil
+	}
+
+	if !info.Mode().IsRegular() {
+		return entry, fmt.Errorf("cannot back up %s: not a regular file", path)
+	}
+
+	entry.Stored = name
+	if err := copyFile(path, filepath.Join(dir, name), info.Mode().Perm()); err != nil {
+		return entry, fmt.Errorf("error backing up %s: %w", path, err)
+	}
+	return entry, nil
+}
+
+// List returns every generation, newest first
+func (s *Store) List() ([]*Generation, error) {
+	dirEntries, err := os.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup directory: %w", err)
+	}
+
+	var generations []*Generation
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(s.Dir, dirEntry.Name())
+		data, err := os.ReadFile(filepath.Join(dir, metadataFile))
+		if err != nil {
+			// Skip directories that are not complete generations
+			continue
+		}
+
+		var gen Generation
+		if err := json.Unmarshal(data, &gen); err != nil {
+			return nil, fmt.Errorf("error decoding backup %s: %w", dirEntry.Name(), err)
+		}
+		gen.dir = dir
+		generations = append(generations, &gen)
+	}
+
+	sort.Slice(generations, func(i, j int) bool {
+		return generations[i].ID > generations[j].ID
+	})
+	return generations, nil
+}
+
+// Latest returns the newest generation, or nil if there are no backups
+func (s *Store) Latest() (*Generation, error) {
+	generations, err := s.List()
+	if err != nil || len(generations) == 0 {
+		return nil, err
+	}
+	return generations[0], nil
+}
+
+// Restore puts every file in the generation back in place, removes files the
+// update created, and then deletes the generation
+func (s *Store) Restore(gen *Generation) error {
+	for _, entry := range gen.Entries {
+		if err := restoreEntry(gen.dir, entry); err != nil {
+			return err
+		}
+	}
+	return s.Remove(gen)
+}
+
+// restoreEntry restores a single path from the generation directory
+func restoreEntry(dir string, entry Entry) error {
+	if !entry.Existed {
+		if err := os.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing %s: %w", entry.Path, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(entry.Path), 0755); err != nil {
+		return fmt.Errorf("error creating directory for %s: %w", entry.Path, err)
+	}
+
+	// Restore into a temporary file and rename it over the path, so a running
+	// binary is replaced rather than overwritten
+	temp := entry.Path + ".guppy-restore"
+	_ = os.Remove(temp)
+
+	if entry.Link != "" {
+		if err := os.Symlink(entry.Link, temp); err != nil {
+			return fmt.Errorf("error restoring symlink %s: %w", entry.Path, err)
+		}
+	} else if err := copyFile(filepath.Join(dir, entry.Stored), temp, entry.Mode.Perm()); err != nil {
+		_ = os.Remove(temp)
+		return fmt.Errorf("error restoring %s: %w", entry.Path, err)
+	}
+
+	if err := os.Rename(temp, entry.Path); err != nil {
+		_ = os.Remove(temp)
+		return fmt.Errorf("error restoring %s: %w", entry.Path, err)
+	}
+	return nil
+}
+
+// Remove deletes a generation
+func (s *Store) Remove(gen *Generation) error {
+	if err := os.RemoveAll(gen.dir); err != nil {
+		return fmt.Errorf("error removing backup %s: %w", gen.ID, err)
+	}
+	return nil
+}
+
+// Prune deletes the oldest generations so that at most Keep remain
+func (s *Store) Prune() error {
+	generations, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	for i := s.Keep; i < len(generations); i++ {
+		if err := s.Remove(generations[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextID returns a sortable ID that is newer than every existing generation
+func (s *Store) nextID() (string, error) {
+	generations, err := s.List()
+	if err != nil {
+		return "", err
+	}
+
+	next := 1
+	if len(generations) > 0 {
+		latest, err := strconv.Atoi(generations[0].ID)
+		if err == nil {
+			next = latest + 1
+		}
+	}
+	return fmt.Sprintf("%06d", next), nil
+}
+
+// writeMetadata writes the generation description into its directory
+func writeMetadata(gen *Generation) error {
+	data, err := json.MarshalIndent(gen, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding backup metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(gen.dir, metadataFile), data, 0644); err != nil {
+		return fmt.Errorf("error writing backup metadata: %w", err)
+	}
+	return nil
+}
+
+// copyFile copies src to dest with the given permissions
+func copyFile(src, dest string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// OpenFile applies the umask, so set the mode explicitly
+	return os.Chmod(dest, perm)
+}
@@ -0,0 +1,167 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestStore_CreateAndRestore(t *testing.T) {
+	tempDir := t.TempDir()
+	store := NewStore(filepath.Join(tempDir, "state", "backups"), 2)
+
+	binary := filepath.Join(tempDir, "app", "bin", "app")
+	config := filepath.Join(tempDir, "app", "config.yml")
+	created := filepath.Join(tempDir, "app", "lib", "new.so")
+
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(binary, []byte("old binary"), 0755); err != nil {
+		t.Fatalf("Failed to write binary: %v", err)
+	}
+	if err := os.WriteFile(config, []byte("old config"), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	gen, err := store.Create("v1.0.0", []string{binary, config, created})
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if gen.Version != "v1.0.0" || len(gen.Entries) != 3 {
+		t.Fatalf("Create() = version %q with %d entries, want v1.0.0 with 3", gen.Version, len(gen.Entries))
+	}
+	if gen.Entries[2].Existed {
+		t.Error("Create() should record that the new file did not exist")
+	}
+
+	// Simulate the update
+	if err := os.WriteFile(binary, []byte("new binary"), 0755); err != nil {
+		t.Fatalf("Failed to write binary: %v", err)
+	}
+	if err := os.WriteFile(config, []byte("new config"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(created), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(created, []byte("added"), 0644); err != nil {
+		t.Fatalf("Failed to write new file: %v", err)
+	}
+
+	latest, err := store.Latest()
+	if err != nil {
+		t.Fatalf("Latest() failed: %v", err)
+	}
+	if latest == nil || latest.ID != gen.ID {
+		t.Fatalf("Latest() = %v, want generation %s", latest, gen.ID)
+	}
+
+	if err := store.Restore(latest); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+
+	if content, _ := os.ReadFile(binary); string(content) != "old binary" {
+		t.Errorf("Restored binary = %q, want %q", string(content), "old binary")
+	}
+	if content, _ := os.ReadFile(config); string(content) != "old config" {
+		t.Errorf("Restored config = %q, want %q", string(content), "old config")
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Error("Restore() should remove files the update created")
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(config)
+		if err != nil {
+			t.Fatalf("Failed to stat config: %v", err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("Restored config mode = %v, want 0600", info.Mode().Perm())
+		}
+	}
+
+	// The generation is consumed by the restore
+	if latest, _ := store.Latest(); latest != nil {
+		t.Errorf("Latest() after Restore() = %s, want no backups", latest.ID)
+	}
+}
+
+func TestStore_Symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping symlink test on Windows")
+	}
+
+	tempDir := t.TempDir()
+	store := NewStore(filepath.Join(tempDir, "backups"), 1)
+
+	link := filepath.Join(tempDir, "current")
+	if err := os.Symlink("releases/1.0.0", link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	gen, err := store.Create("1.0.0", []string{link})
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	_ = os.Remove(link)
+	if err := os.Symlink("releases/2.0.0", link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	if err := store.Restore(gen); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+
+	target, err := os.Readlink(link)
+	if err != nil {
+		t.Fatalf("Restored path is not a symlink: %v", err)
+	}
+	if target != "releases/1.0.0" {
+		t.Errorf("Restored symlink target = %q, want releases/1.0.0", target)
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	tempDir := t.TempDir()
+	store := NewStore(filepath.Join(tempDir, "backups"), 2)
+
+	file := filepath.Join(tempDir, "app")
+	for _, v := range []string{"1.0.0", "1.1.0", "1.2.0", "1.3.0"} {
+		if err := os.WriteFile(file, []byte(v), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if _, err := store.Create(v, []string{file}); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+	}
+
+	if err := store.Prune(); err != nil {
+		t.Fatalf("Prune() failed: %v", err)
+	}
+
+	generations, err := store.List()
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(generations) != 2 {
+		t.Fatalf("List() returned %d generations after Prune(), want 2", len(generations))
+	}
+	if generations[0].Version != "1.3.0" || generations[1].Version != "1.2.0" {
+		t.Errorf("List() = %s, %s, want the two newest generations", generations[0].Version, generations[1].Version)
+	}
+}
+
+func TestStore_Empty(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "missing"), 1)
+
+	latest, err := store.Latest()
+	if err != nil {
+		t.Fatalf("Latest() failed: %v", err)
+	}
+	if latest != nil {
+		t.Errorf("Latest() = %v, want nil for an empty store", latest)
+	}
+}
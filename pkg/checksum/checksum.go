@@ -1,51 +1,216 @@
 package checksum
 
 import (
+	"crypto/md5"
+	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"strings"
+
+	"golang.org/x/crypto/blake2b"
 )
 
-// VerifySHA256 verifies the SHA256 checksum of a file
-func VerifySHA256(filePath string, expectedChecksum string) (bool, error) {
-	// Open the file
-	file, err := os.Open(filePath)
-	if err != nil {
-		return false, fmt.Errorf("error opening file: %w", err)
+// Supported algorithms, as used in "algo:hex" digests
+const (
+	SHA256  = "sha256"
+	SHA512  = "sha512"
+	SHA1    = "sha1"
+	MD5     = "md5"
+	BLAKE2b = "blake2b"
+)
+
+// Digest is an expected checksum and the algorithm that produced it
+type Digest struct {
+	Algorithm string
+	Hex       string
+}
+
+// String returns the digest in "algo:hex" form
+func (d Digest) String() string {
+	return d.Algorithm + ":" + d.Hex
+}
+
+// Parse parses an "algo:hex" digest
+// A bare hex digest is accepted when its length identifies the algorithm:
+// 32 characters for md5, 40 for sha1, 64 for sha256 and 128 for sha512
+func Parse(digest string) (Digest, error) {
+	digest = strings.ToLower(strings.TrimSpace(digest))
+	if digest == "" {
+		return Digest{}, fmt.Errorf("empty checksum")
 	}
-	defer func() { _ = file.Close() }()
 
-	// Calculate SHA256
-	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return false, fmt.Errorf("error calculating checksum: %w", err)
+	var d Digest
+	if algorithm, value, ok := strings.Cut(digest, ":"); ok {
+		d = Digest{Algorithm: algorithm, Hex: value}
+	} else {
+		d = Digest{Algorithm: algorithmForLength(len(digest)), Hex: digest}
+		if d.Algorithm == "" {
+			return Digest{}, fmt.Errorf("cannot tell the algorithm of checksum %s; use the algo:hex form", digest)
+		}
+	}
+
+	if _, err := newHash(d.Algorithm, len(d.Hex)/2); err != nil {
+		return Digest{}, err
+	}
+	if _, err := hex.DecodeString(d.Hex); err != nil {
+		return Digest{}, fmt.Errorf("invalid %s checksum %s: not hexadecimal", d.Algorithm, d.Hex)
 	}
+	if size := digestSize(d.Algorithm, len(d.Hex)/2); len(d.Hex) != size*2 {
+		return Digest{}, fmt.Errorf("invalid %s checksum %s: want %d hex characters, got %d", d.Algorithm, d.Hex, size*2, len(d.Hex))
+	}
+	return d, nil
+}
 
-	// Get the checksum as hex string
-	actualChecksum := hex.EncodeToString(hash.Sum(nil))
+// Normalize returns a digest in "algo:hex" form for a provider to publish
+// The value is not fully validated until it is verified. An empty digest
+// returns "", meaning there is no checksum; a digest that is present but
+// cannot be verified, because its algorithm is not supported or cannot be
+// told from its length, is an error so that verification is never skipped
+func Normalize(digest string) (string, error) {
+	digest = strings.ToLower(strings.TrimSpace(digest))
+	if digest == "" {
+		return "", nil
+	}
 
-	// Compare checksums (case-insensitive)
-	expectedChecksum = strings.ToLower(strings.TrimSpace(expectedChecksum))
-	actualChecksum = strings.ToLower(actualChecksum)
+	algorithm, value, ok := strings.Cut(digest, ":")
+	if !ok {
+		algorithm, value = algorithmForLength(len(digest)), digest
+		if algorithm == "" {
+			return "", fmt.Errorf("cannot tell the algorithm of checksum %s; use the algo:hex form", digest)
+		}
+	}
+	if !Supported(algorithm) {
+		return "", fmt.Errorf("unsupported hash algorithm: %s", algorithm)
+	}
+	if value == "" {
+		return "", fmt.Errorf("empty %s checksum", algorithm)
+	}
+	return algorithm + ":" + value, nil
+}
 
-	return actualChecksum == expectedChecksum, nil
+// Supported reports whether an algorithm can be verified
+func Supported(algorithm string) bool {
+	switch algorithm {
+	case SHA256, SHA512, SHA1, MD5, BLAKE2b:
+		return true
+	}
+	return false
 }
 
-// CalculateSHA256 calculates the SHA256 checksum of a file
-func CalculateSHA256(filePath string) (string, error) {
+// algorithmForLength returns the algorithm of a bare hex digest, or "" if the length is ambiguous
+func algorithmForLength(length int) string {
+	switch length {
+	case 32:
+		return MD5
+	case 40:
+		return SHA1
+	case 64:
+		return SHA256
+	case 128:
+		return SHA512
+	}
+	return ""
+}
+
+// digestSize returns the size in bytes of an algorithm's digest
+// BLAKE2b digests may be any size up to 64 bytes, so the size given is kept
+// when it is valid; otherwise the full 64 bytes is expected
+func digestSize(algorithm string, size int) int {
+	switch algorithm {
+	case MD5:
+		return md5.Size
+	case SHA1:
+		return sha1.Size
+	case SHA256:
+		return sha256.Size
+	case SHA512:
+		return sha512.Size
+	default:
+		if size < 1 || size > blake2b.Size {
+			return blake2b.Size
+		}
+		return size
+	}
+}
+
+// newHash returns a hash for the algorithm, producing size bytes for BLAKE2b
+func newHash(algorithm string, size int) (hash.Hash, error) {
+	switch algorithm {
+	case SHA256:
+		return sha256.New(), nil
+	case SHA512:
+		return sha512.New(), nil
+	case SHA1:
+		return sha1.New(), nil
+	case MD5:
+		return md5.New(), nil
+	case BLAKE2b:
+		return blake2b.New(digestSize(BLAKE2b, size), nil)
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm: %s", algorithm)
+	}
+}
+
+// Verify checks a file against an "algo:hex" digest
+// It returns false if the file does not match, and an error if the digest is
+// malformed or the file cannot be read
+func Verify(filePath string, expected string) (bool, error) {
+	d, err := Parse(expected)
+	if err != nil {
+		return false, err
+	}
+
+	actual, err := calculate(filePath, d.Algorithm, len(d.Hex)/2)
+	if err != nil {
+		return false, err
+	}
+	return actual == d.Hex, nil
+}
+
+// Calculate returns the hex digest of a file using the given algorithm
+// BLAKE2b produces a 64-byte digest
+func Calculate(filePath string, algorithm string) (string, error) {
+	return calculate(filePath, algorithm, blake2b.Size)
+}
+
+// calculate returns the hex digest of a file, producing size bytes for BLAKE2b
+func calculate(filePath string, algorithm string, size int) (string, error) {
+	hash, err := newHash(algorithm, size)
+	if err != nil {
+		return "", err
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", fmt.Errorf("error opening file: %w", err)
 	}
 	defer func() { _ = file.Close() }()
 
-	hash := sha256.New()
 	if _, err := io.Copy(hash, file); err != nil {
 		return "", fmt.Errorf("error calculating checksum: %w", err)
 	}
 
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
+
+// VerifySHA256 verifies the SHA256 checksum of a file
+func VerifySHA256(filePath string, expectedChecksum string) (bool, error) {
+	actualChecksum, err := CalculateSHA256(filePath)
+	if err != nil {
+		return false, err
+	}
+
+	// Compare checksums (case-insensitive)
+	expectedChecksum = strings.ToLower(strings.TrimSpace(expectedChecksum))
+	return actualChecksum == expectedChecksum, nil
+}
+
+// CalculateSHA256 calculates the SHA256 checksum of a file
+func CalculateSHA256(filePath string) (string, error) {
+	return Calculate(filePath, SHA256)
+}
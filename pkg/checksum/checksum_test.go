@@ -269,3 +269,164 @@ func TestVerifySHA256_SymlinkToFile(t *testing.T) {
 		t.Error("VerifySHA256() should validate file through symlink")
 	}
 }
+
+func TestVerify(t *testing.T) {
+	tempDir := t.TempDir()
+	testFilePath := filepath.Join(tempDir, "testfile.txt")
+	if err := os.WriteFile(testFilePath, []byte("test content for checksum verification"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		filePath  string
+		digest    string
+		wantValid bool
+		wantErr   bool
+	}{
+		{
+			name:      "sha256",
+			digest:    "sha256:0bb4f3131cf52feab05638958f23f10539388ba67cd7977f5ffc46add6a3fff5",
+			wantValid: true,
+		},
+		{
+			name:      "sha512",
+			digest:    "sha512:c741dbe28c87494df55b24c2f81a7904b92d1a873e46a410cc7f6951aed4e408be4bcbc233188381a55502b8e193ca06ab71b48678b9ea41f1d9912ec87c55d4",
+			wantValid: true,
+		},
+		{
+			name:      "sha1",
+			digest:    "sha1:9972a14ef931c289b5122e6e1b7005e7891f28ff",
+			wantValid: true,
+		},
+		{
+			name:      "md5",
+			digest:    "md5:d28cd39b02ce37082426395b9385f56e",
+			wantValid: true,
+		},
+		{
+			name:      "blake2b",
+			digest:    "blake2b:b98fa69eb911b6c3803699928ff3296565ef5424997a4429b6af83ea6538ab8ad20665a0fa895d1e9d3922fac07a17e2095606796c9fa6ef337d6bfc99b88b53",
+			wantValid: true,
+		},
+		{
+			name:      "blake2b-256 length",
+			digest:    "blake2b:547b2cb93d6a9c4be0f36c8d9189986fc365d55b436eeddcef13933d26e9be5c",
+			wantValid: true,
+		},
+		{
+			name:      "uppercase algorithm and hex",
+			digest:    "SHA256:0BB4F3131CF52FEAB05638958F23F10539388BA67CD7977F5FFC46ADD6A3FFF5",
+			wantValid: true,
+		},
+		{
+			name:      "bare sha256",
+			digest:    "0bb4f3131cf52feab05638958f23f10539388ba67cd7977f5ffc46add6a3fff5",
+			wantValid: true,
+		},
+		{
+			name:      "mismatch",
+			digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+			wantValid: false,
+		},
+		{
+			name:    "wrong length for algorithm",
+			digest:  "sha512:0bb4f3131cf52feab05638958f23f10539388ba67cd7977f5ffc46add6a3fff5",
+			wantErr: true,
+		},
+		{
+			name:    "not hexadecimal",
+			digest:  "sha256:wronghash",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported algorithm",
+			digest:  "sha3-256:0bb4f3131cf52feab05638958f23f10539388ba67cd7977f5ffc46add6a3fff5",
+			wantErr: true,
+		},
+		{
+			name:    "bare digest of unknown length",
+			digest:  "abc123",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			digest:  "",
+			wantErr: true,
+		},
+		{
+			name:     "file does not exist",
+			filePath: filepath.Join(tempDir, "nonexistent.txt"),
+			digest:   "md5:d28cd39b02ce37082426395b9385f56e",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := tt.filePath
+			if filePath == "" {
+				filePath = testFilePath
+			}
+
+			valid, err := Verify(filePath, tt.digest)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if valid != tt.wantValid {
+				t.Errorf("Verify() valid = %v, want %v", valid, tt.wantValid)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		digest  string
+		want    string
+		wantErr bool
+	}{
+		{"sha256:ABC123", "sha256:abc123", false},
+		{" blake2b:abc ", "blake2b:abc", false},
+		{"d28cd39b02ce37082426395b9385f56e", "md5:d28cd39b02ce37082426395b9385f56e", false},
+		{"", "", false},
+		{"sha3-256:abc", "", true},
+		{"sha384:abc", "", true},
+		{"sha256:", "", true},
+		{"abc123", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := Normalize(tt.digest)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Normalize(%q) error = %v, wantErr %v", tt.digest, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.digest, got, tt.want)
+		}
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	testFilePath := filepath.Join(t.TempDir(), "testfile.txt")
+	if err := os.WriteFile(testFilePath, []byte("test content for checksum verification"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	sum, err := Calculate(testFilePath, SHA512)
+	if err != nil {
+		t.Fatalf("Calculate() failed: %v", err)
+	}
+	d, err := Parse(SHA512 + ":" + sum)
+	if err != nil {
+		t.Fatalf("Parse() failed on Calculate() output: %v", err)
+	}
+	if valid, err := Verify(testFilePath, d.String()); err != nil || !valid {
+		t.Errorf("Verify(%s) = %v, %v, want a match", d, valid, err)
+	}
+
+	if _, err := Calculate(testFilePath, "crc32"); err == nil {
+		t.Error("Calculate() expected error, got nil")
+	}
+}
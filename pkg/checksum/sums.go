@@ -0,0 +1,102 @@
+package checksum
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// sumsFilePattern matches the names of checksum files published alongside
+// release assets, e.g. SHA256SUMS, checksums.txt or myapp_1.2.0_checksums.txt
+var sumsFilePattern = regexp.MustCompile(`(?i)(^|[._-])(checksums|sha256sums|sha512sums|sha1sums|md5sums|b2sums)(\.txt)?$`)
+
+// bsdLinePattern matches a BSD-style line such as "SHA256 (myapp.tar.gz) = <hex>"
+var bsdLinePattern = regexp.MustCompile(`^([A-Za-z0-9-]+) \((.+)\) ?= ?([0-9A-Fa-f]+)$`)
+
+// maxSumsFileSize limits how much of a checksum file is read
+const maxSumsFileSize = 1 << 20
+
+// IsSumsFile reports whether a file name looks like a checksum file
+func IsSumsFile(name string) bool {
+	return sumsFilePattern.MatchString(name)
+}
+
+// AlgorithmFromName returns the algorithm a checksum file's name implies,
+// e.g. sha512 for SHA512SUMS, or "" if the name does not say
+func AlgorithmFromName(name string) string {
+	name = strings.ToLower(path.Base(name))
+	switch {
+	case strings.Contains(name, SHA512):
+		return SHA512
+	case strings.Contains(name, SHA256):
+		return SHA256
+	case strings.Contains(name, SHA1):
+		return SHA1
+	case strings.Contains(name, MD5):
+		return MD5
+	case strings.Contains(name, BLAKE2b), strings.HasPrefix(name, "b2sums"):
+		return BLAKE2b
+	}
+	return ""
+}
+
+// FindInSums looks up fileName in a checksum file and returns its digest in
+// "algo:hex" form, or "" if the file is not listed
+// A listed digest that cannot be verified is an error.
+// Both coreutils-style lines ("<hex>  <name>", or "<hex> *<name>" in binary
+// mode) and BSD-style lines ("SHA256 (<name>) = <hex>") are understood.
+// algorithm is used for coreutils-style lines; when empty, the algorithm is
+// inferred from the length of the digest. Names match on their base name, so
+// entries such as "./dist/myapp" match "myapp".
+func FindInSums(r io.Reader, fileName string, algorithm string) (string, error) {
+	scanner := bufio.NewScanner(io.LimitReader(r, maxSumsFileSize))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		lineAlgorithm, name, digest, ok := parseSumsLine(line)
+		if !ok || path.Base(name) != fileName {
+			continue
+		}
+		if lineAlgorithm == "" {
+			lineAlgorithm = algorithm
+		}
+		if lineAlgorithm != "" {
+			digest = lineAlgorithm + ":" + digest
+		}
+		sum, err := Normalize(digest)
+		if err != nil {
+			return "", fmt.Errorf("checksum of %s: %w", fileName, err)
+		}
+		return sum, nil
+	}
+	return "", scanner.Err()
+}
+
+// parseSumsLine splits a checksum file line into its algorithm, file name and
+// hex digest; the algorithm is empty for coreutils-style lines
+func parseSumsLine(line string) (string, string, string, bool) {
+	if match := bsdLinePattern.FindStringSubmatch(line); match != nil {
+		algorithm := strings.ToLower(match[1])
+		// b2sum --tag writes BLAKE2b-256 and similar for shorter digests
+		if strings.HasPrefix(algorithm, BLAKE2b) {
+			algorithm = BLAKE2b
+		}
+		return algorithm, path.Clean(strings.ReplaceAll(match[2], "\\", "/")), match[3], true
+	}
+
+	digest, name, ok := strings.Cut(line, " ")
+	if !ok {
+		return "", "", "", false
+	}
+	name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*")
+	if name == "" {
+		return "", "", "", false
+	}
+	return "", path.Clean(strings.ReplaceAll(name, "\\", "/")), digest, true
+}
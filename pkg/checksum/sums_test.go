@@ -0,0 +1,136 @@
+package checksum
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindInSums(t *testing.T) {
+	sha256Sum := "0bb4f3131cf52feab05638958f23f10539388ba67cd7977f5ffc46add6a3fff5"
+	md5Sum := "d28cd39b02ce37082426395b9385f56e"
+
+	tests := []struct {
+		name      string
+		contents  string
+		fileName  string
+		algorithm string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:     "coreutils text mode",
+			contents: md5Sum + "  other.tar.gz\n" + sha256Sum + "  myapp.tar.gz\n",
+			fileName: "myapp.tar.gz",
+			want:     "sha256:" + sha256Sum,
+		},
+		{
+			name:     "coreutils binary mode",
+			contents: strings.ToUpper(sha256Sum) + " *myapp.tar.gz\n",
+			fileName: "myapp.tar.gz",
+			want:     "sha256:" + sha256Sum,
+		},
+		{
+			name:     "coreutils with path",
+			contents: sha256Sum + "  ./dist/myapp.tar.gz\n",
+			fileName: "myapp.tar.gz",
+			want:     "sha256:" + sha256Sum,
+		},
+		{
+			name:      "algorithm from file name",
+			contents:  sha256Sum + "  myapp.tar.gz\n",
+			fileName:  "myapp.tar.gz",
+			algorithm: BLAKE2b,
+			want:      "blake2b:" + sha256Sum,
+		},
+		{
+			name:     "bsd style",
+			contents: "# comment\nMD5 (other) = " + md5Sum + "\nSHA256 (myapp.tar.gz) = " + sha256Sum + "\n",
+			fileName: "myapp.tar.gz",
+			want:     "sha256:" + sha256Sum,
+		},
+		{
+			name:     "bsd style blake2b length suffix",
+			contents: "BLAKE2b-256 (myapp.tar.gz) = " + sha256Sum + "\n",
+			fileName: "myapp.tar.gz",
+			want:     "blake2b:" + sha256Sum,
+		},
+		{
+			name:     "file not listed",
+			contents: sha256Sum + "  other.tar.gz\n",
+			fileName: "myapp.tar.gz",
+			want:     "",
+		},
+		{
+			name:     "prefix of another name",
+			contents: sha256Sum + "  myapp.tar.gz.sig\n",
+			fileName: "myapp.tar.gz",
+			want:     "",
+		},
+		{
+			name:     "unsupported bsd algorithm",
+			contents: "SHA384 (myapp.tar.gz) = " + sha256Sum + md5Sum + "\n",
+			fileName: "myapp.tar.gz",
+			wantErr:  true,
+		},
+		{
+			name:     "ambiguous digest length",
+			contents: "abc123  myapp.tar.gz\n",
+			fileName: "myapp.tar.gz",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindInSums(strings.NewReader(tt.contents), tt.fileName, tt.algorithm)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("FindInSums() expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindInSums() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FindInSums() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSumsFile(t *testing.T) {
+	tests := map[string]bool{
+		"checksums.txt":              true,
+		"myapp_1.2.0_checksums.txt":  true,
+		"SHA256SUMS":                 true,
+		"sha512sums.txt":             true,
+		"B2SUMS":                     true,
+		"myapp-linux-amd64.tar.gz":   false,
+		"checksums-tool-linux-amd64": false,
+		"albums":                     false,
+	}
+
+	for name, want := range tests {
+		if got := IsSumsFile(name); got != want {
+			t.Errorf("IsSumsFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestAlgorithmFromName(t *testing.T) {
+	tests := map[string]string{
+		"SHA256SUMS":                "sha256",
+		"sha512sums.txt":            "sha512",
+		"SHA1SUMS":                  "sha1",
+		"MD5SUMS":                   "md5",
+		"B2SUMS":                    "blake2b",
+		"myapp_1.2.0_checksums.txt": "",
+	}
+
+	for name, want := range tests {
+		if got := AlgorithmFromName(name); got != want {
+			t.Errorf("AlgorithmFromName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jaredhaight/guppy/internal/util"
+)
+
+// Defaults used when a check does not set them
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultRetryDelay = 5 * time.Second
+)
+
+// Check verifies that an application is working after an update
+// Command and URL are both optional; when both are set, both must pass
+type Check struct {
+	Command          string        // Shell command to run
+	ExpectedExitCode int           // Exit code the command must return
+	URL              string        // URL that must return a 2xx status
+	Timeout          time.Duration // Time limit for each attempt
+	Retries          int           // Additional attempts after the first failure
+	RetryDelay       time.Duration // Wait between attempts
+}
+
+// Run runs the check until it passes or every attempt has failed
+func (c *Check) Run() error {
+	attempts := c.Retries + 1
+	retryDelay := c.RetryDelay
+	if retryDelay == 0 {
+		retryDelay = DefaultRetryDelay
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = c.runOnce(); err == nil {
+			return nil
+		}
+		if attempt < attempts {
+			time.Sleep(retryDelay)
+		}
+	}
+
+	return fmt.Errorf("failed after %d attempt(s): %w", attempts, err)
+}
+
+// runOnce runs a single attempt of the check
+func (c *Check) runOnce() error {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if c.Command != "" {
+		if err := c.runCommand(ctx); err != nil {
+			return err
+		}
+	}
+
+	if c.URL != "" {
+		if err := c.checkURL(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runCommand runs the command and compares its exit code
+func (c *Check) runCommand(ctx context.Context) error {
+	cmd := util.ShellCommand(ctx, c.Command)
+	// Don't wait on background processes that keep the output open
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("command %q timed out", c.Command)
+	}
+
+	exitCode := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("error running command %q: %w", c.Command, err)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	if exitCode != c.ExpectedExitCode {
+		msg := strings.TrimSpace(string(output))
+		if msg != "" {
+			return fmt.Errorf("command %q exited with code %d, want %d: %s", c.Command, exitCode, c.ExpectedExitCode, msg)
+		}
+		return fmt.Errorf("command %q exited with code %d, want %d", c.Command, exitCode, c.ExpectedExitCode)
+	}
+	return nil
+}
+
+// checkURL requests the URL and requires a 2xx response
+func (c *Check) checkURL(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.URL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "guppy-updater")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error requesting %s: %w", c.URL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned status %d", c.URL, resp.StatusCode)
+	}
+	return nil
+}
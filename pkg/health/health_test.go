@@ -0,0 +1,136 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheck_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping shell command test on Windows")
+	}
+
+	tests := []struct {
+		name             string
+		command          string
+		expectedExitCode int
+		wantErr          bool
+	}{
+		{
+			name:    "success",
+			command: "true",
+			wantErr: false,
+		},
+		{
+			name:    "unexpected exit code",
+			command: "exit 3",
+			wantErr: true,
+		},
+		{
+			name:             "expected non-zero exit code",
+			command:          "exit 3",
+			expectedExitCode: 3,
+			wantErr:          false,
+		},
+		{
+			name:             "expected failure that succeeds",
+			command:          "true",
+			expectedExitCode: 1,
+			wantErr:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := &Check{
+				Command:          tt.command,
+				ExpectedExitCode: tt.expectedExitCode,
+			}
+			err := check.Run()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheck_URL(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{"ok", http.StatusOK, false},
+		{"no content", http.StatusNoContent, false},
+		{"not found", http.StatusNotFound, true},
+		{"server error", http.StatusInternalServerError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			check := &Check{URL: server.URL}
+			err := check.Run()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheck_Retries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail until the third request
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	check := &Check{URL: server.URL, Retries: 1, RetryDelay: time.Millisecond}
+	if err := check.Run(); err == nil {
+		t.Fatal("Run() expected error after 2 attempts, got nil")
+	}
+
+	requests.Store(0)
+	check.Retries = 2
+	if err := check.Run(); err != nil {
+		t.Errorf("Run() failed with 3 attempts: %v", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("Run() made %d requests, want 3", got)
+	}
+}
+
+func TestCheck_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	check := &Check{URL: server.URL, Timeout: 50 * time.Millisecond}
+
+	start := time.Now()
+	if err := check.Run(); err == nil {
+		t.Fatal("Run() expected timeout error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Run() took %v, want it to stop at the timeout", elapsed)
+	}
+}
@@ -0,0 +1,69 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jaredhaight/guppy/internal/util"
+)
+
+// DefaultTimeout is the time limit for each hook command when none is set
+const DefaultTimeout = 5 * time.Minute
+
+// Runner runs hook commands with guppy's update details in their environment
+type Runner struct {
+	Env     []string      // Extra environment variables, in KEY=value form
+	Timeout time.Duration // Time limit for each command
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+// NewRunner creates a new hook runner
+func NewRunner(env []string, timeout time.Duration) *Runner {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	return &Runner{
+		Env:     env,
+		Timeout: timeout,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	}
+}
+
+// Run runs the commands for a hook in order, stopping at the first failure
+// The hook name is passed to each command as GUPPY_HOOK
+func (r *Runner) Run(name string, commands []string) error {
+	for _, command := range commands {
+		if err := r.runCommand(name, command); err != nil {
+			return fmt.Errorf("%s hook failed: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// runCommand runs a single hook command
+func (r *Runner) runCommand(name, command string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.Timeout)
+	defer cancel()
+
+	cmd := util.ShellCommand(ctx, command)
+	cmd.Env = append(os.Environ(), r.Env...)
+	cmd.Env = append(cmd.Env, "GUPPY_HOOK="+name)
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
+	// Don't wait on background processes that keep the output open
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("command %q timed out after %s", command, r.Timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("command %q: %w", command, err)
+	}
+	return nil
+}
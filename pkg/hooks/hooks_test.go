@@ -0,0 +1,83 @@
+package hooks
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunner_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping shell command test on Windows")
+	}
+
+	var stdout bytes.Buffer
+	runner := NewRunner([]string{"GUPPY_NEW_VERSION=v2.0.0"}, time.Minute)
+	runner.Stdout = &stdout
+
+	commands := []string{
+		`echo "$GUPPY_HOOK $GUPPY_NEW_VERSION"`,
+		`echo second`,
+	}
+	if err := runner.Run("pre_apply", commands); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	want := "pre_apply v2.0.0\nsecond\n"
+	if stdout.String() != want {
+		t.Errorf("Run() output = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunner_Run_StopsAtFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping shell command test on Windows")
+	}
+
+	var stdout bytes.Buffer
+	runner := NewRunner(nil, time.Minute)
+	runner.Stdout = &stdout
+
+	err := runner.Run("pre_download", []string{"echo first", "exit 4", "echo never"})
+	if err == nil {
+		t.Fatal("Run() expected error for failing command, got nil")
+	}
+	if !strings.Contains(err.Error(), "pre_download hook failed") {
+		t.Errorf("Run() error = %v, want it to name the hook", err)
+	}
+	if stdout.String() != "first\n" {
+		t.Errorf("Run() output = %q, want only the commands before the failure", stdout.String())
+	}
+}
+
+func TestRunner_Run_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping shell command test on Windows")
+	}
+
+	runner := NewRunner(nil, 100*time.Millisecond)
+
+	start := time.Now()
+	err := runner.Run("post_apply", []string{"sleep 10"})
+	if err == nil {
+		t.Fatal("Run() expected timeout error, got nil")
+	}
+	if !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Run() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Run() took %v, want it to stop at the timeout", elapsed)
+	}
+}
+
+func TestRunner_Run_NoCommands(t *testing.T) {
+	runner := NewRunner(nil, 0)
+	if runner.Timeout != DefaultTimeout {
+		t.Errorf("NewRunner() timeout = %v, want %v", runner.Timeout, DefaultTimeout)
+	}
+	if err := runner.Run("on_failure", nil); err != nil {
+		t.Errorf("Run() with no commands failed: %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jaredhaight/guppy/pkg/checksum"
+)
+
+// Manifest records the files a release installed, so that a later update can
+// remove files the new release no longer ships and an uninstall can remove
+// everything guppy put in place without touching anything else
+type Manifest struct {
+	Version string   `json:"version"`
+	Root    string   `json:"root"`           // Directory the paths are relative to
+	Files   []string `json:"files"`          // Slash-separated file paths relative to Root
+	Dirs    []string `json:"dirs,omitempty"` // Directories owned entirely by guppy, removed recursively
+	// Preserve maps files in Files that the user may edit, such as
+	// configuration, to the SHA256 of the content guppy installed
+	Preserve map[string]string `json:"preserve,omitempty"`
+}
+
+// Uninstall removes every file and directory in the manifest, then any
+// directories left empty, up to but not including Root
+// Files in Preserve that no longer hold the content guppy installed are left
+// in place and returned
+func (m *Manifest) Uninstall() ([]string, error) {
+	files, kept, err := m.unmodifiedFiles()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := RemoveFiles(m.Root, files); err != nil {
+		return kept, err
+	}
+
+	for _, dir := range m.Dirs {
+		fullPath, err := resolve(m.Root, dir)
+		if err != nil {
+			return kept, err
+		}
+		if err := os.RemoveAll(fullPath); err != nil {
+			return kept, fmt.Errorf("error removing %s: %w", fullPath, err)
+		}
+		RemoveEmptyParents(m.Root, fullPath)
+	}
+	return kept, nil
+}
+
+// unmodifiedFiles splits Files into those Uninstall removes and the preserved
+// files the user has changed since they were installed
+func (m *Manifest) unmodifiedFiles() ([]string, []string, error) {
+	var files, modified []string
+	for _, file := range m.Files {
+		sum, ok := m.Preserve[file]
+		if !ok {
+			files = append(files, file)
+			continue
+		}
+
+		fullPath, err := resolve(m.Root, file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := os.Lstat(fullPath); os.IsNotExist(err) {
+			continue
+		}
+		actual, err := checksum.CalculateSHA256(fullPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error checking %s: %w", fullPath, err)
+		}
+		if actual == sum {
+			files = append(files, file)
+		} else {
+			modified = append(modified, file)
+		}
+	}
+	return files, modified, nil
+}
+
+// RemoveFiles removes the given files, relative to root, along with any
+// directories left empty by their removal
+// Files that are already gone are skipped. It returns the files it removed.
+func RemoveFiles(root string, files []string) ([]string, error) {
+	var removed []string
+	for _, file := range files {
+		fullPath, err := resolve(root, file)
+		if err != nil {
+			return removed, err
+		}
+
+		if err := os.Remove(fullPath); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return removed, fmt.Errorf("error removing %s: %w", fullPath, err)
+		}
+		removed = append(removed, file)
+		RemoveEmptyParents(root, fullPath)
+	}
+	return removed, nil
+}
+
+// resolve joins a manifest path to root, rejecting paths that escape it
+func resolve(root string, name string) (string, error) {
+	clean := path.Clean(name)
+	if clean == "." || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("illegal manifest path: %s", name)
+	}
+	return filepath.Join(root, filepath.FromSlash(clean)), nil
+}
+
+// RemoveEmptyParents removes the directories above path that are now empty,
+// stopping at root
+func RemoveEmptyParents(root string, fullPath string) {
+	root = filepath.Clean(root)
+	for dir := filepath.Dir(fullPath); dir != root && strings.HasPrefix(dir, root+string(os.PathSeparator)); dir = filepath.Dir(dir) {
+		// Remove fails on directories that still have contents
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
+
+// Store keeps the manifest of each installed version under a state directory
+type Store struct {
+	Dir string
+}
+
+// NewStore creates a new manifest store
+func NewStore(dir string) *Store {
+	return &Store{
+		Dir: dir,
+	}
+}
+
+// Load returns the manifest for a version, or nil if there is none
+func (s *Store) Load(version string) (*Manifest, error) {
+	if version == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(s.path(version))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error decoding manifest for %s: %w", version, err)
+	}
+	return &m, nil
+}
+
+// Save writes the manifest for its version, replacing any existing one
+func (s *Store) Save(m *Manifest) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("error creating manifest directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding manifest: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a partial manifest
+	dest := s.path(m.Version)
+	temp := dest + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	if err := os.Rename(temp, dest); err != nil {
+		_ = os.Remove(temp)
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the manifest for a version
+func (s *Store) Remove(version string) error {
+	if err := os.Remove(s.path(version)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing manifest for %s: %w", version, err)
+	}
+	return nil
+}
+
+// Prune deletes the manifests of every version not in keep
+func (s *Store) Prune(keep []string) error {
+	entries, err := os.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading manifest directory: %w", err)
+	}
+
+	keepFiles := make(map[string]bool)
+	for _, version := range keep {
+		keepFiles[filepath.Base(s.path(version))] = true
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || keepFiles[entry.Name()] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.Dir, entry.Name())); err != nil {
+			return fmt.Errorf("error removing manifest %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// path returns the manifest file for a version
+func (s *Store) path(version string) string {
+	return filepath.Join(s.Dir, url.PathEscape(version)+".json")
+}
@@ -0,0 +1,197 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jaredhaight/guppy/pkg/checksum"
+)
+
+func TestStore_SaveAndLoad(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "manifests"))
+
+	m := &Manifest{
+		Version: "v1.0.0/rc",
+		Root:    "/opt/app",
+		Files:   []string{"bin/app", "lib/plugin.so"},
+	}
+	if err := store.Save(m); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	loaded, err := store.Load("v1.0.0/rc")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if loaded == nil || loaded.Root != "/opt/app" || len(loaded.Files) != 2 {
+		t.Fatalf("Load() = %+v, want the saved manifest", loaded)
+	}
+
+	missing, err := store.Load("v2.0.0")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if missing != nil {
+		t.Errorf("Load() = %+v, want nil for a version without a manifest", missing)
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "manifests"))
+
+	for _, v := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+		if err := store.Save(&Manifest{Version: v, Root: "/opt/app"}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+
+	if err := store.Prune([]string{"1.2.0", "1.0.0"}); err != nil {
+		t.Fatalf("Prune() failed: %v", err)
+	}
+
+	for version, want := range map[string]bool{"1.0.0": true, "1.1.0": false, "1.2.0": true} {
+		m, err := store.Load(version)
+		if err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		if (m != nil) != want {
+			t.Errorf("Load(%s) after Prune() found = %v, want %v", version, m != nil, want)
+		}
+	}
+}
+
+func TestRemoveFiles(t *testing.T) {
+	root := t.TempDir()
+
+	files := map[string]string{
+		"bin/app":             "app",
+		"plugins/old/main.so": "old",
+		"plugins/user.so":     "user",
+	}
+	for name, contents := range files {
+		fullPath := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(contents), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	removed, err := RemoveFiles(root, []string{"plugins/old/main.so", "missing.txt"})
+	if err != nil {
+		t.Fatalf("RemoveFiles() failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "plugins/old/main.so" {
+		t.Errorf("RemoveFiles() = %v, want [plugins/old/main.so]", removed)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "plugins", "old")); !os.IsNotExist(err) {
+		t.Error("RemoveFiles() should remove directories left empty")
+	}
+	if _, err := os.Stat(filepath.Join(root, "plugins", "user.so")); err != nil {
+		t.Error("RemoveFiles() should leave untracked files alone")
+	}
+	if _, err := os.Stat(root); err != nil {
+		t.Error("RemoveFiles() should never remove the root")
+	}
+}
+
+func TestRemoveFiles_Traversal(t *testing.T) {
+	tempDir := t.TempDir()
+	root := filepath.Join(tempDir, "app")
+	outside := filepath.Join(tempDir, "outside.txt")
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(outside, []byte("keep"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	for _, name := range []string{"../outside.txt", "/etc/passwd", "."} {
+		if _, err := RemoveFiles(root, []string{name}); err == nil {
+			t.Errorf("RemoveFiles(%q) expected error, got nil", name)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Error("RemoveFiles() removed a file outside the root")
+	}
+}
+
+func TestManifest_Uninstall(t *testing.T) {
+	root := t.TempDir()
+
+	for _, name := range []string{"app/bin/app", "app/releases/1.0.0/app", "app/data/user.db"} {
+		fullPath := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	m := &Manifest{
+		Version: "1.0.0",
+		Root:    root,
+		Files:   []string{"app/bin/app"},
+		Dirs:    []string{"app/releases"},
+	}
+	if _, err := m.Uninstall(); err != nil {
+		t.Fatalf("Uninstall() failed: %v", err)
+	}
+
+	for _, name := range []string{"app/bin", "app/releases"} {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(name))); !os.IsNotExist(err) {
+			t.Errorf("Uninstall() should remove %s", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "app", "data", "user.db")); err != nil {
+		t.Error("Uninstall() should leave untracked files alone")
+	}
+}
+
+func TestManifest_Uninstall_KeepsModifiedPreserve(t *testing.T) {
+	root := t.TempDir()
+
+	for name, content := range map[string]string{"app": "binary", "app.conf": "default", "extra.conf": "default"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	sum, err := checksum.CalculateSHA256(filepath.Join(root, "app.conf"))
+	if err != nil {
+		t.Fatalf("CalculateSHA256() failed: %v", err)
+	}
+
+	m := &Manifest{
+		Version:  "1.0.0",
+		Root:     root,
+		Files:    []string{"app", "app.conf", "extra.conf"},
+		Preserve: map[string]string{"app.conf": sum, "extra.conf": sum},
+	}
+
+	// Edited after it was installed
+	if err := os.WriteFile(filepath.Join(root, "extra.conf"), []byte("edited"), 0644); err != nil {
+		t.Fatalf("Failed to edit file: %v", err)
+	}
+
+	kept, err := m.Uninstall()
+	if err != nil {
+		t.Fatalf("Uninstall() failed: %v", err)
+	}
+	if len(kept) != 1 || kept[0] != "extra.conf" {
+		t.Errorf("Uninstall() kept = %v, want [extra.conf]", kept)
+	}
+
+	for _, name := range []string{"app", "app.conf"} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Errorf("Uninstall() should remove unmodified %s", name)
+		}
+	}
+	if content, _ := os.ReadFile(filepath.Join(root, "extra.conf")); string(content) != "edited" {
+		t.Errorf("extra.conf = %q, want the edited file kept", string(content))
+	}
+}
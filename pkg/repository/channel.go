@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/jaredhaight/guppy/pkg/version"
+)
+
+// Release channels, from most to least stable
+const (
+	ChannelStable  = "stable"
+	ChannelBeta    = "beta"
+	ChannelNightly = "nightly"
+)
+
+// channelRank orders channels by stability; a host on a channel also receives
+// releases from every more stable channel
+var channelRank = map[string]int{
+	ChannelStable:  0,
+	ChannelBeta:    1,
+	ChannelNightly: 2,
+}
+
+// nightlyMarkers are pre-release identifiers that mark a nightly build
+var nightlyMarkers = []string{"nightly", "dev", "snapshot"}
+
+// inChannel reports whether a release published on releaseChannel should be
+// offered to a host following channel
+// Unknown release channels are never offered
+func inChannel(releaseChannel, channel string) bool {
+	releaseRank, ok := channelRank[releaseChannel]
+	if !ok {
+		return false
+	}
+	return releaseRank <= channelRank[channel]
+}
+
+// tagChannel classifies a version tag by its pre-release identifier, e.g.
+// 1.2.0 is stable, 1.2.0-rc.1 is beta and 1.2.0-nightly.20250401 is nightly
+// Tags that are not semantic versions are treated as stable
+func tagChannel(tag string) string {
+	v, err := version.Parse(tag)
+	if err != nil || v.PreRelease == "" {
+		return ChannelStable
+	}
+
+	preRelease := strings.ToLower(v.PreRelease)
+	for _, marker := range nightlyMarkers {
+		if strings.Contains(preRelease, marker) {
+			return ChannelNightly
+		}
+	}
+	return ChannelBeta
+}
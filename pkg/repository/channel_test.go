@@ -0,0 +1,47 @@
+package repository
+
+import "testing"
+
+func TestTagChannel(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{tag: "v1.2.0", want: ChannelStable},
+		{tag: "1.2.0+build.5", want: ChannelStable},
+		{tag: "v1.2.0-rc.1", want: ChannelBeta},
+		{tag: "v1.2.0-beta.2", want: ChannelBeta},
+		{tag: "v1.2.0-nightly.20250401", want: ChannelNightly},
+		{tag: "1.2.0-dev.42", want: ChannelNightly},
+		{tag: "1.2.0-SNAPSHOT", want: ChannelNightly},
+		{tag: "release-2025", want: ChannelStable},
+	}
+
+	for _, tt := range tests {
+		if got := tagChannel(tt.tag); got != tt.want {
+			t.Errorf("tagChannel(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestInChannel(t *testing.T) {
+	tests := []struct {
+		releaseChannel string
+		channel        string
+		want           bool
+	}{
+		{ChannelStable, ChannelStable, true},
+		{ChannelBeta, ChannelStable, false},
+		{ChannelStable, ChannelBeta, true},
+		{ChannelBeta, ChannelBeta, true},
+		{ChannelNightly, ChannelBeta, false},
+		{ChannelNightly, ChannelNightly, true},
+		{"canary", ChannelNightly, false},
+	}
+
+	for _, tt := range tests {
+		if got := inChannel(tt.releaseChannel, tt.channel); got != tt.want {
+			t.Errorf("inChannel(%q, %q) = %v, want %v", tt.releaseChannel, tt.channel, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package repository
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jaredhaight/guppy/pkg/checksum"
+	"github.com/jaredhaight/guppy/pkg/version"
+)
+
+// FileRepository implements Repository for releases on a local or mounted filesystem
+// Path is either a releases.json file (same format as HTTPRepository) or a directory
+// of versioned artifacts laid out according to KeyPattern, e.g. "{version}/myapp-linux-amd64"
+type FileRepository struct {
+	Path       string
+	KeyPattern string // Required when Path is a directory
+	debug      bool
+}
+
+// NewFileRepository creates a new file repository
+// path may be a plain path or a file:// URL
+func NewFileRepository(path, keyPattern string) *FileRepository {
+	if p, err := localPath(path); err == nil {
+		path = p
+	}
+	return &FileRepository{
+		Path:       path,
+		KeyPattern: strings.TrimPrefix(filepath.ToSlash(keyPattern), "/"),
+	}
+}
+
+// SetDebug enables or disables debug logging
+func (f *FileRepository) SetDebug(enabled bool) {
+	f.debug = enabled
+}
+
+// debugLog prints a debug message if debug mode is enabled
+func (f *FileRepository) debugLog(format string, args ...interface{}) {
+	if f.debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
+	}
+}
+
+// localPath converts a file:// URL or plain path into a filesystem path
+func localPath(location string) (string, error) {
+	if !strings.Contains(location, "://") {
+		return location, nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %s: %w", location, err)
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URL scheme %q (only local paths and file:// URLs are supported)", u.Scheme)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("file URL %s must not have a remote host", location)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+// listReleases returns every release available at Path
+func (f *FileRepository) listReleases() ([]*Release, error) {
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading repository path: %w", err)
+	}
+
+	if info.IsDir() {
+		return f.scanDirectory()
+	}
+	return f.readReleasesFile()
+}
+
+// readReleasesFile parses a releases.json file; relative URLs resolve against its directory
+func (f *FileRepository) readReleasesFile() ([]*Release, error) {
+	f.debugLog("Reading releases from file: %s", f.Path)
+
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading releases file: %w", err)
+	}
+
+	var entries []httpRelease
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error decoding releases JSON: %w", err)
+	}
+
+	baseDir := filepath.Dir(f.Path)
+	releases := make([]*Release, 0, len(entries))
+	for _, entry := range entries {
+		artifact, err := localPath(entry.URL)
+		if err != nil {
+			return nil, fmt.Errorf("release %s: %w", entry.Version, err)
+		}
+		if !filepath.IsAbs(artifact) {
+			artifact = filepath.Join(baseDir, artifact)
+		}
+
+		// A checksums_url is only read when its release is downloaded
+		sum, _ := entry.checksum()
+		var sumsFiles map[string]string
+		if sum == "" && entry.ChecksumsURL != "" {
+			sumsFiles = map[string]string{sumsFileName(entry.ChecksumsURL): entry.ChecksumsURL}
+		}
+
+		releases = append(releases, &Release{
+			Version:       entry.Version,
+			DownloadURL:   artifact,
+			FileName:      filepath.Base(artifact),
+			Checksum:      sum,
+			Signatures:    adjacentSignatures(artifact),
+			ChecksumFiles: sumsFiles,
+		})
+	}
+
+	f.debugLog("Read %d release(s)", len(releases))
+	return releases, nil
+}
+
+// scanDirectory walks Path and returns every file matching KeyPattern
+func (f *FileRepository) scanDirectory() ([]*Release, error) {
+	parts := strings.SplitN(f.KeyPattern, VersionPlaceholder, 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("key pattern %q must contain %s", f.KeyPattern, VersionPlaceholder)
+	}
+	re, err := regexp.Compile("^" + regexp.QuoteMeta(parts[0]) + "([^/]+)" + regexp.QuoteMeta(parts[1]) + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid key pattern: %w", err)
+	}
+
+	f.debugLog("Scanning %s for artifacts matching %s", f.Path, f.KeyPattern)
+
+	var releases []*Release
+	err = filepath.Walk(f.Path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Skip directories and checksum sidecars, which a loose pattern could otherwise match
+		if info.IsDir() || strings.HasSuffix(p, ".sha256") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(f.Path, p)
+		if err != nil {
+			return err
+		}
+		match := re.FindStringSubmatch(filepath.ToSlash(rel))
+		if match == nil {
+			return nil
+		}
+
+		sum, err := readChecksumFile(p + ".sha256")
+		if err != nil {
+			return err
+		}
+
+		releases = append(releases, &Release{
+			Version:     match[1],
+			DownloadURL: p,
+			FileName:    filepath.Base(p),
+			ReleaseDate: info.ModTime(),
+			Checksum:    sum,
+			Signatures:  adjacentSignatures(p),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error scanning repository directory: %w", err)
+	}
+
+	f.debugLog("Found %d release(s)", len(releases))
+	return releases, nil
+}
+
+// checksumFromSumsFile looks up fileName in a SHA256SUMS-style file named by a
+// releases.json checksums_url; relative paths resolve against baseDir
+// A file that does not list fileName is an error
+func (f *FileRepository) checksumFromSumsFile(location, baseDir, fileName string) (string, error) {
+	sumsPath, err := localPath(location)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(sumsPath) {
+		sumsPath = filepath.Join(baseDir, sumsPath)
+	}
+
+	file, err := os.Open(sumsPath)
+	if err != nil {
+		return "", fmt.Errorf("error opening checksum file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	sum, err := checksum.FindInSums(file, fileName, checksum.AlgorithmFromName(sumsPath))
+	if err != nil {
+		return "", fmt.Errorf("error reading checksum file %s: %w", sumsPath, err)
+	}
+	if sum == "" {
+		return "", fmt.Errorf("checksum file %s does not list %s", sumsPath, fileName)
+	}
+	return sum, nil
+}
+
+// readChecksumFile reads the digest from a sha256sum-style sidecar file, in "sha256:hex" form
+// Returns an empty string if the file does not exist
+func readChecksumFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error opening checksum file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return "", fmt.Errorf("checksum file %s is empty", path)
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) == 0 {
+		return "", fmt.Errorf("checksum file %s is empty", path)
+	}
+	return checksum.SHA256 + ":" + strings.ToLower(fields[0]), nil
+}
+
+// GetLatestRelease returns the latest release by comparing all versions
+func (f *FileRepository) GetLatestRelease() (*Release, error) {
+	releases, err := f.listReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *Release
+	for _, rel := range releases {
+		if latest == nil {
+			latest = rel
+			continue
+		}
+
+		isNewer, err := version.IsNewer(rel.Version, latest.Version)
+		if err != nil {
+			f.debugLog("Error comparing versions %s and %s: %v", rel.Version, latest.Version, err)
+			continue
+		}
+		if isNewer {
+			latest = rel
+		}
+	}
+
+	if latest == nil {
+		return nil, fmt.Errorf("no releases found")
+	}
+
+	f.debugLog("Latest release: %s", latest.Version)
+	return latest, nil
+}
+
+// GetRelease returns a specific release by version
+func (f *FileRepository) GetRelease(v string) (*Release, error) {
+	releases, err := f.listReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	return findRelease(v, func(tag string) (*Release, error) {
+		for _, rel := range releases {
+			if rel.Version == tag {
+				return rel, nil
+			}
+		}
+		return nil, errVersionNotFound(tag)
+	})
+}
+
+// ListReleases returns every release at Path, newest first
+func (f *FileRepository) ListReleases() ([]*Release, error) {
+	releases, err := f.listReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	sortReleases(releases)
+	return releases, nil
+}
+
+// CompareVersions compares current version with latest
+func (f *FileRepository) CompareVersions(current, latest string) (bool, error) {
+	return version.IsNewer(latest, current)
+}
+
+// Download copies a release to the specified destination and verifies that the
+// copy matches the source, so a flaky mount cannot leave a truncated file behind
+func (f *FileRepository) Download(release *Release, dest string) error {
+	if release.DownloadURL == "" {
+		return fmt.Errorf("no download URL in release")
+	}
+
+	// The checksum file is only read for the release being installed;
+	// releases.json names at most one per release
+	if release.Checksum == "" {
+		for _, location := range release.ChecksumFiles {
+			sum, err := f.checksumFromSumsFile(location, filepath.Dir(f.Path), release.FileName)
+			if err != nil {
+				return fmt.Errorf("release %s: %w", release.Version, err)
+			}
+			release.Checksum = sum
+		}
+	}
+
+	source, err := localPath(release.DownloadURL)
+	if err != nil {
+		return err
+	}
+
+	f.debugLog("Copying from %s to %s", source, dest)
+
+	in, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("error opening release file: %w", err)
+	}
+	defer func() { _ = in.Close() }()
+
+	// Create destination directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("error creating destination directory: %w", err)
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("error creating destination file: %w", err)
+	}
+
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(dest)
+		return fmt.Errorf("error copying release file: %w", err)
+	}
+
+	// Compare the copy with the source
+	sourceSum, err := checksum.CalculateSHA256(source)
+	if err != nil {
+		_ = os.Remove(dest)
+		return err
+	}
+	valid, err := checksum.VerifySHA256(dest, sourceSum)
+	if err != nil {
+		_ = os.Remove(dest)
+		return err
+	}
+	if !valid {
+		_ = os.Remove(dest)
+		return fmt.Errorf("copy of %s does not match the source", source)
+	}
+
+	f.debugLog("Copy verified (sha256 %s)", sourceSum)
+	return nil
+}
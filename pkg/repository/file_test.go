@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFile writes content to path, creating parent directories
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+		wantErr  bool
+	}{
+		{location: "/mnt/releases/releases.json", want: "/mnt/releases/releases.json"},
+		{location: "relative/app", want: "relative/app"},
+		{location: "file:///mnt/releases/app", want: filepath.FromSlash("/mnt/releases/app")},
+		{location: "file://localhost/mnt/app", want: filepath.FromSlash("/mnt/app")},
+		{location: "file://fileserver/share/app", wantErr: true},
+		{location: "https://example.com/app", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := localPath(tt.location)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("localPath(%q) expected error, got nil", tt.location)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("localPath(%q) unexpected error: %v", tt.location, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("localPath(%q) = %q, want %q", tt.location, got, tt.want)
+		}
+	}
+}
+
+func TestFileRepository_ReleasesFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "1.0.0", "app"), "version one")
+	writeTestFile(t, filepath.Join(dir, "2.0.0", "app"), "version two")
+	writeTestFile(t, filepath.Join(dir, "releases.json"), `[
+  {"version": "1.0.0", "url": "1.0.0/app", "md5": "5f432711af7ffa8942d5588e21259022"},
+  {"version": "2.0.0", "url": "file://`+filepath.ToSlash(filepath.Join(dir, "2.0.0", "app"))+`", "sha256": "`+sha256Hex("version two")+`"}
+]`)
+
+	repo := NewFileRepository("file://"+filepath.ToSlash(filepath.Join(dir, "releases.json")), "")
+
+	latest, err := repo.GetLatestRelease()
+	if err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+	if latest.Version != "2.0.0" {
+		t.Errorf("GetLatestRelease() version = %q, want 2.0.0", latest.Version)
+	}
+	if latest.Checksum != "sha256:"+sha256Hex("version two") {
+		t.Errorf("GetLatestRelease() checksum = %q, want sha256 from releases.json", latest.Checksum)
+	}
+
+	older, err := repo.GetRelease("1.0.0")
+	if err != nil {
+		t.Fatalf("GetRelease() unexpected error: %v", err)
+	}
+	if older.DownloadURL != filepath.Join(dir, "1.0.0", "app") {
+		t.Errorf("GetRelease() downloadURL = %q, want path relative to releases.json", older.DownloadURL)
+	}
+	if older.Checksum != "md5:5f432711af7ffa8942d5588e21259022" {
+		t.Errorf("GetRelease() checksum = %q, want md5 when it is the only checksum given", older.Checksum)
+	}
+
+	dest := filepath.Join(t.TempDir(), "downloads", older.FileName)
+	if err := repo.Download(older, dest); err != nil {
+		t.Fatalf("Download() unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("Failed to read copied file: %v", err)
+	}
+	if string(content) != "version one" {
+		t.Errorf("Copied content = %q, want %q", string(content), "version one")
+	}
+
+	if rel, err := repo.GetRelease("v1.0.0"); err != nil || rel.Version != "1.0.0" {
+		t.Errorf("GetRelease(\"v1.0.0\") = %v, %v; want release 1.0.0", rel, err)
+	}
+
+	if _, err := repo.GetRelease("3.0.0"); err == nil {
+		t.Error("GetRelease() expected error for missing version, got nil")
+	}
+}
+
+func TestFileRepository_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "myapp", "1.0.0", "myapp-linux-amd64"), "one")
+	writeTestFile(t, filepath.Join(dir, "myapp", "1.9.0", "myapp-linux-amd64"), "nine")
+	writeTestFile(t, filepath.Join(dir, "myapp", "1.10.0", "myapp-linux-amd64"), "ten")
+	writeTestFile(t, filepath.Join(dir, "myapp", "1.10.0", "myapp-linux-amd64.sha256"), sha256Hex("ten")+"  myapp-linux-amd64\n")
+	writeTestFile(t, filepath.Join(dir, "myapp", "1.10.0", "myapp-darwin"), "other platform")
+	writeTestFile(t, filepath.Join(dir, "README.txt"), "not a release")
+
+	repo := NewFileRepository(dir, "myapp/{version}/myapp-linux-amd64")
+
+	latest, err := repo.GetLatestRelease()
+	if err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+	if latest.Version != "1.10.0" {
+		t.Errorf("GetLatestRelease() version = %q, want 1.10.0", latest.Version)
+	}
+	if latest.Checksum != "sha256:"+sha256Hex("ten") {
+		t.Errorf("GetLatestRelease() checksum = %q, want value from .sha256 sidecar", latest.Checksum)
+	}
+	if latest.ReleaseDate.IsZero() {
+		t.Error("GetLatestRelease() releaseDate should come from the file modification time")
+	}
+
+	release, err := repo.GetRelease("1.9.0")
+	if err != nil {
+		t.Fatalf("GetRelease() unexpected error: %v", err)
+	}
+	if release.Checksum != "" {
+		t.Errorf("GetRelease() checksum = %q, want empty without sidecar", release.Checksum)
+	}
+}
+
+func TestFileRepository_ChecksumsURL(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "1.0.0", "app.tar.gz"), "version one")
+	writeTestFile(t, filepath.Join(dir, "1.0.0", "SHA256SUMS"), sha256Hex("version one")+"  app.tar.gz\n")
+	// An older release whose checksum file is missing must not break the others
+	writeTestFile(t, filepath.Join(dir, "releases.json"), `[
+  {"version": "1.0.0", "url": "1.0.0/app.tar.gz", "checksums_url": "1.0.0/SHA256SUMS"},
+  {"version": "0.9.0", "url": "0.9.0/app.tar.gz", "checksums_url": "0.9.0/SHA256SUMS"}
+]`)
+
+	repo := NewFileRepository(filepath.Join(dir, "releases.json"), "")
+	release, err := repo.GetLatestRelease()
+	if err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+	if release.Checksum != "" {
+		t.Errorf("GetLatestRelease() checksum = %q, want checksums_url left until Download", release.Checksum)
+	}
+
+	dest := filepath.Join(t.TempDir(), "app.tar.gz")
+	if err := repo.Download(release, dest); err != nil {
+		t.Fatalf("Download() unexpected error: %v", err)
+	}
+	if release.Checksum != "sha256:"+sha256Hex("version one") {
+		t.Errorf("Download() checksum = %q, want value from checksums_url", release.Checksum)
+	}
+
+	writeTestFile(t, filepath.Join(dir, "1.0.0", "SHA256SUMS"), sha256Hex("other")+"  other.tar.gz\n")
+	release, err = repo.GetLatestRelease()
+	if err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+	if err := repo.Download(release, dest); err == nil {
+		t.Error("Download() expected error when checksums_url does not list the release, got nil")
+	}
+}
+
+func TestFileRepository_ListReleases(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "1.9.0", "app"), "nine")
+	writeTestFile(t, filepath.Join(dir, "1.10.0", "app"), "ten")
+	writeTestFile(t, filepath.Join(dir, "1.2.0", "app"), "two")
+
+	releases, err := NewFileRepository(dir, "{version}/app").ListReleases()
+	if err != nil {
+		t.Fatalf("ListReleases() unexpected error: %v", err)
+	}
+
+	want := []string{"1.10.0", "1.9.0", "1.2.0"}
+	if len(releases) != len(want) {
+		t.Fatalf("ListReleases() returned %d releases, want %d", len(releases), len(want))
+	}
+	for i, rel := range releases {
+		if rel.Version != want[i] {
+			t.Errorf("ListReleases()[%d] version = %q, want %q", i, rel.Version, want[i])
+		}
+	}
+}
+
+func TestFileRepository_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := NewFileRepository(filepath.Join(dir, "missing.json"), "").GetLatestRelease(); err == nil {
+		t.Error("GetLatestRelease() expected error for missing path, got nil")
+	}
+
+	if _, err := NewFileRepository(dir, "").GetLatestRelease(); err == nil {
+		t.Error("GetLatestRelease() expected error for directory without key pattern, got nil")
+	}
+
+	if _, err := NewFileRepository(dir, "{version}/app").GetLatestRelease(); err == nil {
+		t.Error("GetLatestRelease() expected error for empty directory, got nil")
+	}
+
+	writeTestFile(t, filepath.Join(dir, "remote.json"), `[{"version": "1.0.0", "url": "https://example.com/app"}]`)
+	if _, err := NewFileRepository(filepath.Join(dir, "remote.json"), "").GetLatestRelease(); err == nil {
+		t.Error("GetLatestRelease() expected error for remote URL, got nil")
+	}
+
+	repo := NewFileRepository(dir, "{version}/app")
+	if err := repo.Download(&Release{Version: "1.0.0"}, filepath.Join(dir, "out")); err == nil {
+		t.Error("Download() expected error for missing download URL, got nil")
+	}
+	if err := repo.Download(&Release{Version: "1.0.0", DownloadURL: filepath.Join(dir, "nope")}, filepath.Join(dir, "out")); err == nil {
+		t.Error("Download() expected error for missing source file, got nil")
+	}
+}
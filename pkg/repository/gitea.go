@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jaredhaight/guppy/pkg/checksum"
+	"github.com/jaredhaight/guppy/pkg/signature"
+	"github.com/jaredhaight/guppy/pkg/version"
+)
+
+// GiteaRepository implements Repository for Gitea and Forgejo releases
+type GiteaRepository struct {
+	BaseURL    string // Instance URL (e.g. https://git.example.com)
+	Owner      string
+	Repo       string
+	Token      string // Optional access token for authenticated requests
+	AssetName  string // Optional: specific asset name to download
+	httpClient *http.Client
+	debug      bool
+}
+
+// NewGiteaRepository creates a new Gitea/Forgejo repository
+func NewGiteaRepository(baseURL, owner, repo, token string) *GiteaRepository {
+	return &GiteaRepository{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Owner:      owner,
+		Repo:       repo,
+		Token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetAssetName sets the specific asset name to download
+func (g *GiteaRepository) SetAssetName(name string) {
+	g.AssetName = name
+}
+
+// SetDebug enables or disables debug logging
+func (g *GiteaRepository) SetDebug(enabled bool) {
+	g.debug = enabled
+}
+
+// debugLog prints a debug message if debug mode is enabled
+func (g *GiteaRepository) debugLog(format string, args ...interface{}) {
+	if g.debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
+	}
+}
+
+// giteaRelease represents a Gitea release API response
+type giteaRelease struct {
+	ID          int64        `json:"id"`
+	TagName     string       `json:"tag_name"`
+	Name        string       `json:"name"`
+	Draft       bool         `json:"draft"`
+	Prerelease  bool         `json:"prerelease"`
+	PublishedAt time.Time    `json:"published_at"`
+	Assets      []giteaAsset `json:"assets"`
+}
+
+// giteaAsset represents a release attachment
+type giteaAsset struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	UUID               string `json:"uuid"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// releasesURL returns the API URL for the repository's releases
+func (g *GiteaRepository) releasesURL() string {
+	return fmt.Sprintf("%s/api/v1/repos/%s/%s/releases", g.BaseURL, url.PathEscape(g.Owner), url.PathEscape(g.Repo))
+}
+
+// setAuthHeader sets the Authorization header if a token is configured
+func (g *GiteaRepository) setAuthHeader(req *http.Request) {
+	if g.Token != "" {
+		authValue := fmt.Sprintf("token %s", g.Token)
+		req.Header.Set("Authorization", authValue)
+		g.debugLog("Request header set: Authorization: token <redacted>")
+	}
+}
+
+// fetchRelease fetches and decodes a single release from the given API URL
+func (g *GiteaRepository) fetchRelease(apiURL string) (*Release, error) {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "guppy-updater")
+	req.Header.Set("Accept", "application/json")
+	g.setAuthHeader(req)
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching release: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		err := fmt.Errorf("Gitea API returned status %d: %s", resp.StatusCode, string(body))
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, &releaseNotFoundError{Err: err}
+		}
+		return nil, err
+	}
+
+	var gtRelease giteaRelease
+	if err := json.NewDecoder(resp.Body).Decode(&gtRelease); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return g.convertGiteaRelease(&gtRelease)
+}
+
+// GetLatestRelease returns the latest release from Gitea
+func (g *GiteaRepository) GetLatestRelease() (*Release, error) {
+	apiURL := g.releasesURL() + "/latest"
+	g.debugLog("Fetching latest release from URL: %s", apiURL)
+	return g.fetchRelease(apiURL)
+}
+
+// GetRelease returns a specific release by version
+func (g *GiteaRepository) GetRelease(version string) (*Release, error) {
+	return findRelease(version, func(tag string) (*Release, error) {
+		apiURL := fmt.Sprintf("%s/tags/%s", g.releasesURL(), url.PathEscape(tag))
+		g.debugLog("Fetching release for version %s from URL: %s", tag, apiURL)
+		return g.fetchRelease(apiURL)
+	})
+}
+
+// ListReleases returns every published release, newest first, following Link header pagination
+// Releases without a matching attachment are skipped
+func (g *GiteaRepository) ListReleases() ([]*Release, error) {
+	var releases []*Release
+	nextURL := g.releasesURL() + "?limit=50"
+
+	for nextURL != "" {
+		g.debugLog("Fetching releases from URL: %s", nextURL)
+
+		req, err := http.NewRequest("GET", nextURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %w", err)
+		}
+
+		req.Header.Set("User-Agent", "guppy-updater")
+		req.Header.Set("Accept", "application/json")
+		g.setAuthHeader(req)
+
+		resp, err := g.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching releases: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("Gitea API returned status %d: %s", resp.StatusCode, string(body))
+		}
+
+		var page []giteaRelease
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding response: %w", err)
+		}
+
+		for i := range page {
+			if page[i].Draft {
+				continue
+			}
+			release, err := g.convertGiteaRelease(&page[i])
+			if err != nil {
+				g.debugLog("Skipping release %s: %v", page[i].TagName, err)
+				continue
+			}
+			releases = append(releases, release)
+		}
+
+		nextURL = parseNextLink(resp.Header, req.URL)
+	}
+
+	sortReleases(releases)
+	return releases, nil
+}
+
+// CompareVersions compares current version with latest
+func (g *GiteaRepository) CompareVersions(current, latest string) (bool, error) {
+	return version.IsNewer(latest, current)
+}
+
+// Download downloads a release attachment to the specified destination
+func (g *GiteaRepository) Download(release *Release, dest string) error {
+	if release.DownloadURL == "" {
+		return fmt.Errorf("no download URL in release")
+	}
+
+	g.debugLog("Downloading from URL: %s to %s", release.DownloadURL, dest)
+
+	req, err := http.NewRequest("GET", release.DownloadURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating download request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "guppy-updater")
+	req.Header.Set("Accept", "application/octet-stream")
+
+	// Only send credentials to the instance itself, not to external asset hosts
+	if strings.HasPrefix(release.DownloadURL, g.BaseURL+"/") {
+		g.setAuthHeader(req)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error downloading file: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+
+	// Create destination directory if it doesn't exist
+	destDir := filepath.Dir(dest)
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("error creating destination directory: %w", err)
+	}
+
+	// Create the destination file
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("error creating destination file: %w", err)
+	}
+	defer func() { _ = out.Close() }()
+
+	// Copy the content
+	_, err = io.Copy(out, resp.Body)
+	if err != nil {
+		return fmt.Errorf("error writing to destination: %w", err)
+	}
+
+	return nil
+}
+
+// convertGiteaRelease converts a Gitea API release to our Release type
+func (g *GiteaRepository) convertGiteaRelease(gtRelease *giteaRelease) (*Release, error) {
+	if len(gtRelease.Assets) == 0 {
+		return nil, fmt.Errorf("release has no assets")
+	}
+
+	g.debugLog("Release has %d asset(s)", len(gtRelease.Assets))
+
+	// Find the asset to download
+	asset := &gtRelease.Assets[0]
+	if g.AssetName != "" {
+		g.debugLog("Looking for specific asset: %s", g.AssetName)
+		asset = nil
+		for i := range gtRelease.Assets {
+			if gtRelease.Assets[i].Name == g.AssetName {
+				asset = &gtRelease.Assets[i]
+				break
+			}
+		}
+		if asset == nil {
+			return nil, fmt.Errorf("asset %s not found in release", g.AssetName)
+		}
+	} else {
+		// Use the first asset that is not a checksum or signature file
+		for i := range gtRelease.Assets {
+			name := gtRelease.Assets[i].Name
+			if !checksum.IsSumsFile(name) && !signature.IsSignatureFile(name) {
+				asset = &gtRelease.Assets[i]
+				break
+			}
+		}
+	}
+
+	g.debugLog("Using asset: %s (ID: %d)", asset.Name, asset.ID)
+
+	// Gitea does not publish digests for attachments
+	g.debugLog("WARNING: No checksum available for asset %s", asset.Name)
+
+	assets := make(map[string]string, len(gtRelease.Assets))
+	for _, a := range gtRelease.Assets {
+		assets[a.Name] = a.BrowserDownloadURL
+	}
+
+	return &Release{
+		Version:     gtRelease.TagName,
+		DownloadURL: asset.BrowserDownloadURL,
+		ReleaseDate: gtRelease.PublishedAt,
+		FileName:    asset.Name,
+		AssetID:     asset.ID,
+		Signatures:  assetSignatures(asset.Name, assets),
+	}, nil
+}
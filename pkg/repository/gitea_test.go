@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newGiteaTestServer returns a server that mimics the Gitea releases API for owner/repo
+// with a single release, v1.2.0, that has two attachments
+func newGiteaTestServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "guppy-updater" {
+			t.Errorf("Expected User-Agent header to be 'guppy-updater', got %q", r.Header.Get("User-Agent"))
+		}
+		if token != "" && r.Header.Get("Authorization") != "token "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		release := giteaRelease{
+			ID:          1,
+			TagName:     "v1.2.0",
+			Name:        "v1.2.0",
+			PublishedAt: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+			Assets: []giteaAsset{
+				{ID: 10, Name: "app-linux", UUID: "aaaa", BrowserDownloadURL: server.URL + "/owner/repo/releases/download/v1.2.0/app-linux"},
+				{ID: 11, Name: "app-darwin", UUID: "bbbb", BrowserDownloadURL: server.URL + "/owner/repo/releases/download/v1.2.0/app-darwin"},
+			},
+		}
+
+		switch r.URL.Path {
+		case "/api/v1/repos/owner/repo/releases/latest", "/api/v1/repos/owner/repo/releases/tags/v1.2.0":
+			_ = json.NewEncoder(w).Encode(release)
+		case "/owner/repo/releases/download/v1.2.0/app-linux", "/owner/repo/releases/download/v1.2.0/app-darwin":
+			_, _ = fmt.Fprintf(w, "content of %s", filepath.Base(r.URL.Path))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"not found"}`))
+		}
+	}))
+	return server
+}
+
+func TestGiteaRepository_GetLatestRelease(t *testing.T) {
+	tests := []struct {
+		name         string
+		token        string
+		repoToken    string
+		assetName    string
+		wantErr      bool
+		wantFileName string
+		wantAssetID  int64
+	}{
+		{
+			name:         "first asset by default",
+			wantFileName: "app-linux",
+			wantAssetID:  10,
+		},
+		{
+			name:         "asset_name selects attachment",
+			assetName:    "app-darwin",
+			wantFileName: "app-darwin",
+			wantAssetID:  11,
+		},
+		{
+			name:      "asset_name not found",
+			assetName: "app-windows.exe",
+			wantErr:   true,
+		},
+		{
+			name:         "token auth",
+			token:        "forgejo-token",
+			repoToken:    "forgejo-token",
+			wantFileName: "app-linux",
+			wantAssetID:  10,
+		},
+		{
+			name:    "missing token for private repo",
+			token:   "forgejo-token",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newGiteaTestServer(t, tt.token)
+			defer server.Close()
+
+			repo := NewGiteaRepository(server.URL+"/", "owner", "repo", tt.repoToken)
+			if tt.assetName != "" {
+				repo.SetAssetName(tt.assetName)
+			}
+
+			release, err := repo.GetLatestRelease()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("GetLatestRelease() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+			}
+
+			if release.Version != "v1.2.0" {
+				t.Errorf("GetLatestRelease() version = %q, want v1.2.0", release.Version)
+			}
+			if release.FileName != tt.wantFileName {
+				t.Errorf("GetLatestRelease() fileName = %q, want %q", release.FileName, tt.wantFileName)
+			}
+			if release.AssetID != tt.wantAssetID {
+				t.Errorf("GetLatestRelease() assetID = %d, want %d", release.AssetID, tt.wantAssetID)
+			}
+		})
+	}
+}
+
+func TestConvertGiteaRelease_SkipsSumsAndSignatures(t *testing.T) {
+	release := &giteaRelease{
+		TagName: "v1.2.0",
+		Assets: []giteaAsset{
+			{ID: 10, Name: "checksums.txt", BrowserDownloadURL: "https://example.com/checksums.txt"},
+			{ID: 11, Name: "app-linux.minisig", BrowserDownloadURL: "https://example.com/app-linux.minisig"},
+			{ID: 12, Name: "app-linux", BrowserDownloadURL: "https://example.com/app-linux"},
+		},
+	}
+
+	got, err := NewGiteaRepository("https://gitea.example.com", "owner", "repo", "").convertGiteaRelease(release)
+	if err != nil {
+		t.Fatalf("convertGiteaRelease() unexpected error: %v", err)
+	}
+	if got.FileName != "app-linux" || got.AssetID != 12 {
+		t.Errorf("convertGiteaRelease() asset = %s (ID %d), want app-linux (ID 12)", got.FileName, got.AssetID)
+	}
+}
+
+func TestGiteaRepository_GetRelease(t *testing.T) {
+	server := newGiteaTestServer(t, "")
+	defer server.Close()
+
+	repo := NewGiteaRepository(server.URL, "owner", "repo", "")
+
+	for _, v := range []string{"v1.2.0", "1.2.0"} {
+		release, err := repo.GetRelease(v)
+		if err != nil {
+			t.Fatalf("GetRelease(%q) unexpected error: %v", v, err)
+		}
+		if release.Version != "v1.2.0" {
+			t.Errorf("GetRelease(%q) version = %q, want v1.2.0", v, release.Version)
+		}
+	}
+
+	if _, err := repo.GetRelease("v9.9.9"); err == nil {
+		t.Error("GetRelease() expected error for missing tag, got nil")
+	}
+}
+
+func TestGiteaRepository_Download(t *testing.T) {
+	const token = "forgejo-token"
+	server := newGiteaTestServer(t, token)
+	defer server.Close()
+
+	repo := NewGiteaRepository(server.URL, "owner", "repo", token)
+	repo.SetAssetName("app-darwin")
+
+	release, err := repo.GetLatestRelease()
+	if err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "nested", release.FileName)
+	if err := repo.Download(release, dest); err != nil {
+		t.Fatalf("Download() unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+	if string(content) != "content of app-darwin" {
+		t.Errorf("Downloaded content = %q, want %q", string(content), "content of app-darwin")
+	}
+
+	// Missing attachments surface as an error
+	release.DownloadURL = strings.Replace(release.DownloadURL, "app-darwin", "missing", 1)
+	if err := repo.Download(release, dest); err == nil {
+		t.Error("Download() expected error for missing attachment, got nil")
+	}
+
+	if err := repo.Download(&Release{Version: "v1.2.0"}, dest); err == nil {
+		t.Error("Download() expected error for missing download URL, got nil")
+	}
+}
+
+func TestGiteaRepository_ListReleases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/owner/repo/releases" {
+			t.Errorf("Unexpected request path %q", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]giteaRelease{
+			{TagName: "v1.1.0", Draft: true, Assets: []giteaAsset{{ID: 3, Name: "app", BrowserDownloadURL: "https://example.com/draft"}}},
+			{TagName: "v1.0.0", Assets: []giteaAsset{{ID: 1, Name: "app", BrowserDownloadURL: "https://example.com/1.0.0"}}},
+			{TagName: "v1.0.1", Assets: []giteaAsset{{ID: 2, Name: "app", BrowserDownloadURL: "https://example.com/1.0.1"}}},
+		})
+	}))
+	defer server.Close()
+
+	repo := NewGiteaRepository(server.URL, "owner", "repo", "")
+	releases, err := repo.ListReleases()
+	if err != nil {
+		t.Fatalf("ListReleases() unexpected error: %v", err)
+	}
+
+	if len(releases) != 2 || releases[0].Version != "v1.0.1" || releases[0].AssetID != 2 {
+		t.Errorf("ListReleases() = %v, want v1.0.1 then v1.0.0 without the draft", releases)
+	}
+}
+
+func TestGiteaRepository_DebugLogHidesToken(t *testing.T) {
+	server := newGiteaTestServer(t, "secret-token")
+	defer server.Close()
+
+	repo := NewGiteaRepository(server.URL, "owner", "repo", "secret-token")
+	repo.SetDebug(true)
+
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	_, err := repo.GetLatestRelease()
+	_ = w.Close()
+	os.Stderr = oldStderr
+	output, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+	if !strings.Contains(string(output), "Authorization") {
+		t.Errorf("Debug output = %q, want the Authorization header to be logged", output)
+	}
+	if strings.Contains(string(output), "secret-token") {
+		t.Errorf("Debug output = %q, should not contain the token", output)
+	}
+}
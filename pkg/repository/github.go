@@ -4,21 +4,31 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/jaredhaight/guppy/pkg/checksum"
+	"github.com/jaredhaight/guppy/pkg/signature"
 	"github.com/jaredhaight/guppy/pkg/version"
 )
 
+// DefaultGitHubAPIURL is the API base URL used when none is configured
+const DefaultGitHubAPIURL = "https://api.github.com"
+
 // GitHubRepository implements Repository for GitHub releases
 type GitHubRepository struct {
 	Owner      string
 	Repo       string
 	Token      string // Optional GitHub token for authenticated requests
 	AssetName  string // Optional: specific asset name to download
+	APIURL     string // API base URL (e.g. https://github.example.com/api/v3 for GitHub Enterprise Server)
+	Channel    string // Optional release channel (stable, beta or nightly)
 	httpClient *http.Client
 	debug      bool
 }
@@ -29,6 +39,7 @@ func NewGitHubRepository(owner, repo, token string) *GitHubRepository {
 		Owner:      owner,
 		Repo:       repo,
 		Token:      token,
+		APIURL:     DefaultGitHubAPIURL,
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 	}
 }
@@ -38,6 +49,20 @@ func (g *GitHubRepository) SetAssetName(name string) {
 	g.AssetName = name
 }
 
+// SetAPIURL sets the API base URL, e.g. for GitHub Enterprise Server
+// An empty value keeps the default of https://api.github.com
+func (g *GitHubRepository) SetAPIURL(apiURL string) {
+	if apiURL != "" {
+		g.APIURL = strings.TrimSuffix(apiURL, "/")
+	}
+}
+
+// SetChannel sets the release channel to follow
+// An empty channel uses GitHub's latest release, which never includes prereleases
+func (g *GitHubRepository) SetChannel(channel string) {
+	g.Channel = channel
+}
+
 // SetDebug enables or disables debug logging
 func (g *GitHubRepository) SetDebug(enabled bool) {
 	g.debug = enabled
@@ -55,17 +80,24 @@ type githubRelease struct {
 	TagName     string    `json:"tag_name"`
 	Name        string    `json:"name"`
 	PublishedAt time.Time `json:"published_at"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
 	Assets      []struct {
 		ID                 int64  `json:"id"`
 		Name               string `json:"name"`
 		BrowserDownloadURL string `json:"browser_download_url"`
-		Digest             string `json:"digest"` // SHA256 checksum in format "sha256:hexvalue"
+		Digest             string `json:"digest"` // Checksum in format "algo:hexvalue", e.g. "sha256:..."
 	} `json:"assets"`
 }
 
 // GetLatestRelease returns the latest release from GitHub
+// When a channel is set, the newest release on that channel is returned instead
 func (g *GitHubRepository) GetLatestRelease() (*Release, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", g.Owner, g.Repo)
+	if g.Channel != "" {
+		return g.getLatestChannelRelease()
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/releases/latest", g.APIURL, g.Owner, g.Repo)
 	g.debugLog("Fetching latest release from URL: %s", url)
 
 	req, err := http.NewRequest("GET", url, nil)
@@ -79,7 +111,7 @@ func (g *GitHubRepository) GetLatestRelease() (*Release, error) {
 	if g.Token != "" {
 		authValue := fmt.Sprintf("token %s", g.Token)
 		req.Header.Set("Authorization", authValue)
-		g.debugLog("Request header set: Authorization: %s", authValue)
+		g.debugLog("Request header set: Authorization: token <redacted>")
 	}
 
 	resp, err := g.httpClient.Do(req)
@@ -101,15 +133,156 @@ func (g *GitHubRepository) GetLatestRelease() (*Release, error) {
 	return g.convertGitHubRelease(&ghRelease)
 }
 
+// fetchReleases fetches every release of the repository, following Link header pagination
+func (g *GitHubRepository) fetchReleases() ([]githubRelease, error) {
+	var ghReleases []githubRelease
+	nextURL := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=100", g.APIURL, g.Owner, g.Repo)
+
+	for nextURL != "" {
+		g.debugLog("Fetching releases from URL: %s", nextURL)
+
+		req, err := http.NewRequest("GET", nextURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %w", err)
+		}
+
+		req.Header.Set("User-Agent", "guppy-updater")
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+		if g.Token != "" {
+			authValue := fmt.Sprintf("token %s", g.Token)
+			req.Header.Set("Authorization", authValue)
+			g.debugLog("Request header set: Authorization: token <redacted>")
+		}
+
+		resp, err := g.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching releases: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+		}
+
+		var page []githubRelease
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding response: %w", err)
+		}
+
+		ghReleases = append(ghReleases, page...)
+		nextURL = parseNextLink(resp.Header, req.URL)
+	}
+
+	g.debugLog("Fetched %d release(s)", len(ghReleases))
+	return ghReleases, nil
+}
+
+// channelReleases returns the published releases on the configured channel
+// Drafts are always skipped; every published release is returned if no channel is set
+func (g *GitHubRepository) channelReleases() ([]githubRelease, error) {
+	ghReleases, err := g.fetchReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []githubRelease
+	for _, ghRelease := range ghReleases {
+		if ghRelease.Draft {
+			continue
+		}
+		if g.Channel != "" {
+			channel := githubReleaseChannel(&ghRelease)
+			if !inChannel(channel, g.Channel) {
+				g.debugLog("Skipping %s release %s", channel, ghRelease.TagName)
+				continue
+			}
+		}
+		matching = append(matching, ghRelease)
+	}
+	return matching, nil
+}
+
+// getLatestChannelRelease returns the newest release on the configured channel
+// A release without a matching asset is skipped in favour of the next newest
+func (g *GitHubRepository) getLatestChannelRelease() (*Release, error) {
+	ghReleases, err := g.channelReleases()
+	if err != nil {
+		return nil, err
+	}
+	if len(ghReleases) == 0 {
+		return nil, fmt.Errorf("no releases found on channel %s", g.Channel)
+	}
+
+	sort.SliceStable(ghReleases, func(i, j int) bool {
+		isNewer, err := version.IsNewer(ghReleases[i].TagName, ghReleases[j].TagName)
+		if err != nil {
+			g.debugLog("Error comparing versions %s and %s: %v", ghReleases[i].TagName, ghReleases[j].TagName, err)
+			return false
+		}
+		return isNewer
+	})
+
+	var firstErr error
+	for i := range ghReleases {
+		release, err := g.convertGitHubRelease(&ghReleases[i])
+		if err != nil {
+			g.debugLog("Skipping release %s: %v", ghReleases[i].TagName, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		g.debugLog("Latest %s release: %s", g.Channel, ghReleases[i].TagName)
+		return release, nil
+	}
+	return nil, firstErr
+}
+
+// ListReleases returns every published release on the configured channel, newest first
+// Releases without a matching asset are skipped
+func (g *GitHubRepository) ListReleases() ([]*Release, error) {
+	ghReleases, err := g.channelReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]*Release, 0, len(ghReleases))
+	for i := range ghReleases {
+		release, err := g.convertGitHubRelease(&ghReleases[i])
+		if err != nil {
+			g.debugLog("Skipping release %s: %v", ghReleases[i].TagName, err)
+			continue
+		}
+		releases = append(releases, release)
+	}
+
+	sortReleases(releases)
+	return releases, nil
+}
+
+// githubReleaseChannel returns the channel of a GitHub release
+// The tag decides between beta and nightly; a release marked as a prerelease is never stable
+func githubReleaseChannel(ghRelease *githubRelease) string {
+	channel := tagChannel(ghRelease.TagName)
+	if channel == ChannelStable && ghRelease.Prerelease {
+		return ChannelBeta
+	}
+	return channel
+}
+
 // GetRelease returns a specific release by version
 func (g *GitHubRepository) GetRelease(version string) (*Release, error) {
-	// Ensure version has 'v' prefix for GitHub tags
-	if !strings.HasPrefix(version, "v") {
-		version = "v" + version
-	}
+	return findRelease(version, g.getTagRelease)
+}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/tags/%s", g.Owner, g.Repo, version)
-	g.debugLog("Fetching release for version %s from URL: %s", version, url)
+// getTagRelease returns the release published under the given tag
+func (g *GitHubRepository) getTagRelease(tag string) (*Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", g.APIURL, g.Owner, g.Repo, tag)
+	g.debugLog("Fetching release for version %s from URL: %s", tag, url)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -122,7 +295,7 @@ func (g *GitHubRepository) GetRelease(version string) (*Release, error) {
 	if g.Token != "" {
 		authValue := fmt.Sprintf("token %s", g.Token)
 		req.Header.Set("Authorization", authValue)
-		g.debugLog("Request header set: Authorization: %s", authValue)
+		g.debugLog("Request header set: Authorization: token <redacted>")
 	}
 
 	resp, err := g.httpClient.Do(req)
@@ -133,7 +306,11 @@ func (g *GitHubRepository) GetRelease(version string) (*Release, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+		err := fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, &releaseNotFoundError{Err: err}
+		}
+		return nil, err
 	}
 
 	var ghRelease githubRelease
@@ -150,11 +327,21 @@ func (g *GitHubRepository) CompareVersions(current, latest string) (bool, error)
 }
 
 // Download downloads a release to the specified destination
+// A release without a digest gets its checksum from the release's checksum
+// file first, so the checksum file is only fetched for the release installed
 func (g *GitHubRepository) Download(release *Release, dest string) error {
 	if release.DownloadURL == "" {
 		return fmt.Errorf("no download URL in release")
 	}
 
+	if release.Checksum == "" && len(release.ChecksumFiles) > 0 {
+		sum, err := g.checksumFromSumsFile(release)
+		if err != nil {
+			return err
+		}
+		release.Checksum = sum
+	}
+
 	// Check if we're using the GitHub Asset API
 	isAssetAPI := strings.Contains(release.DownloadURL, "/releases/assets/")
 	if isAssetAPI {
@@ -174,7 +361,7 @@ func (g *GitHubRepository) Download(release *Release, dest string) error {
 	if g.Token != "" {
 		authValue := fmt.Sprintf("token %s", g.Token)
 		req.Header.Set("Authorization", authValue)
-		g.debugLog("Request header set: Authorization: %s", authValue)
+		g.debugLog("Request header set: Authorization: token <redacted>")
 	}
 
 	resp, err := g.httpClient.Do(req)
@@ -218,7 +405,7 @@ func (g *GitHubRepository) convertGitHubRelease(ghRelease *githubRelease) (*Rele
 	g.debugLog("Release has %d asset(s)", len(ghRelease.Assets))
 
 	// Find the asset to download
-	var downloadURL, fileName, checksum string
+	var downloadURL, fileName, digest string
 	var assetID int64
 	if g.AssetName != "" {
 		g.debugLog("Looking for specific asset: %s", g.AssetName)
@@ -228,8 +415,8 @@ func (g *GitHubRepository) convertGitHubRelease(ghRelease *githubRelease) (*Rele
 				downloadURL = asset.BrowserDownloadURL
 				fileName = asset.Name
 				assetID = asset.ID
-				checksum = parseDigest(asset.Digest)
-				g.debugLog("Found matching asset: %s (ID: %d, Checksum: %s)", fileName, assetID, checksum)
+				digest = asset.Digest
+				g.debugLog("Found matching asset: %s (ID: %d, Checksum: %s)", fileName, assetID, digest)
 				break
 			}
 		}
@@ -237,46 +424,146 @@ func (g *GitHubRepository) convertGitHubRelease(ghRelease *githubRelease) (*Rele
 			return nil, fmt.Errorf("asset %s not found in release", g.AssetName)
 		}
 	} else {
-		// Use the first asset
-		downloadURL = ghRelease.Assets[0].BrowserDownloadURL
-		fileName = ghRelease.Assets[0].Name
-		assetID = ghRelease.Assets[0].ID
-		checksum = parseDigest(ghRelease.Assets[0].Digest)
-		g.debugLog("Using first asset: %s (ID: %d, Checksum: %s)", fileName, assetID, checksum)
+		// Use the first asset that is not a checksum or signature file
+		asset := ghRelease.Assets[0]
+		for _, candidate := range ghRelease.Assets {
+			if !checksum.IsSumsFile(candidate.Name) && !signature.IsSignatureFile(candidate.Name) {
+				asset = candidate
+				break
+			}
+		}
+		downloadURL = asset.BrowserDownloadURL
+		fileName = asset.Name
+		assetID = asset.ID
+		digest = asset.Digest
+		g.debugLog("Using first asset: %s (ID: %d, Checksum: %s)", fileName, assetID, digest)
+	}
+
+	sum, err := parseDigest(digest)
+	if err != nil {
+		return nil, fmt.Errorf("asset %s: %w", fileName, err)
 	}
 
 	// If we have a token, use the GitHub Asset API URL instead
 	if g.Token != "" && assetID != 0 {
-		downloadURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/assets/%d", g.Owner, g.Repo, assetID)
+		downloadURL = g.assetAPIURL(assetID)
 		g.debugLog("Using GitHub Asset API URL: %s", downloadURL)
 	}
 
-	if checksum == "" {
+	// Older releases have no digest, so fall back to a published checksum
+	// file, which Download fetches
+	var sumsFiles map[string]string
+	if sum == "" {
+		sumsFiles = g.sumsFileURLs(ghRelease, fileName)
+	}
+
+	if sum == "" && len(sumsFiles) == 0 {
 		g.debugLog("WARNING: No checksum available for asset %s", fileName)
 	}
 
 	return &Release{
-		Version:     ghRelease.TagName,
-		DownloadURL: downloadURL,
-		ReleaseDate: ghRelease.PublishedAt,
-		FileName:    fileName,
-		AssetID:     assetID,
-		Checksum:    checksum,
+		Version:       ghRelease.TagName,
+		DownloadURL:   downloadURL,
+		ReleaseDate:   ghRelease.PublishedAt,
+		FileName:      fileName,
+		AssetID:       assetID,
+		Checksum:      sum,
+		Signatures:    g.signatureURLs(ghRelease, fileName),
+		ChecksumFiles: sumsFiles,
 	}, nil
 }
 
-// parseDigest extracts the hex value from a digest string in format "sha256:hexvalue"
-// Returns empty string if the digest is empty or invalid
-func parseDigest(digest string) string {
-	if digest == "" {
-		return ""
+// sumsFileURLs returns the URLs of the release's checksum files, such as
+// checksums.txt or SHA256SUMS, by file name
+func (g *GitHubRepository) sumsFileURLs(ghRelease *githubRelease, fileName string) map[string]string {
+	var urls map[string]string
+	for _, asset := range ghRelease.Assets {
+		if asset.Name == fileName || !checksum.IsSumsFile(asset.Name) {
+			continue
+		}
+		if urls == nil {
+			urls = make(map[string]string)
+		}
+		urls[asset.Name] = asset.BrowserDownloadURL
+		if g.Token != "" && asset.ID != 0 {
+			urls[asset.Name] = g.assetAPIURL(asset.ID)
+		}
 	}
+	return urls
+}
 
-	// GitHub API returns digest in format "sha256:hexvalue"
-	parts := strings.SplitN(digest, ":", 2)
-	if len(parts) == 2 && parts[0] == "sha256" {
-		return parts[1]
+// signatureURLs returns the URLs of the detached signatures published for
+// fileName, such as myapp.tar.gz.minisig or myapp.tar.gz.asc, by scheme
+func (g *GitHubRepository) signatureURLs(ghRelease *githubRelease, fileName string) map[string][]string {
+	assets := make(map[string]string, len(ghRelease.Assets))
+	for _, asset := range ghRelease.Assets {
+		assets[asset.Name] = asset.BrowserDownloadURL
+		if g.Token != "" && asset.ID != 0 {
+			assets[asset.Name] = g.assetAPIURL(asset.ID)
+		}
 	}
+	return assetSignatures(fileName, assets)
+}
+
+// assetAPIURL returns the GitHub Asset API URL for an asset, which works for private repositories
+func (g *GitHubRepository) assetAPIURL(assetID int64) string {
+	return fmt.Sprintf("%s/repos/%s/%s/releases/assets/%d", g.APIURL, g.Owner, g.Repo, assetID)
+}
+
+// checksumFromSumsFile looks up the release's file in its checksum files and
+// returns its digest in "algo:hex" form
+// It is an error if no checksum file lists it, since the release publishes
+// checksums and an unverified download must not be installed
+func (g *GitHubRepository) checksumFromSumsFile(release *Release) (string, error) {
+	for _, name := range slices.Sorted(maps.Keys(release.ChecksumFiles)) {
+		sumsURL := release.ChecksumFiles[name]
+		g.debugLog("Fetching checksum file %s from URL: %s", name, sumsURL)
+
+		req, err := http.NewRequest("GET", sumsURL, nil)
+		if err != nil {
+			return "", fmt.Errorf("error creating request: %w", err)
+		}
+
+		req.Header.Set("User-Agent", "guppy-updater")
+		req.Header.Set("Accept", "application/octet-stream")
+
+		if g.Token != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("token %s", g.Token))
+		}
 
-	return ""
+		resp, err := g.httpClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("error fetching checksum file %s: %w", name, err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return "", fmt.Errorf("error fetching checksum file %s: status %d", name, resp.StatusCode)
+		}
+
+		sum, err := checksum.FindInSums(resp.Body, release.FileName, checksum.AlgorithmFromName(name))
+		_ = resp.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("error reading checksum file %s: %w", name, err)
+		}
+		if sum != "" {
+			g.debugLog("Found checksum for %s in %s: %s", release.FileName, name, sum)
+			return sum, nil
+		}
+		g.debugLog("Checksum file %s does not list %s", name, release.FileName)
+	}
+
+	names := slices.Sorted(maps.Keys(release.ChecksumFiles))
+	return "", fmt.Errorf("checksum file %s does not list %s", strings.Join(names, ", "), release.FileName)
+}
+
+// parseDigest converts a digest such as "sha256:hexvalue" from the API into
+// the "algo:hex" form used for releases
+// Returns an empty string if there is no digest, and an error if the digest
+// cannot be verified
+func parseDigest(digest string) (string, error) {
+	if digest != "" && !strings.Contains(digest, ":") {
+		return "", fmt.Errorf("digest %s has no algorithm", digest)
+	}
+	return checksum.Normalize(digest)
 }
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 )
@@ -16,11 +17,12 @@ func TestParseDigest(t *testing.T) {
 		name     string
 		digest   string
 		expected string
+		wantErr  bool
 	}{
 		{
 			name:     "valid sha256 digest",
 			digest:   "sha256:bb3dcd74ea4b8b1c354ef53f0c758a0d75ee8233c2fa34165cdc85bbfc812691",
-			expected: "bb3dcd74ea4b8b1c354ef53f0c758a0d75ee8233c2fa34165cdc85bbfc812691",
+			expected: "sha256:bb3dcd74ea4b8b1c354ef53f0c758a0d75ee8233c2fa34165cdc85bbfc812691",
 		},
 		{
 			name:     "empty digest",
@@ -28,25 +30,39 @@ func TestParseDigest(t *testing.T) {
 			expected: "",
 		},
 		{
-			name:     "invalid format - no colon",
-			digest:   "sha256bb3dcd74ea4b8b1c354ef53f0c758a0d75ee8233c2fa34165cdc85bbfc812691",
-			expected: "",
+			name:    "invalid format - no colon",
+			digest:  "sha256bb3dcd74ea4b8b1c354ef53f0c758a0d75ee8233c2fa34165cdc85bbfc812691",
+			wantErr: true,
 		},
 		{
-			name:     "invalid format - wrong algorithm",
-			digest:   "md5:abc123def456",
-			expected: "",
+			name:     "sha512 digest",
+			digest:   "sha512:abc123def456",
+			expected: "sha512:abc123def456",
+		},
+		{
+			name:    "unsupported algorithm",
+			digest:  "sha3-256:abc123def456",
+			wantErr: true,
 		},
 		{
 			name:     "valid digest with uppercase",
 			digest:   "sha256:BB3DCD74EA4B8B1C354EF53F0C758A0D75EE8233C2FA34165CDC85BBFC812691",
-			expected: "BB3DCD74EA4B8B1C354EF53F0C758A0D75EE8233C2FA34165CDC85BBFC812691",
+			expected: "sha256:bb3dcd74ea4b8b1c354ef53f0c758a0d75ee8233c2fa34165cdc85bbfc812691",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := parseDigest(tt.digest)
+			result, err := parseDigest(tt.digest)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseDigest(%q) expected error, got %q", tt.digest, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDigest(%q) unexpected error: %v", tt.digest, err)
+			}
 			if result != tt.expected {
 				t.Errorf("parseDigest(%q) = %q, want %q", tt.digest, result, tt.expected)
 			}
@@ -83,7 +99,7 @@ func TestConvertGitHubRelease(t *testing.T) {
 			},
 			wantErr:      false,
 			wantVersion:  "v1.0.0",
-			wantChecksum: "abc123def456",
+			wantChecksum: "sha256:abc123def456",
 		},
 		{
 			name: "release without checksum",
@@ -147,7 +163,7 @@ func TestConvertGitHubRelease(t *testing.T) {
 			assetName:    "correct-binary",
 			wantErr:      false,
 			wantVersion:  "v1.0.0",
-			wantChecksum: "correcthash",
+			wantChecksum: "sha256:correcthash",
 		},
 	}
 
@@ -218,7 +234,7 @@ func TestGitHubRepository_GetLatestRelease(t *testing.T) {
 			},
 			wantErr:      false,
 			wantVersion:  "v1.2.3",
-			wantChecksum: "abc123def456",
+			wantChecksum: "sha256:abc123def456",
 		},
 		{
 			name:           "successful fetch without checksum",
@@ -327,7 +343,7 @@ func TestGitHubRepository_GetLatestRelease(t *testing.T) {
 			assetName:    "app-darwin",
 			wantErr:      false,
 			wantVersion:  "v1.5.0",
-			wantChecksum: "darwin456",
+			wantChecksum: "sha256:darwin456",
 		},
 		{
 			name:           "authenticated request with token",
@@ -353,7 +369,7 @@ func TestGitHubRepository_GetLatestRelease(t *testing.T) {
 			token:           "ghp_testtoken123",
 			wantErr:         false,
 			wantVersion:     "v3.0.0",
-			wantChecksum:    "private789",
+			wantChecksum:    "sha256:private789",
 			checkAuthHeader: true,
 		},
 	}
@@ -518,9 +534,10 @@ func TestGitHubRepository_GetRelease(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Create test server
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// Verify the correct endpoint was called
-				if tt.expectedPath != "" && r.URL.Path != tt.expectedPath {
-					t.Errorf("Expected path %q, got %q", tt.expectedPath, r.URL.Path)
+				// Only the expected tag exists, so other spellings of the version get a 404
+				if r.URL.Path != tt.expectedPath {
+					w.WriteHeader(http.StatusNotFound)
+					return
 				}
 
 				w.WriteHeader(tt.responseStatus)
@@ -715,6 +732,437 @@ func TestGitHubRepository_Download(t *testing.T) {
 	}
 }
 
+func TestGitHubRepository_SetAPIURL(t *testing.T) {
+	repo := NewGitHubRepository("owner", "repo", "")
+	if repo.APIURL != DefaultGitHubAPIURL {
+		t.Errorf("APIURL = %q, want %q", repo.APIURL, DefaultGitHubAPIURL)
+	}
+
+	repo.SetAPIURL("")
+	if repo.APIURL != DefaultGitHubAPIURL {
+		t.Errorf("SetAPIURL(\"\") changed APIURL to %q", repo.APIURL)
+	}
+
+	repo.SetAPIURL("https://github.example.com/api/v3/")
+	if repo.APIURL != "https://github.example.com/api/v3" {
+		t.Errorf("APIURL = %q, want https://github.example.com/api/v3", repo.APIURL)
+	}
+}
+
+func TestGitHubRepository_EnterpriseAPIURL(t *testing.T) {
+	const token = "ghe_token"
+	const content = "enterprise binary"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release := githubRelease{
+			TagName:     "v1.4.0",
+			PublishedAt: time.Now(),
+			Assets: []struct {
+				ID                 int64  `json:"id"`
+				Name               string `json:"name"`
+				BrowserDownloadURL string `json:"browser_download_url"`
+				Digest             string `json:"digest"`
+			}{
+				{
+					ID:                 42,
+					Name:               "app",
+					BrowserDownloadURL: "https://github.example.com/owner/repo/releases/download/v1.4.0/app",
+				},
+			},
+		}
+
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo/releases/tags/1.4.0":
+			w.WriteHeader(http.StatusNotFound)
+		case "/api/v3/repos/owner/repo/releases/latest", "/api/v3/repos/owner/repo/releases/tags/v1.4.0":
+			_ = json.NewEncoder(w).Encode(release)
+		case "/api/v3/repos/owner/repo/releases/assets/42":
+			if r.Header.Get("Authorization") != "token "+token {
+				t.Errorf("Expected Authorization header on asset download, got %q", r.Header.Get("Authorization"))
+			}
+			_, _ = w.Write([]byte(content))
+		default:
+			t.Errorf("Unexpected request path %q", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	repo := NewGitHubRepository("owner", "repo", token)
+	repo.SetAPIURL(server.URL + "/api/v3")
+
+	if _, err := repo.GetRelease("1.4.0"); err != nil {
+		t.Fatalf("GetRelease() unexpected error: %v", err)
+	}
+
+	release, err := repo.GetLatestRelease()
+	if err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+
+	wantURL := server.URL + "/api/v3/repos/owner/repo/releases/assets/42"
+	if release.DownloadURL != wantURL {
+		t.Errorf("GetLatestRelease() downloadURL = %q, want %q", release.DownloadURL, wantURL)
+	}
+
+	dest := filepath.Join(t.TempDir(), "app")
+	if err := repo.Download(release, dest); err != nil {
+		t.Fatalf("Download() unexpected error: %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+	if string(got) != content {
+		t.Errorf("Downloaded content = %q, want %q", string(got), content)
+	}
+}
+
+func TestGitHubRepository_Channel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/releases" {
+			t.Errorf("Unexpected request path %q", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[
+  {"tag_name": "v2.1.0-nightly.20250402", "prerelease": true, "assets": [{"id": 5, "name": "app", "browser_download_url": "https://example.com/nightly"}]},
+  {"tag_name": "v2.1.0", "draft": true, "assets": [{"id": 4, "name": "app", "browser_download_url": "https://example.com/draft"}]},
+  {"tag_name": "v2.0.0-rc.1", "prerelease": true, "assets": [{"id": 3, "name": "app", "browser_download_url": "https://example.com/rc"}]},
+  {"tag_name": "v1.9.1", "prerelease": true, "assets": [{"id": 2, "name": "app", "browser_download_url": "https://example.com/flagged"}]},
+  {"tag_name": "v1.9.0", "assets": [{"id": 1, "name": "app", "browser_download_url": "https://example.com/stable"}]}
+]`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		channel     string
+		wantVersion string
+	}{
+		{channel: ChannelStable, wantVersion: "v1.9.0"},
+		{channel: ChannelBeta, wantVersion: "v2.0.0-rc.1"},
+		{channel: ChannelNightly, wantVersion: "v2.1.0-nightly.20250402"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			repo := NewGitHubRepository("owner", "repo", "")
+			repo.SetAPIURL(server.URL)
+			repo.SetChannel(tt.channel)
+
+			release, err := repo.GetLatestRelease()
+			if err != nil {
+				t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+			}
+			if release.Version != tt.wantVersion {
+				t.Errorf("GetLatestRelease() version = %q, want %q", release.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestGitHubRepository_ChannelSkipsReleaseWithoutAsset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+  {"tag_name": "v2.0.0", "assets": [{"id": 3, "name": "notes.txt", "browser_download_url": "https://example.com/2.0.0/notes.txt"}]},
+  {"tag_name": "v1.9.0", "assets": [{"id": 2, "name": "app", "browser_download_url": "https://example.com/1.9.0/app"}]},
+  {"tag_name": "v1.8.0", "assets": [{"id": 1, "name": "app", "browser_download_url": "https://example.com/1.8.0/app"}]}
+]`))
+	}))
+	defer server.Close()
+
+	repo := NewGitHubRepository("owner", "repo", "")
+	repo.SetAPIURL(server.URL)
+	repo.SetAssetName("app")
+	repo.SetChannel(ChannelStable)
+
+	release, err := repo.GetLatestRelease()
+	if err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+	if release.Version != "v1.9.0" {
+		t.Errorf("GetLatestRelease() version = %q, want v1.9.0", release.Version)
+	}
+}
+
+func TestGitHubRepository_ListReleases(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/releases" {
+			t.Errorf("Unexpected request path %q", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		// Two pages, linked like the GitHub API does
+		if r.URL.Query().Get("page") == "2" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/releases?per_page=100&page=1>; rel="prev", <%s/repos/owner/repo/releases?per_page=100&page=1>; rel="first"`, server.URL, server.URL))
+			_, _ = w.Write([]byte(`[
+  {"tag_name": "v1.0.0", "published_at": "2025-01-01T00:00:00Z", "assets": [{"id": 1, "name": "app", "browser_download_url": "https://example.com/1.0.0/app", "digest": "sha256:abc"}]},
+  {"tag_name": "v0.9.0", "assets": []}
+]`))
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/releases?per_page=100&page=2>; rel="next", <%s/repos/owner/repo/releases?per_page=100&page=2>; rel="last"`, server.URL, server.URL))
+		_, _ = w.Write([]byte(`[
+  {"tag_name": "v1.2.0-rc.1", "prerelease": true, "assets": [{"id": 3, "name": "app", "browser_download_url": "https://example.com/1.2.0-rc.1/app"}]},
+  {"tag_name": "v1.3.0", "draft": true, "assets": [{"id": 4, "name": "app", "browser_download_url": "https://example.com/draft/app"}]},
+  {"tag_name": "v1.1.0", "assets": [{"id": 2, "name": "app", "browser_download_url": "https://example.com/1.1.0/app"}]}
+]`))
+	}))
+	defer server.Close()
+
+	repo := NewGitHubRepository("owner", "repo", "")
+	repo.SetAPIURL(server.URL)
+
+	releases, err := repo.ListReleases()
+	if err != nil {
+		t.Fatalf("ListReleases() unexpected error: %v", err)
+	}
+
+	// Drafts and releases without assets are skipped
+	want := []string{"v1.2.0-rc.1", "v1.1.0", "v1.0.0"}
+	if len(releases) != len(want) {
+		t.Fatalf("ListReleases() returned %d releases, want %d", len(releases), len(want))
+	}
+	for i, rel := range releases {
+		if rel.Version != want[i] {
+			t.Errorf("ListReleases()[%d] version = %q, want %q", i, rel.Version, want[i])
+		}
+	}
+	if releases[2].Checksum != "sha256:abc" {
+		t.Errorf("ListReleases() checksum = %q, want sha256:abc", releases[2].Checksum)
+	}
+
+	repo.SetChannel(ChannelStable)
+	releases, err = repo.ListReleases()
+	if err != nil {
+		t.Fatalf("ListReleases() unexpected error: %v", err)
+	}
+	if len(releases) != 2 || releases[0].Version != "v1.1.0" {
+		t.Errorf("ListReleases() on stable channel = %d releases, want prerelease filtered out", len(releases))
+	}
+}
+
+func TestGitHubRepository_SumsFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		assets       string
+		sums         string
+		wantAsset    string
+		wantChecksum string
+		wantErr      bool
+	}{
+		{
+			name:         "goreleaser checksums.txt",
+			assets:       `{"id": 1, "name": "myapp_1.0.0_checksums.txt", "browser_download_url": "%[1]s/download/sums"}, {"id": 2, "name": "myapp_linux_amd64.tar.gz", "browser_download_url": "%[1]s/download/app"}`,
+			sums:         "1111111111111111111111111111111111111111111111111111111111111111  myapp_darwin.tar.gz\n2222222222222222222222222222222222222222222222222222222222222222  myapp_linux_amd64.tar.gz\n",
+			wantAsset:    "myapp_linux_amd64.tar.gz",
+			wantChecksum: "sha256:2222222222222222222222222222222222222222222222222222222222222222",
+		},
+		{
+			name:         "bsd style SHA512SUMS",
+			assets:       `{"id": 1, "name": "myapp", "browser_download_url": "%[1]s/download/app"}, {"id": 2, "name": "SHA512SUMS", "browser_download_url": "%[1]s/download/sums"}`,
+			sums:         "SHA512 (myapp) = abcd\n",
+			wantAsset:    "myapp",
+			wantChecksum: "sha512:abcd",
+		},
+		{
+			name:         "asset digest preferred",
+			assets:       `{"id": 1, "name": "myapp", "browser_download_url": "%[1]s/download/app", "digest": "sha256:feed"}, {"id": 2, "name": "SHA256SUMS", "browser_download_url": "%[1]s/download/missing"}`,
+			wantAsset:    "myapp",
+			wantChecksum: "sha256:feed",
+		},
+		{
+			name:      "asset not listed",
+			assets:    `{"id": 1, "name": "myapp", "browser_download_url": "%[1]s/download/app"}, {"id": 2, "name": "SHA256SUMS", "browser_download_url": "%[1]s/download/sums"}`,
+			sums:      "1111111111111111111111111111111111111111111111111111111111111111  other\n",
+			wantAsset: "myapp",
+			wantErr:   true,
+		},
+		{
+			name:      "checksum file unavailable",
+			assets:    `{"id": 1, "name": "myapp", "browser_download_url": "%[1]s/download/app"}, {"id": 2, "name": "SHA256SUMS", "browser_download_url": "%[1]s/download/missing"}`,
+			wantAsset: "myapp",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sumsRequests int
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/repos/owner/repo/releases/latest":
+					_, _ = w.Write([]byte(`{"tag_name": "v1.0.0", "assets": [` + fmt.Sprintf(tt.assets, server.URL) + `]}`))
+				case "/download/sums":
+					sumsRequests++
+					_, _ = w.Write([]byte(tt.sums))
+				case "/download/app":
+					_, _ = w.Write([]byte("app"))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			repo := NewGitHubRepository("owner", "repo", "")
+			repo.SetAPIURL(server.URL)
+
+			release, err := repo.GetLatestRelease()
+			if err != nil {
+				t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+			}
+			if release.FileName != tt.wantAsset {
+				t.Errorf("GetLatestRelease() asset = %q, want %q", release.FileName, tt.wantAsset)
+			}
+			if sumsRequests != 0 {
+				t.Error("GetLatestRelease() should not fetch the checksum file before Download")
+			}
+
+			// The checksum file is read when the release is downloaded
+			err = repo.Download(release, filepath.Join(t.TempDir(), "app"))
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Download() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Download() unexpected error: %v", err)
+			}
+			if release.Checksum != tt.wantChecksum {
+				t.Errorf("Download() checksum = %q, want %q", release.Checksum, tt.wantChecksum)
+			}
+		})
+	}
+}
+
+func TestGitHubRepository_ListReleasesSkipsSumsFiles(t *testing.T) {
+	var sumsRequests int
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/releases":
+			_, _ = fmt.Fprintf(w, `[
+  {"tag_name": "v1.1.0", "assets": [{"id": 1, "name": "myapp", "browser_download_url": "%[1]s/download/app"}, {"id": 2, "name": "SHA256SUMS", "browser_download_url": "%[1]s/download/sums"}]},
+  {"tag_name": "v1.0.0", "assets": [{"id": 3, "name": "myapp", "browser_download_url": "%[1]s/download/app"}, {"id": 4, "name": "SHA256SUMS", "browser_download_url": "%[1]s/download/missing"}]}
+]`, server.URL)
+		case "/download/sums":
+			sumsRequests++
+			_, _ = w.Write([]byte("1111111111111111111111111111111111111111111111111111111111111111  myapp\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	repo := NewGitHubRepository("owner", "repo", "")
+	repo.SetAPIURL(server.URL)
+
+	// Listing neither fetches checksum files nor drops a release whose
+	// checksum file is unavailable
+	releases, err := repo.ListReleases()
+	if err != nil {
+		t.Fatalf("ListReleases() unexpected error: %v", err)
+	}
+	if len(releases) != 2 {
+		t.Fatalf("ListReleases() returned %d releases, want 2", len(releases))
+	}
+	if sumsRequests != 0 {
+		t.Errorf("ListReleases() fetched the checksum file %d time(s), want 0", sumsRequests)
+	}
+	if releases[0].ChecksumFiles["SHA256SUMS"] != server.URL+"/download/sums" {
+		t.Errorf("ListReleases()[0] checksum files = %v, want SHA256SUMS", releases[0].ChecksumFiles)
+	}
+}
+
+func TestGitHubRepository_SignatureAsset(t *testing.T) {
+	tests := []struct {
+		name      string
+		assets    string
+		token     string
+		wantAsset string
+		wantSigs  map[string][]string // Paths are asset API paths on the test server
+	}{
+		{
+			name:      "minisig skipped as first asset",
+			assets:    `{"id": 1, "name": "myapp.tar.gz.minisig", "browser_download_url": "https://example.com/myapp.tar.gz.minisig"}, {"id": 2, "name": "myapp.tar.gz", "browser_download_url": "https://example.com/myapp.tar.gz", "digest": "sha256:feed"}`,
+			wantAsset: "myapp.tar.gz",
+			wantSigs:  map[string][]string{"minisign": {"https://example.com/myapp.tar.gz.minisig"}},
+		},
+		{
+			name:      "asc skipped as first asset",
+			assets:    `{"id": 1, "name": "myapp.tar.gz.asc", "browser_download_url": "https://example.com/myapp.tar.gz.asc"}, {"id": 2, "name": "myapp.tar.gz", "browser_download_url": "https://example.com/myapp.tar.gz", "digest": "sha256:feed"}`,
+			wantAsset: "myapp.tar.gz",
+			wantSigs:  map[string][]string{"openpgp": {"https://example.com/myapp.tar.gz.asc"}},
+		},
+		{
+			name:      "every signature kind",
+			assets:    `{"id": 1, "name": "myapp", "browser_download_url": "https://example.com/myapp", "digest": "sha256:feed"}, {"id": 2, "name": "myapp.sig", "browser_download_url": "https://example.com/myapp.sig"}, {"id": 3, "name": "myapp.minisig", "browser_download_url": "https://example.com/myapp.minisig"}, {"id": 4, "name": "myapp.asc", "browser_download_url": "https://example.com/myapp.asc"}`,
+			wantAsset: "myapp",
+			// A .sig file may hold either kind of signature
+			wantSigs: map[string][]string{
+				"minisign": {"https://example.com/myapp.minisig", "https://example.com/myapp.sig"},
+				"openpgp":  {"https://example.com/myapp.asc", "https://example.com/myapp.sig"},
+			},
+		},
+		{
+			name:      "sigstore bundle skipped as first asset",
+			assets:    `{"id": 1, "name": "myapp.tar.gz.sigstore.json", "browser_download_url": "https://example.com/myapp.tar.gz.sigstore.json"}, {"id": 2, "name": "myapp.tar.gz", "browser_download_url": "https://example.com/myapp.tar.gz", "digest": "sha256:feed"}`,
+			wantAsset: "myapp.tar.gz",
+			wantSigs:  map[string][]string{"sigstore": {"https://example.com/myapp.tar.gz.sigstore.json"}},
+		},
+		{
+			name:      "asset API with token",
+			assets:    `{"id": 1, "name": "myapp", "browser_download_url": "https://example.com/myapp", "digest": "sha256:feed"}, {"id": 2, "name": "myapp.asc", "browser_download_url": "https://example.com/myapp.asc"}`,
+			token:     "secret",
+			wantAsset: "myapp",
+			wantSigs:  map[string][]string{"openpgp": {"/repos/owner/repo/releases/assets/2"}},
+		},
+		{
+			name:      "no signature",
+			assets:    `{"id": 1, "name": "myapp", "browser_download_url": "https://example.com/myapp", "digest": "sha256:feed"}, {"id": 2, "name": "other.sig", "browser_download_url": "https://example.com/other.sig"}`,
+			wantAsset: "myapp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"tag_name": "v1.0.0", "assets": [` + tt.assets + `]}`))
+			}))
+			defer server.Close()
+
+			repo := NewGitHubRepository("owner", "repo", tt.token)
+			repo.SetAPIURL(server.URL)
+
+			release, err := repo.GetLatestRelease()
+			if err != nil {
+				t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+			}
+			if release.FileName != tt.wantAsset {
+				t.Errorf("GetLatestRelease() asset = %q, want %q", release.FileName, tt.wantAsset)
+			}
+			if len(release.Signatures) != len(tt.wantSigs) {
+				t.Errorf("GetLatestRelease() signatures = %v, want %v", release.Signatures, tt.wantSigs)
+			}
+			for scheme, want := range tt.wantSigs {
+				for i := range want {
+					if want[i][0] == '/' {
+						want[i] = server.URL + want[i]
+					}
+				}
+				if !slices.Equal(release.Signatures[scheme], want) {
+					t.Errorf("GetLatestRelease() %s signatures = %q, want %q", scheme, release.Signatures[scheme], want)
+				}
+			}
+		})
+	}
+}
+
 func TestGitHubRepository_CompareVersions(t *testing.T) {
 	tests := []struct {
 		name    string
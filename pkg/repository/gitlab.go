@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jaredhaight/guppy/pkg/checksum"
+	"github.com/jaredhaight/guppy/pkg/signature"
+	"github.com/jaredhaight/guppy/pkg/version"
+)
+
+// DefaultGitLabURL is the base URL used when no GitLab instance is configured
+const DefaultGitLabURL = "https://gitlab.com"
+
+// GitLabRepository implements Repository for GitLab releases
+type GitLabRepository struct {
+	BaseURL    string // GitLab instance URL (e.g. https://gitlab.example.com)
+	Project    string // Project path (e.g. group/subgroup/project) or numeric ID
+	Token      string // Optional token for authenticated requests
+	TokenType  string // "private" (default) or "job"
+	AssetName  string // Optional: specific asset link name to download
+	httpClient *http.Client
+	debug      bool
+}
+
+// NewGitLabRepository creates a new GitLab repository
+// If baseURL is empty, gitlab.com is used
+func NewGitLabRepository(baseURL, project, token string) *GitLabRepository {
+	if baseURL == "" {
+		baseURL = DefaultGitLabURL
+	}
+	return &GitLabRepository{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Project:    project,
+		Token:      token,
+		TokenType:  "private",
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetAssetName sets the specific asset name to download
+func (g *GitLabRepository) SetAssetName(name string) {
+	g.AssetName = name
+}
+
+// SetTokenType sets how the token is sent ("private" or "job")
+func (g *GitLabRepository) SetTokenType(tokenType string) {
+	if tokenType != "" {
+		g.TokenType = tokenType
+	}
+}
+
+// SetDebug enables or disables debug logging
+func (g *GitLabRepository) SetDebug(enabled bool) {
+	g.debug = enabled
+}
+
+// debugLog prints a debug message if debug mode is enabled
+func (g *GitLabRepository) debugLog(format string, args ...interface{}) {
+	if g.debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
+	}
+}
+
+// gitlabRelease represents a GitLab release API response
+type gitlabRelease struct {
+	TagName    string    `json:"tag_name"`
+	Name       string    `json:"name"`
+	ReleasedAt time.Time `json:"released_at"`
+	Assets     struct {
+		Links []gitlabLink `json:"links"`
+	} `json:"assets"`
+}
+
+// gitlabLink represents a release asset link (other, runbook, image or package)
+type gitlabLink struct {
+	ID             int64  `json:"id"`
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	DirectAssetURL string `json:"direct_asset_url"`
+	LinkType       string `json:"link_type"`
+}
+
+// projectURL returns the API URL for the configured project
+func (g *GitLabRepository) projectURL() string {
+	// Project paths must be URL-encoded, including the slashes between namespaces
+	return fmt.Sprintf("%s/api/v4/projects/%s", g.BaseURL, url.PathEscape(g.Project))
+}
+
+// setAuthHeader sets the authentication header for the configured token type
+func (g *GitLabRepository) setAuthHeader(req *http.Request) {
+	if g.Token == "" {
+		return
+	}
+
+	header := "PRIVATE-TOKEN"
+	if g.TokenType == "job" {
+		header = "JOB-TOKEN"
+	}
+	req.Header.Set(header, g.Token)
+	g.debugLog("Request header set: %s", header)
+}
+
+// fetchRelease fetches and decodes a single release from the given API URL
+func (g *GitLabRepository) fetchRelease(apiURL string) (*Release, error) {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "guppy-updater")
+	req.Header.Set("Accept", "application/json")
+	g.setAuthHeader(req)
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching release: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		err := fmt.Errorf("GitLab API returned status %d: %s", resp.StatusCode, string(body))
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, &releaseNotFoundError{Err: err}
+		}
+		return nil, err
+	}
+
+	var glRelease gitlabRelease
+	if err := json.NewDecoder(resp.Body).Decode(&glRelease); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return g.convertGitLabRelease(&glRelease)
+}
+
+// GetLatestRelease returns the latest release from GitLab
+func (g *GitLabRepository) GetLatestRelease() (*Release, error) {
+	apiURL := g.projectURL() + "/releases/permalink/latest"
+	g.debugLog("Fetching latest release from URL: %s", apiURL)
+	return g.fetchRelease(apiURL)
+}
+
+// GetRelease returns a specific release by version
+func (g *GitLabRepository) GetRelease(version string) (*Release, error) {
+	return findRelease(version, func(tag string) (*Release, error) {
+		apiURL := fmt.Sprintf("%s/releases/%s", g.projectURL(), url.PathEscape(tag))
+		g.debugLog("Fetching release for version %s from URL: %s", tag, apiURL)
+		return g.fetchRelease(apiURL)
+	})
+}
+
+// ListReleases returns every release of the project, newest first, following Link header pagination
+// Releases without a matching asset link are skipped
+func (g *GitLabRepository) ListReleases() ([]*Release, error) {
+	var releases []*Release
+	nextURL := g.projectURL() + "/releases?per_page=100"
+
+	for nextURL != "" {
+		g.debugLog("Fetching releases from URL: %s", nextURL)
+
+		req, err := http.NewRequest("GET", nextURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %w", err)
+		}
+
+		req.Header.Set("User-Agent", "guppy-updater")
+		req.Header.Set("Accept", "application/json")
+		g.setAuthHeader(req)
+
+		resp, err := g.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching releases: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("GitLab API returned status %d: %s", resp.StatusCode, string(body))
+		}
+
+		var page []gitlabRelease
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding response: %w", err)
+		}
+
+		for i := range page {
+			release, err := g.convertGitLabRelease(&page[i])
+			if err != nil {
+				g.debugLog("Skipping release %s: %v", page[i].TagName, err)
+				continue
+			}
+			releases = append(releases, release)
+		}
+
+		nextURL = parseNextLink(resp.Header, req.URL)
+	}
+
+	sortReleases(releases)
+	return releases, nil
+}
+
+// CompareVersions compares current version with latest
+func (g *GitLabRepository) CompareVersions(current, latest string) (bool, error) {
+	return version.IsNewer(latest, current)
+}
+
+// Download downloads a release to the specified destination
+func (g *GitLabRepository) Download(release *Release, dest string) error {
+	if release.DownloadURL == "" {
+		return fmt.Errorf("no download URL in release")
+	}
+
+	g.debugLog("Downloading from URL: %s to %s", release.DownloadURL, dest)
+
+	req, err := http.NewRequest("GET", release.DownloadURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating download request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "guppy-updater")
+	req.Header.Set("Accept", "application/octet-stream")
+
+	// Only send credentials to the GitLab instance itself, not to external asset hosts
+	if strings.HasPrefix(release.DownloadURL, g.BaseURL+"/") {
+		g.setAuthHeader(req)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error downloading file: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+
+	// Create destination directory if it doesn't exist
+	destDir := filepath.Dir(dest)
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("error creating destination directory: %w", err)
+	}
+
+	// Create the destination file
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("error creating destination file: %w", err)
+	}
+	defer func() { _ = out.Close() }()
+
+	// Copy the content
+	_, err = io.Copy(out, resp.Body)
+	if err != nil {
+		return fmt.Errorf("error writing to destination: %w", err)
+	}
+
+	return nil
+}
+
+// isAuxiliaryLink reports whether a release link is a checksum or signature
+// file rather than a build artifact
+func isAuxiliaryLink(link *gitlabLink) bool {
+	linkURL := link.DirectAssetURL
+	if linkURL == "" {
+		linkURL = link.URL
+	}
+	name := linkFileName(link.Name, linkURL)
+	return checksum.IsSumsFile(name) || signature.IsSignatureFile(name)
+}
+
+// convertGitLabRelease converts a GitLab API release to our Release type
+func (g *GitLabRepository) convertGitLabRelease(glRelease *gitlabRelease) (*Release, error) {
+	links := glRelease.Assets.Links
+	if len(links) == 0 {
+		return nil, fmt.Errorf("release has no asset links")
+	}
+
+	g.debugLog("Release has %d asset link(s)", len(links))
+
+	// Find the asset link to download
+	var link *gitlabLink
+	if g.AssetName != "" {
+		g.debugLog("Looking for specific asset: %s", g.AssetName)
+		for i := range links {
+			if links[i].Name == g.AssetName {
+				link = &links[i]
+				break
+			}
+		}
+		if link == nil {
+			return nil, fmt.Errorf("asset %s not found in release", g.AssetName)
+		}
+	} else {
+		// Prefer package links, as those are the build artifacts, then any other
+		// link that is not a checksum or signature file, then the first link
+		for i := range links {
+			if links[i].LinkType == "package" && !isAuxiliaryLink(&links[i]) {
+				link = &links[i]
+				break
+			}
+		}
+		if link == nil {
+			for i := range links {
+				if !isAuxiliaryLink(&links[i]) {
+					link = &links[i]
+					break
+				}
+			}
+		}
+		if link == nil {
+			link = &links[0]
+		}
+	}
+
+	// The direct asset URL is stable across link target changes, so prefer it
+	downloadURL := link.DirectAssetURL
+	if downloadURL == "" {
+		downloadURL = link.URL
+	}
+	g.debugLog("Using asset: %s (ID: %d, type: %s)", link.Name, link.ID, link.LinkType)
+
+	fileName := linkFileName(link.Name, downloadURL)
+
+	// GitLab does not publish digests for release links
+	g.debugLog("WARNING: No checksum available for asset %s", link.Name)
+
+	assets := make(map[string]string, len(links))
+	for i := range links {
+		linkURL := links[i].DirectAssetURL
+		if linkURL == "" {
+			linkURL = links[i].URL
+		}
+		assets[linkFileName(links[i].Name, linkURL)] = linkURL
+	}
+
+	return &Release{
+		Version:     glRelease.TagName,
+		DownloadURL: downloadURL,
+		ReleaseDate: glRelease.ReleasedAt,
+		FileName:    fileName,
+		Signatures:  assetSignatures(fileName, assets),
+	}, nil
+}
+
+// linkFileName returns the file name of a release link
+// Link names are free-form labels, so the file name is taken from the URL when possible
+func linkFileName(name string, linkURL string) string {
+	if parsed, err := url.Parse(linkURL); err == nil {
+		if base := path.Base(parsed.Path); base != "." && base != "/" {
+			return base
+		}
+	}
+	return name
+}
@@ -0,0 +1,390 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newGitLabTestRelease builds a GitLab release API response with the given links
+func newGitLabTestRelease(tag string, links ...gitlabLink) gitlabRelease {
+	rel := gitlabRelease{
+		TagName:    tag,
+		Name:       "Release " + tag,
+		ReleasedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	rel.Assets.Links = links
+	return rel
+}
+
+func TestNewGitLabRepository_DefaultURL(t *testing.T) {
+	repo := NewGitLabRepository("", "group/project", "")
+	if repo.BaseURL != DefaultGitLabURL {
+		t.Errorf("BaseURL = %q, want %q", repo.BaseURL, DefaultGitLabURL)
+	}
+
+	repo = NewGitLabRepository("https://gitlab.example.com/", "group/project", "")
+	if repo.BaseURL != "https://gitlab.example.com" {
+		t.Errorf("BaseURL = %q, want trailing slash trimmed", repo.BaseURL)
+	}
+}
+
+func TestConvertGitLabRelease(t *testing.T) {
+	tests := []struct {
+		name         string
+		release      gitlabRelease
+		assetName    string
+		wantErr      bool
+		wantURL      string
+		wantFileName string
+	}{
+		{
+			name: "direct asset URL preferred",
+			release: newGitLabTestRelease("v1.0.0", gitlabLink{
+				ID:             1,
+				Name:           "Linux binary",
+				URL:            "https://storage.example.com/app-linux",
+				DirectAssetURL: "https://gitlab.example.com/group/project/-/releases/v1.0.0/downloads/app-linux",
+				LinkType:       "other",
+			}),
+			wantURL:      "https://gitlab.example.com/group/project/-/releases/v1.0.0/downloads/app-linux",
+			wantFileName: "app-linux",
+		},
+		{
+			name: "package link preferred over first link",
+			release: newGitLabTestRelease("v1.0.0",
+				gitlabLink{ID: 1, Name: "docs", URL: "https://example.com/docs.html", LinkType: "runbook"},
+				gitlabLink{ID: 2, Name: "app.tar.gz", URL: "https://example.com/app.tar.gz", LinkType: "package"},
+			),
+			wantURL:      "https://example.com/app.tar.gz",
+			wantFileName: "app.tar.gz",
+		},
+		{
+			name: "checksum and signature links skipped",
+			release: newGitLabTestRelease("v1.0.0",
+				gitlabLink{ID: 1, Name: "SHA256SUMS", URL: "https://example.com/SHA256SUMS", LinkType: "other"},
+				gitlabLink{ID: 2, Name: "app.tar.gz.sig", URL: "https://example.com/app.tar.gz.sig", LinkType: "package"},
+				gitlabLink{ID: 3, Name: "app.tar.gz", URL: "https://example.com/app.tar.gz", LinkType: "package"},
+			),
+			wantURL:      "https://example.com/app.tar.gz",
+			wantFileName: "app.tar.gz",
+		},
+		{
+			name: "checksum link skipped without package links",
+			release: newGitLabTestRelease("v1.0.0",
+				gitlabLink{ID: 1, Name: "checksums.txt", URL: "https://example.com/checksums.txt", LinkType: "other"},
+				gitlabLink{ID: 2, Name: "app-linux", URL: "https://example.com/app-linux", LinkType: "other"},
+			),
+			wantURL:      "https://example.com/app-linux",
+			wantFileName: "app-linux",
+		},
+		{
+			name: "specific asset by name",
+			release: newGitLabTestRelease("v1.0.0",
+				gitlabLink{ID: 1, Name: "app-linux", URL: "https://example.com/app-linux", LinkType: "package"},
+				gitlabLink{ID: 2, Name: "app-darwin", URL: "https://example.com/app-darwin", LinkType: "package"},
+			),
+			assetName:    "app-darwin",
+			wantURL:      "https://example.com/app-darwin",
+			wantFileName: "app-darwin",
+		},
+		{
+			name: "specific asset not found",
+			release: newGitLabTestRelease("v1.0.0",
+				gitlabLink{ID: 1, Name: "app-linux", URL: "https://example.com/app-linux"},
+			),
+			assetName: "app-windows.exe",
+			wantErr:   true,
+		},
+		{
+			name:    "release with no links",
+			release: newGitLabTestRelease("v1.0.0"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGitLabRepository("https://gitlab.example.com", "group/project", "")
+			if tt.assetName != "" {
+				g.SetAssetName(tt.assetName)
+			}
+
+			release, err := g.convertGitLabRelease(&tt.release)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("convertGitLabRelease() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("convertGitLabRelease() unexpected error: %v", err)
+			}
+
+			if release.DownloadURL != tt.wantURL {
+				t.Errorf("convertGitLabRelease() downloadURL = %q, want %q", release.DownloadURL, tt.wantURL)
+			}
+			if release.FileName != tt.wantFileName {
+				t.Errorf("convertGitLabRelease() fileName = %q, want %q", release.FileName, tt.wantFileName)
+			}
+			if release.Version != "v1.0.0" {
+				t.Errorf("convertGitLabRelease() version = %q, want v1.0.0", release.Version)
+			}
+		})
+	}
+}
+
+func TestGitLabRepository_GetLatestRelease(t *testing.T) {
+	tests := []struct {
+		name           string
+		token          string
+		tokenType      string
+		responseStatus int
+		wantErr        bool
+		wantHeader     string
+	}{
+		{
+			name:           "public project",
+			responseStatus: http.StatusOK,
+		},
+		{
+			name:           "private token",
+			token:          "glpat-secret",
+			responseStatus: http.StatusOK,
+			wantHeader:     "PRIVATE-TOKEN",
+		},
+		{
+			name:           "job token",
+			token:          "ci-job-token",
+			tokenType:      "job",
+			responseStatus: http.StatusOK,
+			wantHeader:     "JOB-TOKEN",
+		},
+		{
+			name:           "404 not found",
+			responseStatus: http.StatusNotFound,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Nested namespaces must reach the API as a single encoded path segment
+				wantPath := "/api/v4/projects/group%2Fsubgroup%2Fproject/releases/permalink/latest"
+				if r.URL.EscapedPath() != wantPath {
+					t.Errorf("Expected path %q, got %q", wantPath, r.URL.EscapedPath())
+				}
+				if r.Header.Get("User-Agent") != "guppy-updater" {
+					t.Errorf("Expected User-Agent header to be 'guppy-updater', got %q", r.Header.Get("User-Agent"))
+				}
+				if tt.wantHeader != "" && r.Header.Get(tt.wantHeader) != tt.token {
+					t.Errorf("Expected %s header to be %q, got %q", tt.wantHeader, tt.token, r.Header.Get(tt.wantHeader))
+				}
+
+				w.WriteHeader(tt.responseStatus)
+				if tt.responseStatus == http.StatusOK {
+					_ = json.NewEncoder(w).Encode(newGitLabTestRelease("v1.2.3", gitlabLink{
+						ID:       7,
+						Name:     "app",
+						URL:      "https://example.com/app",
+						LinkType: "package",
+					}))
+				} else {
+					_, _ = w.Write([]byte(`{"message":"404 Not Found"}`))
+				}
+			}))
+			defer server.Close()
+
+			repo := NewGitLabRepository(server.URL, "group/subgroup/project", tt.token)
+			repo.SetTokenType(tt.tokenType)
+
+			release, err := repo.GetLatestRelease()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("GetLatestRelease() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+			}
+
+			if release.Version != "v1.2.3" {
+				t.Errorf("GetLatestRelease() version = %q, want v1.2.3", release.Version)
+			}
+			if !release.ReleaseDate.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)) {
+				t.Errorf("GetLatestRelease() releaseDate = %v, want released_at", release.ReleaseDate)
+			}
+		})
+	}
+}
+
+func TestGitLabRepository_GetRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		tag     string
+	}{
+		{
+			name:    "with v prefix",
+			version: "v2.0.0",
+			tag:     "v2.0.0",
+		},
+		{
+			name:    "without v prefix",
+			version: "2.0.0",
+			tag:     "v2.0.0",
+		},
+		{
+			name:    "tag without v prefix",
+			version: "v2.0.0",
+			tag:     "2.0.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/releases/"+tt.tag {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(newGitLabTestRelease(tt.tag, gitlabLink{
+					ID:   1,
+					Name: "app",
+					URL:  "https://example.com/app",
+				}))
+			}))
+			defer server.Close()
+
+			repo := NewGitLabRepository(server.URL, "group/project", "")
+			release, err := repo.GetRelease(tt.version)
+			if err != nil {
+				t.Fatalf("GetRelease() unexpected error: %v", err)
+			}
+			if release.Version != tt.tag {
+				t.Errorf("GetRelease() version = %q, want %q", release.Version, tt.tag)
+			}
+		})
+	}
+}
+
+func TestGitLabRepository_Download(t *testing.T) {
+	const token = "glpat-secret"
+	const content = "gitlab binary content"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/group/project/-/releases/v1.0.0/downloads/app":
+			if r.Header.Get("PRIVATE-TOKEN") != token {
+				t.Errorf("Expected PRIVATE-TOKEN header on instance download, got %q", r.Header.Get("PRIVATE-TOKEN"))
+			}
+			_, _ = w.Write([]byte(content))
+		case "/external/app":
+			if r.Header.Get("PRIVATE-TOKEN") != "" {
+				t.Error("Token should not be sent to hosts other than the GitLab instance")
+			}
+			_, _ = w.Write([]byte(content))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		baseURL string
+		url     string
+		wantErr bool
+	}{
+		{
+			name:    "instance download with token",
+			baseURL: server.URL,
+			url:     server.URL + "/group/project/-/releases/v1.0.0/downloads/app",
+		},
+		{
+			name:    "external download without token",
+			baseURL: "https://gitlab.example.com",
+			url:     server.URL + "/external/app",
+		},
+		{
+			name:    "download failure - 404",
+			baseURL: server.URL,
+			url:     server.URL + "/missing",
+			wantErr: true,
+		},
+		{
+			name:    "missing download URL",
+			baseURL: server.URL,
+			url:     "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewGitLabRepository(tt.baseURL, "group/project", token)
+			dest := filepath.Join(t.TempDir(), "downloaded")
+
+			err := repo.Download(&Release{Version: "v1.0.0", DownloadURL: tt.url, FileName: "app"}, dest)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Download() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Download() unexpected error: %v", err)
+			}
+
+			got, err := os.ReadFile(dest)
+			if err != nil {
+				t.Fatalf("Failed to read downloaded file: %v", err)
+			}
+			if string(got) != content {
+				t.Errorf("Downloaded content = %q, want %q", string(got), content)
+			}
+		})
+	}
+}
+
+func TestGitLabRepository_ListReleases(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/releases" {
+			t.Errorf("Unexpected request path %q", r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var page []gitlabRelease
+		if r.URL.Query().Get("page") == "2" {
+			page = append(page, newGitLabTestRelease("v1.0.0", gitlabLink{Name: "app", URL: "https://example.com/1.0.0/app"}))
+		} else {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v4/projects/group%%2Fproject/releases?page=2&per_page=100>; rel="next"`, server.URL))
+			page = append(page,
+				newGitLabTestRelease("v1.1.0", gitlabLink{Name: "app", URL: "https://example.com/1.1.0/app"}),
+				newGitLabTestRelease("v1.0.1"),
+			)
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	repo := NewGitLabRepository(server.URL, "group/project", "")
+	releases, err := repo.ListReleases()
+	if err != nil {
+		t.Fatalf("ListReleases() unexpected error: %v", err)
+	}
+
+	// v1.0.1 has no links and is skipped
+	if len(releases) != 2 || releases[0].Version != "v1.1.0" || releases[1].Version != "v1.0.0" {
+		t.Errorf("ListReleases() = %v, want v1.1.0 and v1.0.0", releases)
+	}
+}
@@ -1,26 +1,60 @@
 package repository
 
 import (
-	"crypto/md5"
-	"crypto/sha1"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/jaredhaight/guppy/pkg/checksum"
+	"github.com/jaredhaight/guppy/pkg/signature"
 	"github.com/jaredhaight/guppy/pkg/version"
 )
 
+// Mirror selection strategies
+const (
+	MirrorStrategyOrdered = "ordered" // Try mirrors in the configured order
+	MirrorStrategyLatency = "latency" // Try the fastest responding mirror first
+)
+
 // HTTPRepository implements Repository for HTTP-based releases
 type HTTPRepository struct {
-	URL        string
-	httpClient *http.Client
-	debug      bool
+	URL            string
+	Mirrors        []string // Additional releases.json URLs tried after URL
+	MirrorStrategy string
+	Channel        string // Optional release channel (stable, beta or nightly)
+	httpClient     *http.Client
+	debug          bool
+	mirrorOrder    []string // Mirror order for the current check, reset by fetchReleases
+}
+
+// mirrorFailure records why a single mirror could not be used
+type mirrorFailure struct {
+	URL string
+	Err error
+}
+
+// mirrorError is returned when no mirror could serve a request
+type mirrorError struct {
+	Failures []mirrorFailure
+}
+
+func (e *mirrorError) Error() string {
+	parts := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		parts = append(parts, fmt.Sprintf("%s: %v", f.URL, f.Err))
+	}
+	return fmt.Sprintf("tried %d mirror(s): %s", len(e.Failures), strings.Join(parts, "; "))
 }
 
 // NewHTTPRepository creates a new HTTP repository
@@ -36,6 +70,24 @@ func (h *HTTPRepository) SetDebug(enabled bool) {
 	h.debug = enabled
 }
 
+// SetMirrors sets additional releases.json URLs to fall back to when URL is unavailable
+func (h *HTTPRepository) SetMirrors(mirrors []string) {
+	h.Mirrors = mirrors
+	h.mirrorOrder = nil
+}
+
+// SetMirrorStrategy sets how mirrors are ordered (ordered or latency)
+func (h *HTTPRepository) SetMirrorStrategy(strategy string) {
+	h.MirrorStrategy = strategy
+	h.mirrorOrder = nil
+}
+
+// SetChannel sets the release channel to follow
+// An empty channel considers every release
+func (h *HTTPRepository) SetChannel(channel string) {
+	h.Channel = channel
+}
+
 // debugLog prints a debug message if debug mode is enabled
 func (h *HTTPRepository) debugLog(format string, args ...interface{}) {
 	if h.debug {
@@ -50,37 +102,200 @@ type httpRelease struct {
 	MD5     string `json:"md5"`
 	SHA1    string `json:"sha1"`
 	SHA256  string `json:"sha256"`
+	SHA512  string `json:"sha512"`
+	BLAKE2b string `json:"blake2b"`
+	// ChecksumsURL points to a SHA256SUMS-style file listing the release's
+	// checksum, used when none of the fields above are set
+	ChecksumsURL string `json:"checksums_url,omitempty"`
+	// SignatureURL points to a detached signature of the download, such as
+	// an OpenPGP .asc file, when it is not published next to it
+	SignatureURL string `json:"signature_url,omitempty"`
+	Channel      string `json:"channel,omitempty"` // Defaults to stable
+}
+
+// checksum returns the strongest checksum the release provides in "algo:hex"
+// form, along with the algorithm's name
+// Priority: SHA512 > BLAKE2b > SHA256 > SHA1 > MD5
+func (r *httpRelease) checksum() (string, string) {
+	candidates := []struct{ algorithm, value, name string }{
+		{checksum.SHA512, r.SHA512, "SHA512"},
+		{checksum.BLAKE2b, r.BLAKE2b, "BLAKE2b"},
+		{checksum.SHA256, r.SHA256, "SHA256"},
+		{checksum.SHA1, r.SHA1, "SHA1"},
+		{checksum.MD5, r.MD5, "MD5"},
+	}
+	for _, c := range candidates {
+		if c.value != "" {
+			d := checksum.Digest{Algorithm: c.algorithm, Hex: strings.ToLower(strings.TrimSpace(c.value))}
+			return d.String(), c.name
+		}
+	}
+	return "", ""
+}
+
+// channel returns the release's channel, defaulting to stable
+func (r *httpRelease) channel() string {
+	if r.Channel == "" {
+		return ChannelStable
+	}
+	return r.Channel
+}
+
+// mirrorURLs returns the releases.json URLs to try, in order
+func (h *HTTPRepository) mirrorURLs() []string {
+	if h.mirrorOrder != nil {
+		return h.mirrorOrder
+	}
+
+	seen := make(map[string]bool)
+	var urls []string
+	for _, u := range append([]string{h.URL}, h.Mirrors...) {
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+	}
+
+	if h.MirrorStrategy == MirrorStrategyLatency && len(urls) > 1 {
+		urls = h.sortByLatency(urls)
+	}
+
+	h.mirrorOrder = urls
+	return urls
+}
+
+// sortByLatency orders mirrors by the response time of a HEAD request
+// Unreachable mirrors and mirrors returning 5xx are moved to the end
+func (h *HTTPRepository) sortByLatency(urls []string) []string {
+	client := &http.Client{Timeout: 5 * time.Second}
+	latency := make(map[string]time.Duration, len(urls))
+
+	for _, u := range urls {
+		latency[u] = time.Duration(math.MaxInt64)
+
+		req, err := http.NewRequest("HEAD", u, nil)
+		if err != nil {
+			continue
+		}
+		req.Header.Set("User-Agent", "guppy-updater")
+
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			h.debugLog("Mirror %s is unreachable: %v", u, err)
+			continue
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 500 {
+			h.debugLog("Mirror %s returned status %d", u, resp.StatusCode)
+			continue
+		}
+
+		latency[u] = time.Since(start)
+		h.debugLog("Mirror %s responded in %s", u, latency[u])
+	}
+
+	sorted := append([]string(nil), urls...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return latency[sorted[i]] < latency[sorted[j]]
+	})
+	return sorted
 }
 
-// fetchReleases fetches and parses the releases.json file
+// preferMirror moves the mirror that last served a request to the front, so
+// downloads start from a mirror known to be up
+func (h *HTTPRepository) preferMirror(mirror string) {
+	for i, u := range h.mirrorOrder {
+		if u == mirror && i > 0 {
+			order := append([]string{u}, h.mirrorOrder[:i]...)
+			h.mirrorOrder = append(order, h.mirrorOrder[i+1:]...)
+			return
+		}
+	}
+}
+
+// mirrorsFailed returns the error for a request that no mirror could serve
+// With a single URL the underlying error is returned unchanged
+func mirrorsFailed(failures []mirrorFailure) error {
+	if len(failures) == 1 {
+		return failures[0].Err
+	}
+	return &mirrorError{Failures: failures}
+}
+
+// fetchReleases fetches and parses the releases.json file, falling through
+// to the next mirror on connection errors and 5xx responses
 func (h *HTTPRepository) fetchReleases() ([]httpRelease, error) {
-	h.debugLog("Fetching releases from URL: %s", h.URL)
+	// Every check starts by fetching releases.json, so re-rank the mirrors here
+	// A long-running monitor would otherwise keep the order measured at startup
+	h.mirrorOrder = nil
+	urls := h.mirrorURLs()
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no releases URL configured")
+	}
+
+	var failures []mirrorFailure
+	for _, u := range urls {
+		releases, retry, err := h.fetchReleasesFrom(u)
+		if err == nil {
+			h.preferMirror(u)
+			return releases, nil
+		}
 
-	req, err := http.NewRequest("GET", h.URL, nil)
+		failures = append(failures, mirrorFailure{URL: u, Err: err})
+		if !retry {
+			break
+		}
+		h.debugLog("Mirror %s failed, trying next: %v", u, err)
+	}
+
+	return nil, mirrorsFailed(failures)
+}
+
+// fetchReleasesFrom fetches releases.json from a single mirror
+// Relative release, checksum and signature URLs are resolved against the
+// mirror URL, so they point at the mirror that served the file
+// The bool result reports whether the next mirror should be tried
+func (h *HTTPRepository) fetchReleasesFrom(releasesURL string) ([]httpRelease, bool, error) {
+	h.debugLog("Fetching releases from URL: %s", releasesURL)
+
+	base, err := url.Parse(releasesURL)
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+		return nil, false, fmt.Errorf("invalid releases URL: %w", err)
+	}
+
+	req, err := http.NewRequest("GET", releasesURL, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error creating request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", "guppy-updater")
 
 	resp, err := h.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching releases: %w", err)
+		return nil, true, fmt.Errorf("error fetching releases: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("HTTP request returned status %d: %s", resp.StatusCode, string(body))
+		return nil, resp.StatusCode >= 500, fmt.Errorf("HTTP request returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	var releases []httpRelease
 	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
-		return nil, fmt.Errorf("error decoding releases JSON: %w", err)
+		return nil, false, fmt.Errorf("error decoding releases JSON: %w", err)
+	}
+
+	for i := range releases {
+		releases[i].URL = resolveURL(base, releases[i].URL)
+		releases[i].ChecksumsURL = resolveURL(base, releases[i].ChecksumsURL)
+		releases[i].SignatureURL = resolveURL(base, releases[i].SignatureURL)
 	}
 
 	h.debugLog("Fetched %d release(s)", len(releases))
-	return releases, nil
+	return releases, false, nil
 }
 
 // GetLatestRelease returns the latest release by comparing all versions
@@ -97,6 +312,11 @@ func (h *HTTPRepository) GetLatestRelease() (*Release, error) {
 	// Find the latest version by comparing all releases
 	var latestRelease *httpRelease
 	for i := range releases {
+		if h.Channel != "" && !inChannel(releases[i].channel(), h.Channel) {
+			h.debugLog("Skipping %s release %s", releases[i].channel(), releases[i].Version)
+			continue
+		}
+
 		if latestRelease == nil {
 			latestRelease = &releases[i]
 			continue
@@ -115,6 +335,9 @@ func (h *HTTPRepository) GetLatestRelease() (*Release, error) {
 	}
 
 	if latestRelease == nil {
+		if h.Channel != "" {
+			return nil, fmt.Errorf("no releases found on channel %s", h.Channel)
+		}
 		return nil, fmt.Errorf("no valid release found")
 	}
 
@@ -131,14 +354,34 @@ func (h *HTTPRepository) GetRelease(version string) (*Release, error) {
 
 	h.debugLog("Looking for release version: %s", version)
 
-	for i := range releases {
-		if releases[i].Version == version {
-			h.debugLog("Found matching release: %s", version)
-			return h.convertHTTPRelease(&releases[i]), nil
+	return findRelease(version, func(tag string) (*Release, error) {
+		for i := range releases {
+			if releases[i].Version == tag {
+				h.debugLog("Found matching release: %s", tag)
+				return h.convertHTTPRelease(&releases[i]), nil
+			}
+		}
+		return nil, errVersionNotFound(tag)
+	})
+}
+
+// ListReleases returns every release on the configured channel, newest first
+func (h *HTTPRepository) ListReleases() ([]*Release, error) {
+	httpReleases, err := h.fetchReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]*Release, 0, len(httpReleases))
+	for i := range httpReleases {
+		if h.Channel != "" && !inChannel(httpReleases[i].channel(), h.Channel) {
+			continue
 		}
+		releases = append(releases, h.convertHTTPRelease(&httpReleases[i]))
 	}
 
-	return nil, fmt.Errorf("release version %s not found", version)
+	sortReleases(releases)
+	return releases, nil
 }
 
 // CompareVersions compares current version with latest
@@ -146,71 +389,144 @@ func (h *HTTPRepository) CompareVersions(current, latest string) (bool, error) {
 	return version.IsNewer(latest, current)
 }
 
-// Download downloads a release to the specified destination
+// Download downloads a release to the specified destination, falling through
+// to the next mirror on connection errors and 5xx responses
 func (h *HTTPRepository) Download(release *Release, dest string) error {
 	if release.DownloadURL == "" {
 		return fmt.Errorf("no download URL in release")
 	}
 
-	h.debugLog("Downloading from URL: %s to %s", release.DownloadURL, dest)
+	// The checksum file is only fetched for the release being installed
+	if release.Checksum == "" && len(release.ChecksumFiles) > 0 {
+		sum, err := h.checksumFromSumsFile(release)
+		if err != nil {
+			return fmt.Errorf("release %s: %w", release.Version, err)
+		}
+		h.debugLog("Selected checksum from checksum file: %s", sum)
+		release.Checksum = sum
+	}
+
+	var failures []mirrorFailure
+	downloaded := false
+	for _, u := range h.downloadURLs(release.DownloadURL) {
+		retry, err := h.downloadFrom(u, dest)
+		if err == nil {
+			downloaded = true
+			break
+		}
+
+		failures = append(failures, mirrorFailure{URL: u, Err: err})
+		if !retry {
+			break
+		}
+		h.debugLog("Download from %s failed, trying next mirror: %v", u, err)
+	}
+	if !downloaded {
+		return mirrorsFailed(failures)
+	}
+
+	// Verify checksum if available
+	if release.Checksum != "" {
+		h.debugLog("Verifying checksum: %s", release.Checksum)
+		valid, err := checksum.Verify(dest, release.Checksum)
+		if err == nil && !valid {
+			err = fmt.Errorf("file does not match %s", release.Checksum)
+		}
+		if err != nil {
+			// Remove the downloaded file if checksum verification fails
+			_ = os.Remove(dest)
+			return fmt.Errorf("checksum verification failed: %w", err)
+		}
+		h.debugLog("Checksum verification passed")
+	} else {
+		h.debugLog("WARNING: No checksum available for verification")
+	}
+
+	return nil
+}
+
+// downloadURLs returns the download URL on each mirror, in mirror order
+// A URL under the directory of a mirror's releases.json is mapped to the same
+// relative path on every other mirror; any other URL is used as-is
+func (h *HTTPRepository) downloadURLs(downloadURL string) []string {
+	mirrors := h.mirrorURLs()
+	for _, m := range mirrors {
+		base := mirrorBase(m)
+		if !strings.HasPrefix(downloadURL, base) {
+			continue
+		}
+
+		rel := strings.TrimPrefix(downloadURL, base)
+		urls := make([]string, 0, len(mirrors))
+		for _, other := range mirrors {
+			urls = append(urls, mirrorBase(other)+rel)
+		}
+		return urls
+	}
+	return []string{downloadURL}
+}
+
+// mirrorBase returns the directory part of a releases.json URL, including the trailing slash
+func mirrorBase(releasesURL string) string {
+	return releasesURL[:strings.LastIndex(releasesURL, "/")+1]
+}
+
+// downloadFrom downloads a single URL to dest
+// The bool result reports whether the next mirror should be tried
+func (h *HTTPRepository) downloadFrom(downloadURL, dest string) (bool, error) {
+	h.debugLog("Downloading from URL: %s to %s", downloadURL, dest)
 
-	req, err := http.NewRequest("GET", release.DownloadURL, nil)
+	req, err := http.NewRequest("GET", downloadURL, nil)
 	if err != nil {
-		return fmt.Errorf("error creating download request: %w", err)
+		return false, fmt.Errorf("error creating download request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", "guppy-updater")
 
 	resp, err := h.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("error downloading file: %w", err)
+		return true, fmt.Errorf("error downloading file: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+		return resp.StatusCode >= 500, fmt.Errorf("download failed with status %d", resp.StatusCode)
 	}
 
 	// Create destination directory if it doesn't exist
 	destDir := filepath.Dir(dest)
 	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return fmt.Errorf("error creating destination directory: %w", err)
+		return false, fmt.Errorf("error creating destination directory: %w", err)
 	}
 
 	// Create the destination file
 	out, err := os.Create(dest)
 	if err != nil {
-		return fmt.Errorf("error creating destination file: %w", err)
+		return false, fmt.Errorf("error creating destination file: %w", err)
 	}
-	defer func() { _ = out.Close() }()
 
-	// Copy the content
+	// Copy the content; a dropped connection leaves a partial file, so remove it
 	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		return fmt.Errorf("error writing to destination: %w", err)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
 	}
-
-	// Verify checksum if available
-	if release.Checksum != "" {
-		h.debugLog("Verifying checksum: %s", release.Checksum)
-		if err := h.verifyChecksum(dest, release.Checksum); err != nil {
-			// Remove the downloaded file if checksum verification fails
-			_ = os.Remove(dest)
-			return fmt.Errorf("checksum verification failed: %w", err)
-		}
-		h.debugLog("Checksum verification passed")
-	} else {
-		h.debugLog("WARNING: No checksum available for verification")
+	if err != nil {
+		_ = os.Remove(dest)
+		return true, fmt.Errorf("error writing to destination: %w", err)
 	}
 
-	return nil
+	return false, nil
 }
 
 // convertHTTPRelease converts an HTTP release to our Release type
+// A release that only has a checksums_url gets its checksum from it in Download
 func (h *HTTPRepository) convertHTTPRelease(httpRel *httpRelease) *Release {
-	checksum, checksumType := h.selectChecksum(httpRel)
-	if checksum != "" {
-		h.debugLog("Selected %s checksum: %s", checksumType, checksum)
+	sum, algorithm := httpRel.checksum()
+	var sumsFiles map[string]string
+	if sum != "" {
+		h.debugLog("Selected %s checksum: %s", algorithm, sum)
+	} else if httpRel.ChecksumsURL != "" {
+		sumsFiles = map[string]string{sumsFileName(httpRel.ChecksumsURL): httpRel.ChecksumsURL}
 	} else {
 		h.debugLog("WARNING: No checksum available for version %s", httpRel.Version)
 	}
@@ -218,85 +534,109 @@ func (h *HTTPRepository) convertHTTPRelease(httpRel *httpRelease) *Release {
 	// Extract filename from URL
 	fileName := filepath.Base(httpRel.URL)
 
+	// Without a signature_url, signatures are looked for next to the download
+	signatures := adjacentSignatures(httpRel.URL)
+	if httpRel.SignatureURL != "" {
+		signatures = make(map[string][]string)
+		for _, scheme := range signatureSchemes(httpRel.SignatureURL) {
+			signatures[scheme] = []string{httpRel.SignatureURL}
+		}
+	}
+
 	return &Release{
 		Version:     httpRel.Version,
 		DownloadURL: httpRel.URL,
 		FileName:    fileName,
-		Checksum:    checksum,
+		Checksum:    sum,
 		// ReleaseDate is not available in the HTTP format
-		ReleaseDate: time.Time{},
-		AssetID:     0,
+		ReleaseDate:   time.Time{},
+		AssetID:       0,
+		Signatures:    signatures,
+		ChecksumFiles: sumsFiles,
 	}
 }
 
-// selectChecksum selects the highest priority checksum from available options
-// Priority: SHA256 > SHA1 > MD5
-func (h *HTTPRepository) selectChecksum(httpRel *httpRelease) (string, string) {
-	if httpRel.SHA256 != "" {
-		return "sha256:" + httpRel.SHA256, "SHA256"
+// sumsFileName returns the file name of a checksums_url, such as SHA256SUMS
+func sumsFileName(sumsURL string) string {
+	if u, err := url.Parse(sumsURL); err == nil {
+		return path.Base(u.Path)
 	}
-	if httpRel.SHA1 != "" {
-		return "sha1:" + httpRel.SHA1, "SHA1"
+	return path.Base(sumsURL)
+}
+
+// resolveURL resolves a URL given in releases.json against the releases.json URL
+func resolveURL(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
 	}
-	if httpRel.MD5 != "" {
-		return "md5:" + httpRel.MD5, "MD5"
+	refURL, err := url.Parse(ref)
+	if err != nil || refURL.IsAbs() {
+		return ref
 	}
-	return "", ""
+	return base.ResolveReference(refURL).String()
 }
 
-// verifyChecksum verifies the downloaded file against the checksum
-// Checksum format: "algorithm:hexvalue" (e.g., "sha256:abc123...")
-func (h *HTTPRepository) verifyChecksum(filePath, checksum string) error {
-	// Open the file
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("error opening file: %w", err)
+// signatureSchemes returns the schemes a signature_url may hold, going by its
+// extension; URLs without a known extension are taken to be OpenPGP signatures
+func signatureSchemes(sigURL string) []string {
+	if u, err := url.Parse(sigURL); err == nil {
+		if schemes := signature.Schemes(u.Path); schemes != nil {
+			return schemes
+		}
 	}
-	defer func() { _ = file.Close() }()
+	return []string{signature.SchemeOpenPGP}
+}
 
-	// Parse the checksum format
-	var algorithm, expectedHash string
-	for i, c := range checksum {
-		if c == ':' {
-			algorithm = checksum[:i]
-			expectedHash = checksum[i+1:]
-			break
+// checksumFromSumsFile fetches the release's checksum file and returns the
+// entry for its download in "algo:hex" form
+// Each mirror is tried in turn. A file that does not list the download is an
+// error.
+func (h *HTTPRepository) checksumFromSumsFile(release *Release) (string, error) {
+	var failures []mirrorFailure
+	for _, name := range slices.Sorted(maps.Keys(release.ChecksumFiles)) {
+		for _, u := range h.downloadURLs(release.ChecksumFiles[name]) {
+			h.debugLog("Fetching checksum file from URL: %s", u)
+			sum, retry, err := h.fetchSumsFile(u, release.FileName)
+			if err == nil {
+				if sum == "" {
+					return "", fmt.Errorf("checksum file %s does not list %s", u, release.FileName)
+				}
+				return sum, nil
+			}
+
+			failures = append(failures, mirrorFailure{URL: u, Err: err})
+			if !retry {
+				break
+			}
+			h.debugLog("Fetching %s failed, trying next mirror: %v", u, err)
 		}
 	}
+	return "", mirrorsFailed(failures)
+}
 
-	if algorithm == "" || expectedHash == "" {
-		return fmt.Errorf("invalid checksum format: %s", checksum)
+// fetchSumsFile downloads a checksum file and looks up fileName in it
+// The bool result reports whether the next mirror should be tried
+func (h *HTTPRepository) fetchSumsFile(sumsURL, fileName string) (string, bool, error) {
+	req, err := http.NewRequest("GET", sumsURL, nil)
+	if err != nil {
+		return "", false, fmt.Errorf("error creating request: %w", err)
 	}
 
-	// Calculate the hash based on the algorithm
-	var actualHash string
-	switch algorithm {
-	case "sha256":
-		hasher := sha256.New()
-		if _, err := io.Copy(hasher, file); err != nil {
-			return fmt.Errorf("error calculating SHA256: %w", err)
-		}
-		actualHash = hex.EncodeToString(hasher.Sum(nil))
-	case "sha1":
-		hasher := sha1.New()
-		if _, err := io.Copy(hasher, file); err != nil {
-			return fmt.Errorf("error calculating SHA1: %w", err)
-		}
-		actualHash = hex.EncodeToString(hasher.Sum(nil))
-	case "md5":
-		hasher := md5.New()
-		if _, err := io.Copy(hasher, file); err != nil {
-			return fmt.Errorf("error calculating MD5: %w", err)
-		}
-		actualHash = hex.EncodeToString(hasher.Sum(nil))
-	default:
-		return fmt.Errorf("unsupported hash algorithm: %s", algorithm)
+	req.Header.Set("User-Agent", "guppy-updater")
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return "", true, fmt.Errorf("error fetching checksum file: %w", err)
 	}
+	defer func() { _ = resp.Body.Close() }()
 
-	// Compare the hashes
-	if actualHash != expectedHash {
-		return fmt.Errorf("%s mismatch: expected %s, got %s", algorithm, expectedHash, actualHash)
+	if resp.StatusCode != http.StatusOK {
+		return "", resp.StatusCode >= 500, fmt.Errorf("checksum file request failed with status %d", resp.StatusCode)
 	}
 
-	return nil
+	sum, err := checksum.FindInSums(resp.Body, fileName, checksum.AlgorithmFromName(sumsURL))
+	if err != nil {
+		return "", true, fmt.Errorf("error reading checksum file: %w", err)
+	}
+	return sum, false, nil
 }
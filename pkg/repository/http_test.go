@@ -1,15 +1,22 @@
 package repository
 
 import (
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
-func TestSelectChecksum(t *testing.T) {
+func TestHTTPReleaseChecksum(t *testing.T) {
 	tests := []struct {
 		name             string
 		httpRel          *httpRelease
@@ -54,6 +61,26 @@ func TestSelectChecksum(t *testing.T) {
 			expectedChecksum: "sha256:abc123",
 			expectedType:     "SHA256",
 		},
+		{
+			name: "sha512 preferred over sha256",
+			httpRel: &httpRelease{
+				Version: "1.0.0",
+				SHA512:  "ABC512",
+				SHA256:  "abc123",
+			},
+			expectedChecksum: "sha512:abc512",
+			expectedType:     "SHA512",
+		},
+		{
+			name: "blake2b preferred over sha256",
+			httpRel: &httpRelease{
+				Version: "1.0.0",
+				BLAKE2b: "b2b",
+				SHA256:  "abc123",
+			},
+			expectedChecksum: "blake2b:b2b",
+			expectedType:     "BLAKE2b",
+		},
 		{
 			name: "sha1 and md5 - sha1 preferred",
 			httpRel: &httpRelease{
@@ -76,15 +103,14 @@ func TestSelectChecksum(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			h := NewHTTPRepository("http://example.com/releases.json")
-			checksum, checksumType := h.selectChecksum(tt.httpRel)
+			checksum, checksumType := tt.httpRel.checksum()
 
 			if checksum != tt.expectedChecksum {
-				t.Errorf("selectChecksum() checksum = %q, want %q", checksum, tt.expectedChecksum)
+				t.Errorf("checksum() checksum = %q, want %q", checksum, tt.expectedChecksum)
 			}
 
 			if checksumType != tt.expectedType {
-				t.Errorf("selectChecksum() type = %q, want %q", checksumType, tt.expectedType)
+				t.Errorf("checksum() type = %q, want %q", checksumType, tt.expectedType)
 			}
 		})
 	}
@@ -216,6 +242,12 @@ func TestGetRelease(t *testing.T) {
 			expectedVersion: "3.0.0",
 			wantErr:         false,
 		},
+		{
+			name:            "v prefix not in releases.json",
+			requestVersion:  "v2.0.0",
+			expectedVersion: "2.0.0",
+			wantErr:         false,
+		},
 		{
 			name:           "non-existent version",
 			requestVersion: "4.0.0",
@@ -256,76 +288,6 @@ func TestGetRelease(t *testing.T) {
 	}
 }
 
-func TestVerifyChecksum(t *testing.T) {
-	// Create a temporary file with known content
-	content := []byte("test content for checksum verification")
-	tmpDir := t.TempDir()
-	tmpFile := filepath.Join(tmpDir, "test.txt")
-
-	if err := os.WriteFile(tmpFile, content, 0644); err != nil {
-		t.Fatalf("Failed to create test file: %v", err)
-	}
-
-	tests := []struct {
-		name     string
-		checksum string
-		wantErr  bool
-	}{
-		{
-			name:     "valid sha256",
-			checksum: "sha256:0bb4f3131cf52feab05638958f23f10539388ba67cd7977f5ffc46add6a3fff5",
-			wantErr:  false,
-		},
-		{
-			name:     "valid sha1",
-			checksum: "sha1:9972a14ef931c289b5122e6e1b7005e7891f28ff",
-			wantErr:  false,
-		},
-		{
-			name:     "valid md5",
-			checksum: "md5:d28cd39b02ce37082426395b9385f56e",
-			wantErr:  false,
-		},
-		{
-			name:     "invalid sha256",
-			checksum: "sha256:wronghash",
-			wantErr:  true,
-		},
-		{
-			name:     "invalid algorithm",
-			checksum: "sha512:abc123",
-			wantErr:  true,
-		},
-		{
-			name:     "invalid format - no colon",
-			checksum: "sha256abc123",
-			wantErr:  true,
-		},
-		{
-			name:     "empty checksum",
-			checksum: "",
-			wantErr:  true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			h := NewHTTPRepository("http://example.com/releases.json")
-			err := h.verifyChecksum(tmpFile, tt.checksum)
-
-			if tt.wantErr {
-				if err == nil {
-					t.Errorf("verifyChecksum() expected error, got nil")
-				}
-			} else {
-				if err != nil {
-					t.Errorf("verifyChecksum() unexpected error: %v", err)
-				}
-			}
-		})
-	}
-}
-
 func TestConvertHTTPRelease(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -469,3 +431,404 @@ func TestDownload(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPRepository_MirrorFailover(t *testing.T) {
+	// A mirror that is down entirely
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	// A mirror that returns 5xx for everything
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	// A healthy mirror with a relative download URL
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/releases.json":
+			_, _ = w.Write([]byte(`[{"version": "1.2.0", "url": "1.2.0/app", "sha256": "6ae8a75555209fd6c44157c0aed8016e763ff435a19cf186f76863140143ff72"}]`))
+		case "/releases/1.2.0/app":
+			_, _ = w.Write([]byte("test content"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer healthy.Close()
+
+	h := NewHTTPRepository(down.URL + "/releases/releases.json")
+	h.SetMirrors([]string{broken.URL + "/releases/releases.json", healthy.URL + "/releases/releases.json"})
+
+	release, err := h.GetLatestRelease()
+	if err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+	if release.DownloadURL != healthy.URL+"/releases/1.2.0/app" {
+		t.Errorf("GetLatestRelease() downloadURL = %q, want URL resolved against the healthy mirror", release.DownloadURL)
+	}
+
+	// The healthy mirror served the metadata, so it is tried first for the download
+	if urls := h.downloadURLs(release.DownloadURL); urls[0] != healthy.URL+"/releases/1.2.0/app" || len(urls) != 3 {
+		t.Errorf("downloadURLs() = %v, want healthy mirror first and one URL per mirror", urls)
+	}
+
+	dest := filepath.Join(t.TempDir(), "app")
+	if err := h.Download(release, dest); err != nil {
+		t.Fatalf("Download() unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(dest)
+	if string(content) != "test content" {
+		t.Errorf("Download() content = %q, want %q", string(content), "test content")
+	}
+
+	// Downloads fall through too; the other two mirrors fail before the healthy one is reached
+	h = NewHTTPRepository(down.URL + "/releases/releases.json")
+	h.SetMirrors([]string{broken.URL + "/releases/releases.json", healthy.URL + "/releases/releases.json"})
+	if err := h.Download(&Release{DownloadURL: down.URL + "/releases/1.2.0/app"}, dest); err != nil {
+		t.Errorf("Download() unexpected error with failing primary mirror: %v", err)
+	}
+}
+
+func TestHTTPRepository_MirrorsOnlyRelativeURLs(t *testing.T) {
+	content := []byte("test content")
+	sum := sha256.Sum256(content)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/releases.json":
+			_, _ = w.Write([]byte(`[{"version": "1.2.0", "url": "1.2.0/app", "checksums_url": "1.2.0/SHA256SUMS", "signature_url": "1.2.0/app.asc"}]`))
+		case "/releases/1.2.0/SHA256SUMS":
+			_, _ = fmt.Fprintf(w, "%x  app\n", sum)
+		case "/releases/1.2.0/app":
+			_, _ = w.Write(content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// No primary URL; releases.json and everything it references come from the mirror
+	h := NewHTTPRepository("")
+	h.SetMirrors([]string{server.URL + "/releases/releases.json"})
+
+	release, err := h.GetLatestRelease()
+	if err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+	if want := []string{server.URL + "/releases/1.2.0/app.asc"}; !slices.Equal(release.Signatures["openpgp"], want) {
+		t.Errorf("GetLatestRelease() signatures = %v, want .asc at %s", release.Signatures, want)
+	}
+
+	dest := filepath.Join(t.TempDir(), "app")
+	if err := h.Download(release, dest); err != nil {
+		t.Fatalf("Download() unexpected error: %v", err)
+	}
+	if want := fmt.Sprintf("sha256:%x", sum); release.Checksum != want {
+		t.Errorf("Download() checksum = %q, want %q", release.Checksum, want)
+	}
+}
+
+func TestHTTPRepository_MirrorErrors(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	missing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer missing.Close()
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"version": "1.0.0", "url": "https://example.com/app"}]`))
+	}))
+	defer healthy.Close()
+
+	// Every mirror is reported when all of them fail
+	h := NewHTTPRepository(broken.URL + "/a/releases.json")
+	h.SetMirrors([]string{broken.URL + "/b/releases.json"})
+	_, err := h.GetLatestRelease()
+	if err == nil {
+		t.Fatal("GetLatestRelease() expected error, got nil")
+	}
+	for _, want := range []string{broken.URL + "/a/releases.json", broken.URL + "/b/releases.json", "status 503"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("GetLatestRelease() error = %q, want it to mention %q", err.Error(), want)
+		}
+	}
+
+	// A 4xx response is not a mirror outage and stops the failover
+	h = NewHTTPRepository(missing.URL + "/releases.json")
+	h.SetMirrors([]string{healthy.URL + "/releases.json"})
+	if _, err := h.GetLatestRelease(); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("GetLatestRelease() error = %v, want 404 without trying further mirrors", err)
+	}
+}
+
+func TestHTTPRepository_MirrorLatency(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fast.Close()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	h := NewHTTPRepository(down.URL + "/releases.json")
+	h.SetMirrors([]string{slow.URL + "/releases.json", fast.URL + "/releases.json"})
+	h.SetMirrorStrategy(MirrorStrategyLatency)
+
+	got := h.mirrorURLs()
+	want := []string{fast.URL + "/releases.json", slow.URL + "/releases.json", down.URL + "/releases.json"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("mirrorURLs() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestHTTPRepository_MirrorLatencyRemeasured(t *testing.T) {
+	var firstSlow atomic.Bool
+	firstSlow.Store(true)
+
+	handler := func(slow func() bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if slow() {
+				time.Sleep(100 * time.Millisecond)
+			}
+			_, _ = w.Write([]byte(`[{"version": "1.0.0", "url": "https://example.com/app"}]`))
+		}
+	}
+	first := httptest.NewServer(handler(firstSlow.Load))
+	defer first.Close()
+	second := httptest.NewServer(handler(func() bool { return !firstSlow.Load() }))
+	defer second.Close()
+
+	h := NewHTTPRepository(first.URL + "/releases.json")
+	h.SetMirrors([]string{second.URL + "/releases.json"})
+	h.SetMirrorStrategy(MirrorStrategyLatency)
+
+	if _, err := h.GetLatestRelease(); err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+	if got := h.mirrorOrder[0]; got != second.URL+"/releases.json" {
+		t.Errorf("first check preferred %s, want the faster second mirror", got)
+	}
+
+	firstSlow.Store(false)
+	if _, err := h.GetLatestRelease(); err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+	if got := h.mirrorOrder[0]; got != first.URL+"/releases.json" {
+		t.Errorf("second check preferred %s, want the now faster first mirror", got)
+	}
+}
+
+func TestHTTPRepository_Channel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+  {"version": "1.0.0", "url": "https://example.com/1.0.0/app"},
+  {"version": "1.1.0-beta.1", "url": "https://example.com/1.1.0-beta.1/app", "channel": "beta"},
+  {"version": "1.2.0-nightly.1", "url": "https://example.com/1.2.0-nightly.1/app", "channel": "nightly"}
+]`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		channel     string
+		wantVersion string
+	}{
+		{channel: "", wantVersion: "1.2.0-nightly.1"},
+		{channel: ChannelStable, wantVersion: "1.0.0"},
+		{channel: ChannelBeta, wantVersion: "1.1.0-beta.1"},
+		{channel: ChannelNightly, wantVersion: "1.2.0-nightly.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run("channel "+tt.channel, func(t *testing.T) {
+			h := NewHTTPRepository(server.URL + "/releases.json")
+			h.SetChannel(tt.channel)
+
+			release, err := h.GetLatestRelease()
+			if err != nil {
+				t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+			}
+			if release.Version != tt.wantVersion {
+				t.Errorf("GetLatestRelease() version = %q, want %q", release.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestHTTPRepository_ListReleases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+  {"version": "1.0.0", "url": "https://example.com/1.0.0/app.zip", "md5": "d1c47df9c7d692538e6744fea9d826b1"},
+  {"version": "1.10.0", "url": "https://example.com/1.10.0/app.zip", "sha256": "abc"},
+  {"version": "1.2.0", "url": "https://example.com/1.2.0/app.zip"}
+]`))
+	}))
+	defer server.Close()
+
+	h := NewHTTPRepository(server.URL + "/releases.json")
+	releases, err := h.ListReleases()
+	if err != nil {
+		t.Fatalf("ListReleases() unexpected error: %v", err)
+	}
+
+	want := []string{"1.10.0", "1.2.0", "1.0.0"}
+	if len(releases) != len(want) {
+		t.Fatalf("ListReleases() returned %d releases, want %d", len(releases), len(want))
+	}
+	for i, rel := range releases {
+		if rel.Version != want[i] {
+			t.Errorf("ListReleases()[%d] version = %q, want %q", i, rel.Version, want[i])
+		}
+	}
+	if releases[0].FileName != "app.zip" || releases[0].Checksum != "sha256:abc" {
+		t.Errorf("ListReleases()[0] = %+v, want app.zip with sha256 checksum", releases[0])
+	}
+	if releases[1].Checksum != "" {
+		t.Errorf("ListReleases()[1] checksum = %q, want empty", releases[1].Checksum)
+	}
+}
+
+func TestHTTPRepository_ChecksumsURL(t *testing.T) {
+	sha256Sum := sha256.Sum256([]byte("app 1.0.0"))
+	sha512Sum := sha512.Sum512([]byte("app 2.0.0"))
+	sums := fmt.Sprintf("%x  app-1.0.0.zip\nSHA512 (app-2.0.0.zip) = %x\n", sha256Sum, sha512Sum)
+	inlineSum := sha256.Sum256([]byte("app 4.0.0"))
+
+	var sumsRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases.json":
+			_, _ = fmt.Fprintf(w, `[
+  {"version": "1.0.0", "url": "app-1.0.0.zip", "checksums_url": "SHA256SUMS"},
+  {"version": "2.0.0", "url": "app-2.0.0.zip", "checksums_url": "SHA256SUMS"},
+  {"version": "3.0.0", "url": "app-3.0.0.zip", "checksums_url": "SHA256SUMS"},
+  {"version": "4.0.0", "url": "app-4.0.0.zip", "sha256": "%x", "checksums_url": "SHA256SUMS"},
+  {"version": "5.0.0", "url": "app-5.0.0.zip", "checksums_url": "missing/SHA256SUMS"}
+]`, inlineSum)
+		case "/SHA256SUMS":
+			sumsRequests++
+			_, _ = w.Write([]byte(sums))
+		case "/app-1.0.0.zip", "/app-2.0.0.zip", "/app-3.0.0.zip", "/app-4.0.0.zip", "/app-5.0.0.zip":
+			_, _ = w.Write([]byte("app " + strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/app-"), ".zip")))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	h := NewHTTPRepository(server.URL + "/releases.json")
+
+	// Listing releases does not fetch checksum files, even one that is missing
+	releases, err := h.ListReleases()
+	if err != nil {
+		t.Fatalf("ListReleases() unexpected error: %v", err)
+	}
+	if len(releases) != 5 || sumsRequests != 0 {
+		t.Errorf("ListReleases() = %d releases with %d checksum file request(s), want 5 and 0", len(releases), sumsRequests)
+	}
+
+	tests := []struct {
+		version      string
+		wantChecksum string
+		wantErr      bool
+	}{
+		{version: "1.0.0", wantChecksum: fmt.Sprintf("sha256:%x", sha256Sum)},
+		{version: "2.0.0", wantChecksum: fmt.Sprintf("sha512:%x", sha512Sum)},
+		{version: "3.0.0", wantErr: true},
+		{version: "4.0.0", wantChecksum: fmt.Sprintf("sha256:%x", inlineSum)},
+		{version: "5.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			sumsRequests = 0
+			release, err := h.GetRelease(tt.version)
+			if err != nil {
+				t.Fatalf("GetRelease() unexpected error: %v", err)
+			}
+			if sumsRequests != 0 {
+				t.Error("GetRelease() should not fetch checksums_url before Download")
+			}
+
+			err = h.Download(release, filepath.Join(t.TempDir(), "app.zip"))
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Download() expected error for a checksum file that is missing or does not list the release, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Download() unexpected error: %v", err)
+			}
+			if release.Checksum != tt.wantChecksum {
+				t.Errorf("Download() checksum = %q, want %q", release.Checksum, tt.wantChecksum)
+			}
+			if tt.version == "4.0.0" && sumsRequests != 0 {
+				t.Error("Download() should not fetch checksums_url when an inline checksum is given")
+			}
+		})
+	}
+}
+
+func TestHTTPRepository_SignatureURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+  {"version": "1.0.0", "url": "https://example.com/app-1.0.0.zip", "signature_url": "sigs/app-1.0.0.zip.asc"},
+  {"version": "2.0.0", "url": "https://example.com/app-2.0.0.zip", "signature_url": "https://sigs.example.com/app-2.0.0.zip.minisig"},
+  {"version": "3.0.0", "url": "https://example.com/app-3.0.0.zip", "signature_url": "https://sigs.example.com/signature?release=3"},
+  {"version": "4.0.0", "url": "https://example.com/app-4.0.0.zip"},
+  {"version": "5.0.0", "url": "https://example.com/app-5.0.0.zip", "signature_url": "https://sigs.example.com/app-5.0.0.zip.sigstore.json"},
+  {"version": "6.0.0", "url": "https://example.com/app-6.0.0.zip", "signature_url": "https://sigs.example.com/app-6.0.0.zip.sig"}
+]`))
+	}))
+	defer server.Close()
+
+	h := NewHTTPRepository(server.URL + "/updates/releases.json")
+
+	tests := []struct {
+		version  string
+		wantSigs map[string][]string
+	}{
+		{version: "1.0.0", wantSigs: map[string][]string{"openpgp": {server.URL + "/updates/sigs/app-1.0.0.zip.asc"}}},
+		{version: "2.0.0", wantSigs: map[string][]string{"minisign": {"https://sigs.example.com/app-2.0.0.zip.minisig"}}},
+		{version: "3.0.0", wantSigs: map[string][]string{"openpgp": {"https://sigs.example.com/signature?release=3"}}},
+		// Without signature_url, signatures are looked for next to the download
+		{version: "4.0.0", wantSigs: map[string][]string{
+			"minisign": {"https://example.com/app-4.0.0.zip.minisig", "https://example.com/app-4.0.0.zip.sig"},
+			"openpgp":  {"https://example.com/app-4.0.0.zip.asc", "https://example.com/app-4.0.0.zip.sig"},
+			"sigstore": {"https://example.com/app-4.0.0.zip.sigstore.json"},
+		}},
+		{version: "5.0.0", wantSigs: map[string][]string{"sigstore": {"https://sigs.example.com/app-5.0.0.zip.sigstore.json"}}},
+		// A .sig file may hold either kind of signature
+		{version: "6.0.0", wantSigs: map[string][]string{
+			"minisign": {"https://sigs.example.com/app-6.0.0.zip.sig"},
+			"openpgp":  {"https://sigs.example.com/app-6.0.0.zip.sig"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			release, err := h.GetRelease(tt.version)
+			if err != nil {
+				t.Fatalf("GetRelease() unexpected error: %v", err)
+			}
+			if len(release.Signatures) != len(tt.wantSigs) {
+				t.Errorf("GetRelease() signatures = %v, want %v", release.Signatures, tt.wantSigs)
+			}
+			for scheme, want := range tt.wantSigs {
+				if !slices.Equal(release.Signatures[scheme], want) {
+					t.Errorf("GetRelease() %s signatures = %q, want %q", scheme, release.Signatures[scheme], want)
+				}
+			}
+		})
+	}
+}
@@ -9,7 +9,14 @@ type Release struct {
 	Checksum    string
 	ReleaseDate time.Time
 	FileName    string
-	AssetID     int64 // GitHub asset ID (0 if not applicable)
+	AssetID     int64               // Provider asset ID, e.g. GitHub or Gitea (0 if not applicable)
+	Signatures  map[string][]string // URLs of detached signatures that may be published with the release, by scheme, in the order they are tried
+
+	// ChecksumFiles lists checksum files that may list the release, such as
+	// SHA256SUMS, by file name
+	// They are only fetched, by Download, when Checksum is empty; Download
+	// then sets Checksum from them.
+	ChecksumFiles map[string]string
 }
 
 // Repository checks for new releases and downloads them
@@ -20,10 +27,14 @@ type Repository interface {
 	// GetRelease returns a specific release by version
 	GetRelease(version string) (*Release, error)
 
+	// ListReleases returns all available releases, newest first
+	ListReleases() ([]*Release, error)
+
 	// CompareVersions compares current version with latest
 	// Returns true if latest is newer than current
 	CompareVersions(current, latest string) (bool, error)
 
 	// Download downloads a release to the specified destination
+	// A checksum read from the release's ChecksumFiles is stored in Checksum.
 	Download(release *Release, dest string) error
 }
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jaredhaight/guppy/pkg/version"
+)
+
+// linkNextRegexp matches the target of a rel="next" Link header
+var linkNextRegexp = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// parseNextLink returns the rel="next" URL from a Link header, resolved against base
+// Returns an empty string if there is no next page
+func parseNextLink(header http.Header, base *url.URL) string {
+	match := linkNextRegexp.FindStringSubmatch(header.Get("Link"))
+	if match == nil {
+		return ""
+	}
+	next, err := base.Parse(match[1])
+	if err != nil {
+		return ""
+	}
+	return next.String()
+}
+
+// sortReleases sorts releases newest first
+// Releases whose versions cannot be parsed keep their relative order at the end
+func sortReleases(releases []*Release) {
+	sort.SliceStable(releases, func(i, j int) bool {
+		vi, errI := version.Parse(releases[i].Version)
+		vj, errJ := version.Parse(releases[j].Version)
+		if errI != nil || errJ != nil {
+			return errI == nil && errJ != nil
+		}
+		return vi.IsNewer(vj)
+	})
+}
+
+// releaseNotFoundError reports that a release does not exist, as opposed to a
+// failure to look it up
+type releaseNotFoundError struct {
+	Err error
+}
+
+func (e *releaseNotFoundError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *releaseNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// versionCandidates returns the tags a version may be published under: the
+// version as given, then with the "v" prefix added or removed
+func versionCandidates(v string) []string {
+	if trimmed, ok := strings.CutPrefix(v, "v"); ok && trimmed != "" {
+		return []string{v, trimmed}
+	}
+	return []string{v, "v" + v}
+}
+
+// findRelease looks up each candidate tag for a version in turn, so "1.2.0"
+// and "v1.2.0" find the same release however it is tagged
+// Only a missing release moves on to the next candidate; other errors are returned
+func findRelease(v string, lookup func(tag string) (*Release, error)) (*Release, error) {
+	var firstErr error
+	for _, tag := range versionCandidates(v) {
+		release, err := lookup(tag)
+		if err == nil {
+			return release, nil
+		}
+
+		var notFound *releaseNotFoundError
+		if !errors.As(err, &notFound) {
+			return nil, err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// errVersionNotFound returns the error for a version missing from a release list
+func errVersionNotFound(v string) error {
+	return &releaseNotFoundError{Err: fmt.Errorf("release version %s not found", v)}
+}
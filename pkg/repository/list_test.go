@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestParseNextLink(t *testing.T) {
+	base, _ := url.Parse("https://registry.example.com/v2/team/myapp/tags/list")
+
+	tests := []struct {
+		link string
+		want string
+	}{
+		{`</v2/team/myapp/tags/list?n=2&last=b>; rel="next"`, "https://registry.example.com/v2/team/myapp/tags/list?n=2&last=b"},
+		{`<https://api.example.com/page2>; rel="next", <https://api.example.com/page5>; rel="last"`, "https://api.example.com/page2"},
+		{`<https://api.example.com/page1>; rel="prev"`, ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		header := http.Header{}
+		header.Set("Link", tt.link)
+		if got := parseNextLink(header, base); got != tt.want {
+			t.Errorf("parseNextLink(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestSortReleases(t *testing.T) {
+	releases := []*Release{
+		{Version: "1.2.0"},
+		{Version: "latest"},
+		{Version: "v1.10.0"},
+		{Version: "1.10.0-rc.1"},
+		{Version: "1.9.0"},
+	}
+
+	sortReleases(releases)
+
+	want := []string{"v1.10.0", "1.10.0-rc.1", "1.9.0", "1.2.0", "latest"}
+	for i, rel := range releases {
+		if rel.Version != want[i] {
+			t.Errorf("sortReleases()[%d] = %q, want %q", i, rel.Version, want[i])
+		}
+	}
+}
@@ -0,0 +1,448 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jaredhaight/guppy/pkg/version"
+)
+
+const (
+	// ociManifestMediaType is the media type of an OCI image manifest
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+	// ociTitleAnnotation holds a layer's file name in ORAS-style artifacts
+	ociTitleAnnotation = "org.opencontainers.image.title"
+
+	// ociCreatedAnnotation holds the manifest creation time
+	ociCreatedAnnotation = "org.opencontainers.image.created"
+)
+
+// OCIRepository implements Repository for artifacts stored in an OCI registry
+// Tags are versions, and each tag's manifest holds the artifact as a layer blob
+type OCIRepository struct {
+	Registry   string // Registry URL (e.g. https://registry.example.com)
+	Name       string // Repository name (e.g. team/myapp)
+	Token      string // Optional: static bearer token
+	AssetName  string // Optional: select the layer whose title annotation matches
+	MediaType  string // Optional: select the layer with this media type
+	httpClient *http.Client
+	authToken  string // Token obtained from the registry's auth challenge
+	debug      bool
+}
+
+// NewOCIRepository creates a new OCI registry repository
+func NewOCIRepository(registry, name, token string) *OCIRepository {
+	if !strings.Contains(registry, "://") {
+		registry = "https://" + registry
+	}
+	return &OCIRepository{
+		Registry:   strings.TrimSuffix(registry, "/"),
+		Name:       strings.Trim(name, "/"),
+		Token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetAssetName sets the layer title to download
+func (o *OCIRepository) SetAssetName(name string) {
+	o.AssetName = name
+}
+
+// SetMediaType sets the layer media type to download
+func (o *OCIRepository) SetMediaType(mediaType string) {
+	o.MediaType = mediaType
+}
+
+// SetDebug enables or disables debug logging
+func (o *OCIRepository) SetDebug(enabled bool) {
+	o.debug = enabled
+}
+
+// debugLog prints a debug message if debug mode is enabled
+func (o *OCIRepository) debugLog(format string, args ...interface{}) {
+	if o.debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
+	}
+}
+
+// ociDescriptor describes a blob in a manifest
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ociManifest represents an OCI image manifest
+type ociManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        ociDescriptor     `json:"config"`
+	Layers        []ociDescriptor   `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// ociTagList represents a tag listing response
+type ociTagList struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// do performs a GET request against the registry, answering a bearer
+// challenge once if the registry requires a token
+func (o *OCIRepository) do(rawURL, accept string) (*http.Response, error) {
+	resp, err := o.send(rawURL, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	// Registries that want a token answer 401 with a challenge describing where to get one
+	if resp.StatusCode == http.StatusUnauthorized && o.Token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+
+		if err := o.fetchAuthToken(challenge); err != nil {
+			return nil, err
+		}
+		return o.send(rawURL, accept)
+	}
+
+	return resp, nil
+}
+
+// send performs a single GET request with the current credentials
+func (o *OCIRepository) send(rawURL, accept string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "guppy-updater")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	token := o.Token
+	if token == "" {
+		token = o.authToken
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+		o.debugLog("Request header set: Authorization: Bearer")
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error contacting registry: %w", err)
+	}
+	return resp, nil
+}
+
+// challengeParamRegexp matches key="value" pairs in a WWW-Authenticate header
+var challengeParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// fetchAuthToken requests an anonymous pull token from the realm named in a bearer challenge
+func (o *OCIRepository) fetchAuthToken(challenge string) error {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return fmt.Errorf("registry requires authentication (challenge: %q)", challenge)
+	}
+
+	params := map[string]string{}
+	for _, match := range challengeParamRegexp.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+
+	realm := params["realm"]
+	if realm == "" {
+		return fmt.Errorf("registry auth challenge has no realm: %q", challenge)
+	}
+
+	query := url.Values{}
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", o.Name)
+	}
+	query.Set("scope", scope)
+
+	tokenURL := realm + "?" + query.Encode()
+	o.debugLog("Requesting registry token from: %s", tokenURL)
+
+	req, err := http.NewRequest("GET", tokenURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating token request: %w", err)
+	}
+	req.Header.Set("User-Agent", "guppy-updater")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error fetching registry token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("registry token endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return fmt.Errorf("error decoding registry token: %w", err)
+	}
+
+	o.authToken = tokenResp.Token
+	if o.authToken == "" {
+		o.authToken = tokenResp.AccessToken
+	}
+	if o.authToken == "" {
+		return fmt.Errorf("registry token endpoint returned no token")
+	}
+	return nil
+}
+
+// listTags returns every tag in the repository, following pagination
+func (o *OCIRepository) listTags() ([]string, error) {
+	var tags []string
+	nextURL := fmt.Sprintf("%s/v2/%s/tags/list", o.Registry, o.Name)
+
+	for nextURL != "" {
+		o.debugLog("Listing tags from URL: %s", nextURL)
+
+		resp, err := o.do(nextURL, "application/json")
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("registry returned status %d: %s", resp.StatusCode, string(body))
+		}
+
+		var list ociTagList
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding tag list: %w", err)
+		}
+
+		tags = append(tags, list.Tags...)
+		nextURL = parseNextLink(resp.Header, resp.Request.URL)
+	}
+
+	o.debugLog("Found %d tag(s)", len(tags))
+	return tags, nil
+}
+
+// GetLatestRelease returns the release for the highest semantic version tag
+// Tags that are not versions, such as "latest", are ignored
+func (o *OCIRepository) GetLatestRelease() (*Release, error) {
+	tags, err := o.listTags()
+	if err != nil {
+		return nil, err
+	}
+
+	latest := ""
+	for _, tag := range tags {
+		if _, err := version.Parse(tag); err != nil {
+			o.debugLog("Skipping tag %s: %v", tag, err)
+			continue
+		}
+		if latest == "" {
+			latest = tag
+			continue
+		}
+		if isNewer, _ := version.IsNewer(tag, latest); isNewer {
+			latest = tag
+		}
+	}
+
+	if latest == "" {
+		return nil, fmt.Errorf("no version tags found in %s", o.Name)
+	}
+
+	o.debugLog("Latest tag: %s", latest)
+	return o.GetRelease(latest)
+}
+
+// GetRelease resolves the manifest for a tag and returns the selected layer as a release
+func (o *OCIRepository) GetRelease(v string) (*Release, error) {
+	return findRelease(v, func(tag string) (*Release, error) {
+		manifest, err := o.fetchManifest(tag)
+		if err != nil {
+			return nil, err
+		}
+		return o.convertManifest(tag, manifest)
+	})
+}
+
+// ListReleases returns a release for every semantic version tag, newest first
+// Tags whose manifest has no matching layer are skipped
+func (o *OCIRepository) ListReleases() ([]*Release, error) {
+	tags, err := o.listTags()
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []*Release
+	for _, tag := range tags {
+		if _, err := version.Parse(tag); err != nil {
+			o.debugLog("Skipping tag %s: %v", tag, err)
+			continue
+		}
+
+		manifest, err := o.fetchManifest(tag)
+		if err != nil {
+			return nil, err
+		}
+		release, err := o.convertManifest(tag, manifest)
+		if err != nil {
+			o.debugLog("Skipping tag %s: %v", tag, err)
+			continue
+		}
+		releases = append(releases, release)
+	}
+
+	sortReleases(releases)
+	return releases, nil
+}
+
+// fetchManifest fetches and decodes the image manifest for a tag
+func (o *OCIRepository) fetchManifest(tag string) (*ociManifest, error) {
+	manifestURL := fmt.Sprintf("%s/v2/%s/manifests/%s", o.Registry, o.Name, url.PathEscape(tag))
+	o.debugLog("Fetching manifest from URL: %s", manifestURL)
+
+	resp, err := o.do(manifestURL, ociManifestMediaType)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errVersionNotFound(tag)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("registry returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var manifest ociManifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("error decoding manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// convertManifest selects a layer from the manifest and converts it to our Release type
+func (o *OCIRepository) convertManifest(tag string, manifest *ociManifest) (*Release, error) {
+	if len(manifest.Layers) == 0 {
+		return nil, fmt.Errorf("manifest for %s has no layers", tag)
+	}
+
+	o.debugLog("Manifest has %d layer(s)", len(manifest.Layers))
+
+	var layer *ociDescriptor
+	for i := range manifest.Layers {
+		candidate := &manifest.Layers[i]
+		if o.MediaType != "" && candidate.MediaType != o.MediaType {
+			continue
+		}
+		if o.AssetName != "" && candidate.Annotations[ociTitleAnnotation] != o.AssetName {
+			continue
+		}
+		layer = candidate
+		break
+	}
+	if layer == nil {
+		return nil, fmt.Errorf("no layer matching title %q and media type %q in %s", o.AssetName, o.MediaType, tag)
+	}
+
+	fileName := layer.Annotations[ociTitleAnnotation]
+	if fileName == "" {
+		// Fall back to the digest, which is at least unique and filesystem-safe
+		fileName = strings.ReplaceAll(layer.Digest, ":", "-")
+	}
+
+	var releaseDate time.Time
+	if created, ok := manifest.Annotations[ociCreatedAnnotation]; ok {
+		releaseDate, _ = time.Parse(time.RFC3339, created)
+	}
+
+	o.debugLog("Using layer: %s (%s, %s)", fileName, layer.MediaType, layer.Digest)
+
+	checksum, err := parseDigest(layer.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("layer %s: %w", fileName, err)
+	}
+	if checksum == "" {
+		o.debugLog("WARNING: Layer has no digest, no checksum available")
+	}
+
+	return &Release{
+		Version:     tag,
+		DownloadURL: fmt.Sprintf("%s/v2/%s/blobs/%s", o.Registry, o.Name, layer.Digest),
+		Checksum:    checksum,
+		ReleaseDate: releaseDate,
+		FileName:    filepath.Base(fileName),
+	}, nil
+}
+
+// CompareVersions compares current version with latest
+func (o *OCIRepository) CompareVersions(current, latest string) (bool, error) {
+	return version.IsNewer(latest, current)
+}
+
+// Download downloads a layer blob to the specified destination
+func (o *OCIRepository) Download(release *Release, dest string) error {
+	if release.DownloadURL == "" {
+		return fmt.Errorf("no download URL in release")
+	}
+
+	o.debugLog("Downloading blob from URL: %s to %s", release.DownloadURL, dest)
+
+	resp, err := o.do(release.DownloadURL, "")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+
+	// Create destination directory if it doesn't exist
+	destDir := filepath.Dir(dest)
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("error creating destination directory: %w", err)
+	}
+
+	// Create the destination file
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("error creating destination file: %w", err)
+	}
+	defer func() { _ = out.Close() }()
+
+	// Copy the content
+	_, err = io.Copy(out, resp.Body)
+	if err != nil {
+		return fmt.Errorf("error writing to destination: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,285 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRegistry is an httptest stand-in for an OCI registry serving team/myapp
+// Every /v2/ request requires a bearer token obtained from /token
+type fakeRegistry struct {
+	server    *httptest.Server
+	blobs     map[string]string      // digest -> content
+	manifests map[string]ociManifest // tag -> manifest
+	tags      []string
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	t.Helper()
+
+	reg := &fakeRegistry{
+		blobs:     map[string]string{},
+		manifests: map[string]ociManifest{},
+	}
+
+	reg.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:team/myapp:pull" {
+				t.Errorf("Unexpected token scope %q", r.URL.Query().Get("scope"))
+			}
+			_, _ = w.Write([]byte(`{"token":"pull-token"}`))
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry.test",scope="repository:team/myapp:pull"`, reg.server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/v2/team/myapp/tags/list":
+			// Serve two tags per page, paginating with a Link header like the distribution spec
+			start := 0
+			if last := r.URL.Query().Get("last"); last != "" {
+				for i, tag := range reg.tags {
+					if tag == last {
+						start = i + 1
+					}
+				}
+			}
+			end := start + 2
+			if end < len(reg.tags) {
+				w.Header().Set("Link", fmt.Sprintf(`</v2/team/myapp/tags/list?n=2&last=%s>; rel="next"`, url.QueryEscape(reg.tags[end-1])))
+			} else {
+				end = len(reg.tags)
+			}
+			_ = json.NewEncoder(w).Encode(ociTagList{Name: "team/myapp", Tags: reg.tags[start:end]})
+		case strings.HasPrefix(r.URL.Path, "/v2/team/myapp/manifests/"):
+			manifest, ok := reg.manifests[strings.TrimPrefix(r.URL.Path, "/v2/team/myapp/manifests/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", ociManifestMediaType)
+			_ = json.NewEncoder(w).Encode(manifest)
+		case strings.HasPrefix(r.URL.Path, "/v2/team/myapp/blobs/"):
+			content, ok := reg.blobs[strings.TrimPrefix(r.URL.Path, "/v2/team/myapp/blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(content))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return reg
+}
+
+// push stores an artifact with one layer per file under the given tag
+func (reg *fakeRegistry) push(tag string, files map[string]string, mediaType string) {
+	manifest := ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		Annotations:   map[string]string{ociCreatedAnnotation: "2025-04-01T10:00:00Z"},
+	}
+	for name, content := range files {
+		sum := sha256.Sum256([]byte(content))
+		digest := "sha256:" + hex.EncodeToString(sum[:])
+		reg.blobs[digest] = content
+		manifest.Layers = append(manifest.Layers, ociDescriptor{
+			MediaType:   mediaType,
+			Digest:      digest,
+			Size:        int64(len(content)),
+			Annotations: map[string]string{ociTitleAnnotation: name},
+		})
+	}
+	reg.manifests[tag] = manifest
+	reg.tags = append(reg.tags, tag)
+}
+
+func TestOCIRepository_GetLatestRelease(t *testing.T) {
+	reg := newFakeRegistry(t)
+	defer reg.server.Close()
+
+	reg.push("1.0.0", map[string]string{"myapp": "one"}, "application/vnd.myapp.binary")
+	reg.push("latest", map[string]string{"myapp": "ten"}, "application/vnd.myapp.binary")
+	reg.push("1.2.0", map[string]string{"myapp": "two"}, "application/vnd.myapp.binary")
+	reg.push("1.10.0", map[string]string{"myapp": "ten"}, "application/vnd.myapp.binary")
+	reg.push("1.9.0", map[string]string{"myapp": "nine"}, "application/vnd.myapp.binary")
+
+	repo := NewOCIRepository(reg.server.URL, "team/myapp", "")
+
+	release, err := repo.GetLatestRelease()
+	if err != nil {
+		t.Fatalf("GetLatestRelease() unexpected error: %v", err)
+	}
+
+	if release.Version != "1.10.0" {
+		t.Errorf("GetLatestRelease() version = %q, want 1.10.0", release.Version)
+	}
+	if release.FileName != "myapp" {
+		t.Errorf("GetLatestRelease() fileName = %q, want myapp", release.FileName)
+	}
+	if release.Checksum != "sha256:"+sha256Hex("ten") {
+		t.Errorf("GetLatestRelease() checksum = %q, want blob digest %q", release.Checksum, sha256Hex("ten"))
+	}
+	if release.ReleaseDate.IsZero() {
+		t.Error("GetLatestRelease() releaseDate should come from the created annotation")
+	}
+
+	dest := filepath.Join(t.TempDir(), release.FileName)
+	if err := repo.Download(release, dest); err != nil {
+		t.Fatalf("Download() unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+	if string(content) != "ten" {
+		t.Errorf("Downloaded content = %q, want ten", string(content))
+	}
+}
+
+func TestOCIRepository_LayerSelection(t *testing.T) {
+	reg := newFakeRegistry(t)
+	defer reg.server.Close()
+
+	reg.push("2.0.0", map[string]string{
+		"myapp-linux-amd64":  "linux",
+		"myapp-darwin-arm64": "darwin",
+	}, "application/vnd.myapp.binary")
+	// Add a layer with a different media type and no title
+	manifest := reg.manifests["2.0.0"]
+	manifest.Layers = append(manifest.Layers, ociDescriptor{
+		MediaType: "application/vnd.myapp.sbom+json",
+		Digest:    "sha256:" + sha256Hex("sbom"),
+	})
+	reg.manifests["2.0.0"] = manifest
+	reg.blobs["sha256:"+sha256Hex("sbom")] = "sbom"
+
+	tests := []struct {
+		name         string
+		assetName    string
+		mediaType    string
+		wantErr      bool
+		wantFileName string
+		wantContent  string
+	}{
+		{
+			name:         "by title annotation",
+			assetName:    "myapp-darwin-arm64",
+			wantFileName: "myapp-darwin-arm64",
+			wantContent:  "darwin",
+		},
+		{
+			name:         "by media type",
+			mediaType:    "application/vnd.myapp.sbom+json",
+			wantFileName: "sha256-" + sha256Hex("sbom"),
+			wantContent:  "sbom",
+		},
+		{
+			name:      "no matching layer",
+			assetName: "myapp-windows-amd64.exe",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewOCIRepository(reg.server.URL, "team/myapp", "")
+			repo.SetAssetName(tt.assetName)
+			repo.SetMediaType(tt.mediaType)
+
+			release, err := repo.GetRelease("2.0.0")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("GetRelease() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetRelease() unexpected error: %v", err)
+			}
+
+			if release.FileName != tt.wantFileName {
+				t.Errorf("GetRelease() fileName = %q, want %q", release.FileName, tt.wantFileName)
+			}
+
+			dest := filepath.Join(t.TempDir(), "artifact")
+			if err := repo.Download(release, dest); err != nil {
+				t.Fatalf("Download() unexpected error: %v", err)
+			}
+			content, _ := os.ReadFile(dest)
+			if string(content) != tt.wantContent {
+				t.Errorf("Downloaded content = %q, want %q", string(content), tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestOCIRepository_Errors(t *testing.T) {
+	reg := newFakeRegistry(t)
+	defer reg.server.Close()
+
+	repo := NewOCIRepository(reg.server.URL, "team/myapp", "")
+	if _, err := repo.GetLatestRelease(); err == nil {
+		t.Error("GetLatestRelease() expected error when there are no version tags, got nil")
+	}
+	if _, err := repo.GetRelease("9.9.9"); err == nil {
+		t.Error("GetRelease() expected error for missing tag, got nil")
+	}
+
+	// A static token is sent as-is and is not exchanged
+	staticRepo := NewOCIRepository(reg.server.URL, "team/myapp", "wrong-token")
+	if _, err := staticRepo.GetRelease("1.0.0"); err == nil {
+		t.Error("GetRelease() expected error for rejected static token, got nil")
+	}
+}
+
+func TestOCIRepository_ListReleases(t *testing.T) {
+	reg := newFakeRegistry(t)
+	defer reg.server.Close()
+
+	reg.push("1.0.0", map[string]string{"myapp": "one"}, "application/vnd.myapp.binary")
+	reg.push("latest", map[string]string{"myapp": "two"}, "application/vnd.myapp.binary")
+	reg.push("1.1.0", map[string]string{"other": "no match"}, "application/vnd.myapp.binary")
+	reg.push("1.2.0", map[string]string{"myapp": "two"}, "application/vnd.myapp.binary")
+
+	repo := NewOCIRepository(reg.server.URL, "team/myapp", "")
+	repo.SetAssetName("myapp")
+
+	releases, err := repo.ListReleases()
+	if err != nil {
+		t.Fatalf("ListReleases() unexpected error: %v", err)
+	}
+
+	// "latest" is not a version and 1.1.0 has no matching layer
+	if len(releases) != 2 || releases[0].Version != "1.2.0" || releases[1].Version != "1.0.0" {
+		t.Errorf("ListReleases() = %v, want 1.2.0 and 1.0.0", releases)
+	}
+	if releases[0].Checksum != "sha256:"+sha256Hex("two") {
+		t.Errorf("ListReleases()[0] checksum = %q, want blob digest", releases[0].Checksum)
+	}
+}
+
+func TestNewOCIRepository_DefaultScheme(t *testing.T) {
+	repo := NewOCIRepository("registry.example.com/", "/team/myapp/", "")
+	if repo.Registry != "https://registry.example.com" {
+		t.Errorf("Registry = %q, want https://registry.example.com", repo.Registry)
+	}
+	if repo.Name != "team/myapp" {
+		t.Errorf("Name = %q, want team/myapp", repo.Name)
+	}
+}
@@ -0,0 +1,378 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jaredhaight/guppy/pkg/checksum"
+	"github.com/jaredhaight/guppy/pkg/version"
+)
+
+// VersionPlaceholder marks where the version appears in an S3 key pattern
+const VersionPlaceholder = "{version}"
+
+// S3Repository implements Repository for S3-compatible object storage
+// Releases are discovered by listing objects whose keys match KeyPattern,
+// e.g. "myapp/{version}/myapp-linux-amd64"
+type S3Repository struct {
+	Endpoint        string // e.g. https://s3.us-east-1.amazonaws.com or https://minio.example.com
+	Bucket          string
+	Region          string
+	KeyPattern      string
+	AccessKeyID     string // Optional: anonymous requests are made when empty
+	SecretAccessKey string
+	SessionToken    string // Optional: for temporary credentials
+	httpClient      *http.Client
+	debug           bool
+}
+
+// NewS3Repository creates a new S3 repository
+// If endpoint is empty, the AWS endpoint for the region is used
+// If region is empty, us-east-1 is used
+func NewS3Repository(endpoint, bucket, region, keyPattern string) *S3Repository {
+	if region == "" {
+		region = "us-east-1"
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	return &S3Repository{
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+		Bucket:     bucket,
+		Region:     region,
+		KeyPattern: strings.TrimPrefix(keyPattern, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetCredentials sets the access key used to sign requests
+func (s *S3Repository) SetCredentials(accessKeyID, secretAccessKey, sessionToken string) {
+	s.AccessKeyID = accessKeyID
+	s.SecretAccessKey = secretAccessKey
+	s.SessionToken = sessionToken
+}
+
+// SetDebug enables or disables debug logging
+func (s *S3Repository) SetDebug(enabled bool) {
+	s.debug = enabled
+}
+
+// debugLog prints a debug message if debug mode is enabled
+func (s *S3Repository) debugLog(format string, args ...interface{}) {
+	if s.debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
+	}
+}
+
+// s3ListBucketResult represents a ListObjectsV2 response
+type s3ListBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+// objectURL returns the path-style URL of an object
+func (s *S3Repository) objectURL(key string) string {
+	return fmt.Sprintf("%s/%s/%s", s.Endpoint, s.Bucket, s3URIEncode(key, false))
+}
+
+// keyForVersion returns the object key for the given version
+func (s *S3Repository) keyForVersion(v string) string {
+	return strings.Replace(s.KeyPattern, VersionPlaceholder, v, 1)
+}
+
+// keyRegexp returns a regular expression that matches KeyPattern and captures the version
+func (s *S3Repository) keyRegexp() (*regexp.Regexp, error) {
+	parts := strings.SplitN(s.KeyPattern, VersionPlaceholder, 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("key pattern %q must contain %s", s.KeyPattern, VersionPlaceholder)
+	}
+	return regexp.Compile("^" + regexp.QuoteMeta(parts[0]) + "([^/]+)" + regexp.QuoteMeta(parts[1]) + "$")
+}
+
+// newRequest creates a request, signing it when credentials are configured
+func (s *S3Repository) newRequest(method, rawURL string, header http.Header) (*http.Request, error) {
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "guppy-updater")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	if s.AccessKeyID != "" {
+		signV4(req, s.AccessKeyID, s.SecretAccessKey, s.SessionToken, s.Region, time.Now())
+		s.debugLog("Signed request with access key %s", s.AccessKeyID)
+	}
+
+	return req, nil
+}
+
+// listReleases lists every object matching the key pattern and returns them as releases
+func (s *S3Repository) listReleases() ([]*Release, error) {
+	re, err := s.keyRegexp()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only list below the fixed part of the pattern
+	prefix := strings.SplitN(s.KeyPattern, VersionPlaceholder, 2)[0]
+
+	var releases []*Release
+	continuationToken := ""
+	for {
+		query := "list-type=2&prefix=" + s3URIEncode(prefix, true)
+		if continuationToken != "" {
+			query += "&continuation-token=" + s3URIEncode(continuationToken, true)
+		}
+		listURL := fmt.Sprintf("%s/%s?%s", s.Endpoint, s.Bucket, query)
+		s.debugLog("Listing objects from URL: %s", listURL)
+
+		req, err := s.newRequest("GET", listURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %w", err)
+		}
+
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("error listing objects: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("S3 returned status %d: %s", resp.StatusCode, string(body))
+		}
+
+		var result s3ListBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding list response: %w", err)
+		}
+
+		for _, obj := range result.Contents {
+			match := re.FindStringSubmatch(obj.Key)
+			if match == nil {
+				s.debugLog("Skipping object %s: does not match key pattern", obj.Key)
+				continue
+			}
+			releases = append(releases, &Release{
+				Version:     match[1],
+				DownloadURL: s.objectURL(obj.Key),
+				ReleaseDate: obj.LastModified,
+				FileName:    path.Base(obj.Key),
+				Signatures:  adjacentSignatures(s.objectURL(obj.Key)),
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		continuationToken = result.NextContinuationToken
+	}
+
+	s.debugLog("Found %d release(s)", len(releases))
+	return releases, nil
+}
+
+// GetLatestRelease returns the release with the highest version under the key pattern
+func (s *S3Repository) GetLatestRelease() (*Release, error) {
+	releases, err := s.listReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *Release
+	for _, rel := range releases {
+		if latest == nil {
+			latest = rel
+			continue
+		}
+
+		isNewer, err := version.IsNewer(rel.Version, latest.Version)
+		if err != nil {
+			s.debugLog("Error comparing versions %s and %s: %v", rel.Version, latest.Version, err)
+			continue
+		}
+		if isNewer {
+			latest = rel
+		}
+	}
+
+	if latest == nil {
+		return nil, fmt.Errorf("no releases found matching %s", s.KeyPattern)
+	}
+
+	s.debugLog("Latest release: %s", latest.Version)
+	if err := s.fillChecksum(latest); err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
+
+// GetRelease returns a specific release by version
+func (s *S3Repository) GetRelease(v string) (*Release, error) {
+	if _, err := s.keyRegexp(); err != nil {
+		return nil, err
+	}
+
+	return findRelease(v, func(tag string) (*Release, error) {
+		key := s.keyForVersion(tag)
+		s.debugLog("Looking for release version %s at key %s", tag, key)
+
+		release := &Release{
+			Version:     tag,
+			DownloadURL: s.objectURL(key),
+			FileName:    path.Base(key),
+			Signatures:  adjacentSignatures(s.objectURL(key)),
+		}
+		if err := s.fillChecksum(release); err != nil {
+			return nil, err
+		}
+		return release, nil
+	})
+}
+
+// fillChecksum issues a HEAD request for the release object and records its
+// SHA256 checksum and modification time when S3 reports them
+func (s *S3Repository) fillChecksum(release *Release) error {
+	header := http.Header{}
+	header.Set("X-Amz-Checksum-Mode", "ENABLED")
+	req, err := s.newRequest("HEAD", release.DownloadURL, header)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error fetching object metadata: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errVersionNotFound(release.Version)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("S3 returned status %d for %s", resp.StatusCode, release.DownloadURL)
+	}
+
+	if release.ReleaseDate.IsZero() {
+		if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+			release.ReleaseDate = modified
+		}
+	}
+
+	release.Checksum = parseS3Checksum(resp.Header)
+	if release.Checksum != "" {
+		s.debugLog("Object checksum: %s", release.Checksum)
+	} else {
+		s.debugLog("WARNING: No checksum available for %s", release.FileName)
+	}
+
+	return nil
+}
+
+// parseS3Checksum extracts a SHA256 checksum, in "sha256:hex" form, from object metadata
+// The native x-amz-checksum-sha256 header (base64) is preferred, falling back to
+// a user-defined x-amz-meta-sha256 header (hex). Composite multipart checksums
+// cover the parts rather than the object, so they are ignored
+func parseS3Checksum(header http.Header) string {
+	if native := header.Get("X-Amz-Checksum-Sha256"); native != "" && !strings.Contains(native, "-") {
+		if raw, err := base64.StdEncoding.DecodeString(native); err == nil && len(raw) == 32 {
+			return checksum.SHA256 + ":" + hex.EncodeToString(raw)
+		}
+	}
+
+	if meta := strings.ToLower(strings.TrimSpace(header.Get("X-Amz-Meta-Sha256"))); len(meta) == 64 {
+		if _, err := hex.DecodeString(meta); err == nil {
+			return checksum.SHA256 + ":" + meta
+		}
+	}
+
+	return ""
+}
+
+// ListReleases returns every object matching the key pattern, newest first
+// Each object's checksum is looked up with a HEAD request
+func (s *S3Repository) ListReleases() ([]*Release, error) {
+	releases, err := s.listReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, release := range releases {
+		if err := s.fillChecksum(release); err != nil {
+			return nil, err
+		}
+	}
+
+	sortReleases(releases)
+	return releases, nil
+}
+
+// CompareVersions compares current version with latest
+func (s *S3Repository) CompareVersions(current, latest string) (bool, error) {
+	return version.IsNewer(latest, current)
+}
+
+// Download downloads a release to the specified destination
+func (s *S3Repository) Download(release *Release, dest string) error {
+	if release.DownloadURL == "" {
+		return fmt.Errorf("no download URL in release")
+	}
+
+	s.debugLog("Downloading from URL: %s to %s", release.DownloadURL, dest)
+
+	req, err := s.newRequest("GET", release.DownloadURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating download request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error downloading file: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+
+	// Create destination directory if it doesn't exist
+	destDir := filepath.Dir(dest)
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("error creating destination directory: %w", err)
+	}
+
+	// Create the destination file
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("error creating destination file: %w", err)
+	}
+	defer func() { _ = out.Close() }()
+
+	// Copy the content
+	_, err = io.Copy(out, resp.Body)
+	if err != nil {
+		return fmt.Errorf("error writing to destination: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"github.com/jaredhaight/guppy/pkg/signature"
+)
+
+// assetSignatures returns the URLs of the signatures of fileName among a
+// release's assets, given by name, by scheme
+// Only signatures named after the file with a signature extension appended,
+// e.g. myapp.tar.gz.minisig, are returned.
+func assetSignatures(fileName string, assets map[string]string) map[string][]string {
+	var urls map[string][]string
+	for _, scheme := range signature.AllSchemes {
+		for _, ext := range signature.SchemeExtensions(scheme) {
+			u, ok := assets[fileName+ext]
+			if !ok {
+				continue
+			}
+			if urls == nil {
+				urls = make(map[string][]string)
+			}
+			urls[scheme] = append(urls[scheme], u)
+		}
+	}
+	return urls
+}
+
+// adjacentSignatures returns the URLs a signature of each scheme would be
+// published at next to a download, e.g. myapp.tar.gz.minisig
+// It is only for providers whose download URLs name the file itself.
+func adjacentSignatures(downloadURL string) map[string][]string {
+	urls := make(map[string][]string)
+	for _, scheme := range signature.AllSchemes {
+		for _, ext := range signature.SchemeExtensions(scheme) {
+			urls[scheme] = append(urls[scheme], downloadURL+ext)
+		}
+	}
+	return urls
+}
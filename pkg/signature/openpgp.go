@@ -0,0 +1,93 @@
+package signature
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// PGPExtensions are the suffixes of detached OpenPGP signature files, in the order they are tried
+var PGPExtensions = []string{".asc", ".sig"}
+
+// PGPVerifier checks detached OpenPGP signatures against a keyring
+// Any key in the keyring is trusted, so a new key can be added alongside the
+// old one while releases move over to it
+type PGPVerifier struct {
+	Keyring openpgp.EntityList
+}
+
+// LoadKeyring reads an ASCII-armored keyring, as exported by
+// gpg --armor --export, and returns a verifier trusting its keys
+func LoadKeyring(path string) (*PGPVerifier, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening keyring: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	keyring, err := openpgp.ReadArmoredKeyRing(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading keyring %s: %w", path, err)
+	}
+	return &PGPVerifier{Keyring: keyring}, nil
+}
+
+// VerifyFile checks the OpenPGP signature in sigPath over filePath
+// The signature may be ASCII-armored (.asc) or binary (.sig). It returns the
+// fingerprint of the key that made the signature, which is a subkey's when the
+// release was signed with one, or an error if no key in the keyring did.
+func (v *PGPVerifier) VerifyFile(filePath string, sigPath string) (string, error) {
+	sigFile, err := os.Open(sigPath)
+	if err != nil {
+		return "", fmt.Errorf("error opening signature: %w", err)
+	}
+	sigData, err := io.ReadAll(io.LimitReader(sigFile, maxSignatureSize))
+	_ = sigFile.Close()
+	if err != nil {
+		return "", fmt.Errorf("error reading signature: %w", err)
+	}
+
+	var sigReader io.Reader = bytes.NewReader(sigData)
+	if bytes.HasPrefix(bytes.TrimSpace(sigData), []byte("-----BEGIN")) {
+		block, err := armor.Decode(sigReader)
+		if err != nil {
+			return "", fmt.Errorf("invalid OpenPGP signature: %w", err)
+		}
+		if block.Type != openpgp.SignatureType {
+			return "", fmt.Errorf("invalid OpenPGP signature: unexpected armor type %q", block.Type)
+		}
+		sigReader = block.Body
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("error opening file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	sig, signer, err := openpgp.VerifyDetachedSignature(v.Keyring, file, sigReader, nil)
+	if err != nil {
+		return "", fmt.Errorf("OpenPGP signature verification failed: %w", err)
+	}
+	return signingFingerprint(sig, signer), nil
+}
+
+// signingFingerprint returns the fingerprint of the key that made sig
+func signingFingerprint(sig *packet.Signature, signer *openpgp.Entity) string {
+	if sig.IssuerFingerprint != nil {
+		return fmt.Sprintf("%X", sig.IssuerFingerprint)
+	}
+	if sig.IssuerKeyId != nil {
+		for _, subkey := range signer.Subkeys {
+			if subkey.PublicKey.KeyId == *sig.IssuerKeyId {
+				return fmt.Sprintf("%X", subkey.PublicKey.Fingerprint)
+			}
+		}
+	}
+	return fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint)
+}
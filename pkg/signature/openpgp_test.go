@@ -0,0 +1,131 @@
+package signature
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// newPGPEntity returns a new ed25519 OpenPGP key
+func newPGPEntity(t *testing.T, name string) *openpgp.Entity {
+	t.Helper()
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	if err != nil {
+		t.Fatalf("NewEntity() failed: %v", err)
+	}
+	return entity
+}
+
+// writeKeyring writes the public keys of entities as an armored keyring
+func writeKeyring(t *testing.T, dir string, entities ...*openpgp.Entity) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("armor.Encode() failed: %v", err)
+	}
+	for _, entity := range entities {
+		if err := entity.Serialize(w); err != nil {
+			t.Fatalf("Serialize() failed: %v", err)
+		}
+	}
+	_ = w.Close()
+	return writeFile(t, dir, "keyring.asc", buf.Bytes())
+}
+
+// signingFingerprintOf returns the fingerprint of the key entity signs with
+func signingFingerprintOf(t *testing.T, entity *openpgp.Entity) string {
+	t.Helper()
+	key, ok := entity.SigningKey(time.Now())
+	if !ok {
+		t.Fatal("SigningKey() found no signing key")
+	}
+	return fmt.Sprintf("%X", key.PublicKey.Fingerprint)
+}
+
+func TestPGPVerifier_VerifyFile(t *testing.T) {
+	data := []byte("release contents")
+	signer := newPGPEntity(t, "release")
+	rotated := newPGPEntity(t, "rotated")
+	untrusted := newPGPEntity(t, "untrusted")
+
+	armored := func(entity *openpgp.Entity, signed []byte) []byte {
+		var buf bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&buf, entity, bytes.NewReader(signed), nil); err != nil {
+			t.Fatalf("ArmoredDetachSign() failed: %v", err)
+		}
+		return buf.Bytes()
+	}
+	binary := func(entity *openpgp.Entity, signed []byte) []byte {
+		var buf bytes.Buffer
+		if err := openpgp.DetachSign(&buf, entity, bytes.NewReader(signed), nil); err != nil {
+			t.Fatalf("DetachSign() failed: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name      string
+		keyring   []*openpgp.Entity
+		signature []byte
+		want      *openpgp.Entity
+		wantErr   bool
+	}{
+		{name: "armored signature", keyring: []*openpgp.Entity{signer}, signature: armored(signer, data), want: signer},
+		{name: "binary signature", keyring: []*openpgp.Entity{signer}, signature: binary(signer, data), want: signer},
+		{name: "second key in keyring", keyring: []*openpgp.Entity{signer, rotated}, signature: armored(rotated, data), want: rotated},
+		{name: "key not in keyring", keyring: []*openpgp.Entity{signer, rotated}, signature: armored(untrusted, data), wantErr: true},
+		{name: "signature over other data", keyring: []*openpgp.Entity{signer}, signature: armored(signer, []byte("tampered")), wantErr: true},
+		{name: "not a signature", keyring: []*openpgp.Entity{signer}, signature: []byte("-----BEGIN PGP SIGNATURE-----\nbogus\n"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filePath := writeFile(t, dir, "myapp", data)
+			sigPath := writeFile(t, dir, "myapp.asc", tt.signature)
+
+			verifier, err := LoadKeyring(writeKeyring(t, dir, tt.keyring...))
+			if err != nil {
+				t.Fatalf("LoadKeyring() unexpected error: %v", err)
+			}
+
+			fingerprint, err := verifier.VerifyFile(filePath, sigPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("VerifyFile() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyFile() unexpected error: %v", err)
+			}
+			if want := signingFingerprintOf(t, tt.want); fingerprint != want {
+				t.Errorf("VerifyFile() fingerprint = %s, want %s", fingerprint, want)
+			}
+		})
+	}
+}
+
+func TestLoadKeyring_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := LoadKeyring(filepath.Join(dir, "missing.asc")); err == nil {
+		t.Error("LoadKeyring() expected error for missing file, got nil")
+	}
+
+	path := filepath.Join(dir, "bogus.asc")
+	if err := os.WriteFile(path, []byte("not a keyring"), 0644); err != nil {
+		t.Fatalf("Failed to write keyring: %v", err)
+	}
+	if _, err := LoadKeyring(path); err == nil {
+		t.Error("LoadKeyring() expected error for invalid keyring, got nil")
+	}
+}
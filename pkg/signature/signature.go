@@ -0,0 +1,272 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Extensions are the suffixes of detached minisign and ed25519 signature files, in the order they are tried
+var Extensions = []string{".minisig", ".sig"}
+
+// maxSignatureSize limits how much of a signature file is read
+const maxSignatureSize = 64 << 10
+
+// Minisign signature algorithms: "Ed" signs the file itself, "ED" signs its
+// BLAKE2b-512 hash and is what minisign produces by default
+var (
+	algorithmEd       = [2]byte{'E', 'd'}
+	algorithmHashedEd = [2]byte{'E', 'D'}
+)
+
+// trustedCommentPrefix starts the trusted comment line of a minisign signature
+const trustedCommentPrefix = "trusted comment: "
+
+// Signature schemes, which releases list their signature files under
+const (
+	SchemeMinisign = "minisign" // minisign or raw ed25519 signatures, published with Extensions
+	SchemeOpenPGP  = "openpgp"  // OpenPGP signatures, published with PGPExtensions
+	SchemeSigstore = "sigstore" // Sigstore bundles, published with SigstoreExtensions
+)
+
+// AllSchemes lists every signature scheme
+var AllSchemes = []string{SchemeMinisign, SchemeOpenPGP, SchemeSigstore}
+
+// SchemeExtensions returns the extensions a scheme's signatures are published
+// with, in the order they are tried
+func SchemeExtensions(scheme string) []string {
+	switch scheme {
+	case SchemeMinisign:
+		return Extensions
+	case SchemeOpenPGP:
+		return PGPExtensions
+	case SchemeSigstore:
+		return SigstoreExtensions
+	default:
+		return nil
+	}
+}
+
+// Schemes returns the schemes whose signatures a file name may hold, going by
+// its extension, or nil if it has none
+// A ".sig" file may hold a minisign, ed25519 or OpenPGP signature; which one
+// it is read as depends on the kind of key that verifies it.
+func Schemes(name string) []string {
+	var schemes []string
+	for _, scheme := range AllSchemes {
+		for _, ext := range SchemeExtensions(scheme) {
+			if strings.HasSuffix(strings.ToLower(name), ext) {
+				schemes = append(schemes, scheme)
+				break
+			}
+		}
+	}
+	return schemes
+}
+
+// IsSignatureFile reports whether a file name looks like a detached minisign,
+// ed25519 or OpenPGP signature, or a Sigstore bundle
+func IsSignatureFile(name string) bool {
+	return len(Schemes(name)) > 0
+}
+
+// PublicKey is a trusted ed25519 public key
+type PublicKey struct {
+	Key   ed25519.PublicKey
+	KeyID []byte // Minisign key ID, or nil for a raw ed25519 key
+}
+
+// ID returns a short name for the key: the minisign key ID as minisign prints
+// it, or the start of the key in hex for a raw ed25519 key
+func (k *PublicKey) ID() string {
+	if k.KeyID != nil {
+		return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(k.KeyID))
+	}
+	return hex.EncodeToString(k.Key[:8])
+}
+
+// ParsePublicKey parses a public key, accepting:
+//   - a minisign public key, with or without its "untrusted comment" line
+//   - a raw ed25519 public key, base64 or hex encoded
+func ParsePublicKey(s string) (*PublicKey, error) {
+	s = strings.TrimSpace(s)
+	if lines := strings.Split(s, "\n"); len(lines) == 2 && strings.HasPrefix(lines[0], "untrusted comment:") {
+		s = strings.TrimSpace(lines[1])
+	}
+	if s == "" {
+		return nil, fmt.Errorf("empty public key")
+	}
+
+	if raw, err := hex.DecodeString(s); err == nil && len(raw) == ed25519.PublicKeySize {
+		return &PublicKey{Key: ed25519.PublicKey(raw)}, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: not base64 or hex")
+	}
+	switch len(raw) {
+	case ed25519.PublicKeySize:
+		return &PublicKey{Key: ed25519.PublicKey(raw)}, nil
+	case 2 + 8 + ed25519.PublicKeySize:
+		if !bytes.Equal(raw[:2], algorithmEd[:]) {
+			return nil, fmt.Errorf("unsupported minisign key algorithm %q", raw[:2])
+		}
+		return &PublicKey{Key: ed25519.PublicKey(raw[10:]), KeyID: raw[2:10]}, nil
+	default:
+		return nil, fmt.Errorf("invalid public key: unexpected length %d", len(raw))
+	}
+}
+
+// signature is a parsed detached signature
+type signature struct {
+	hashed          bool   // Signs the BLAKE2b-512 hash of the file
+	keyID           []byte // Minisign key ID, or nil for a raw signature
+	sig             []byte
+	trustedComment  string
+	globalSignature []byte
+}
+
+// parseSignature parses a minisign signature, or a raw 64-byte ed25519
+// signature given as binary or base64
+func parseSignature(data []byte) (*signature, error) {
+	if len(data) == ed25519.SignatureSize {
+		return &signature{sig: data}, nil
+	}
+
+	text := strings.TrimSpace(strings.ReplaceAll(string(data), "\r", ""))
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		raw, err := base64.StdEncoding.DecodeString(text)
+		if err != nil || len(raw) != ed25519.SignatureSize {
+			return nil, fmt.Errorf("invalid signature: not a minisign or ed25519 signature")
+		}
+		return &signature{sig: raw}, nil
+	}
+
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return nil, fmt.Errorf("invalid minisign signature: unexpected format")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid minisign signature: bad signature line")
+	}
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid minisign signature: bad global signature line")
+	}
+
+	s := &signature{
+		keyID:           raw[2:10],
+		sig:             raw[10:],
+		trustedComment:  strings.TrimPrefix(lines[2], trustedCommentPrefix),
+		globalSignature: global,
+	}
+	switch [2]byte(raw[:2]) {
+	case algorithmEd:
+	case algorithmHashedEd:
+		s.hashed = true
+	default:
+		return nil, fmt.Errorf("unsupported minisign signature algorithm %q", raw[:2])
+	}
+	return s, nil
+}
+
+// Verifier checks detached signatures against a set of trusted keys
+// Any one key is enough, so a new key can be trusted alongside the old one
+// while releases move over to it
+type Verifier struct {
+	Keys []*PublicKey
+}
+
+// NewVerifier parses each public key and returns a verifier trusting all of them
+func NewVerifier(keys []string) (*Verifier, error) {
+	v := &Verifier{}
+	for i, s := range keys {
+		key, err := ParsePublicKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("public key %d: %w", i+1, err)
+		}
+		v.Keys = append(v.Keys, key)
+	}
+	return v, nil
+}
+
+// VerifyFile checks the signature in sigPath over filePath
+// It returns the key that made the signature, or an error if no trusted key did
+func (v *Verifier) VerifyFile(filePath string, sigPath string) (*PublicKey, error) {
+	sigFile, err := os.Open(sigPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening signature: %w", err)
+	}
+	sigData, err := io.ReadAll(io.LimitReader(sigFile, maxSignatureSize))
+	_ = sigFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading signature: %w", err)
+	}
+
+	sig, err := parseSignature(sigData)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := signedMessage(filePath, sig.hashed)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range v.Keys {
+		// A minisign signature names the key that made it
+		if sig.keyID != nil && key.KeyID != nil && !bytes.Equal(sig.keyID, key.KeyID) {
+			continue
+		}
+		if !ed25519.Verify(key.Key, message, sig.sig) {
+			continue
+		}
+		// The global signature covers the trusted comment, so it cannot be altered
+		if sig.globalSignature != nil {
+			global := append(append([]byte{}, sig.sig...), sig.trustedComment...)
+			if !ed25519.Verify(key.Key, global, sig.globalSignature) {
+				return nil, fmt.Errorf("invalid signature: trusted comment does not match")
+			}
+		}
+		return key, nil
+	}
+
+	if sig.keyID != nil {
+		return nil, fmt.Errorf("signature verification failed: not signed by a trusted key (signed by key %016X)", binary.LittleEndian.Uint64(sig.keyID))
+	}
+	return nil, fmt.Errorf("signature verification failed: not signed by a trusted key")
+}
+
+// signedMessage returns the bytes a signature covers: the file itself, or its
+// BLAKE2b-512 hash for hashed minisign signatures
+func signedMessage(filePath string, hashed bool) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if !hashed {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+		return data, nil
+	}
+
+	hash, _ := blake2b.New512(nil)
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	return hash.Sum(nil), nil
+}
@@ -0,0 +1,252 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Signatures over "test" produced by minisign itself
+const (
+	minisignPublicKey = "untrusted comment: minisign public key E7620F1842B4E81F\nRWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3"
+	minisignLegacySig = "untrusted comment: signature from minisign secret key\nRWQf6LRCGA9i59SLOFxz6NxvASXDJeRtuZykwQepbDEGt87ig1BNpWaVWuNrm73YiIiJbq71Wi+dP9eKL8OC351vwIasSSbXxwA=\ntrusted comment: timestamp:1635442742\tfile:test\n0YteLgV960ia80vnA/fHbvkyjl/IoP/HNOCaZfrF0CdhAlp7ok+Tpkya+VpWPX5C/Is3q8a/kEDSY7fBmmgJCg==\n"
+	minisignHashedSig = "untrusted comment: signature from minisign secret key\nRUQf6LRCGA9i559r3g7V1qNyJDApGip8MfqcadIgT9CuhV3EMhHoN1mGTkUidF/z7SrlQgXdy8ofjb7bNJJylDOocrCo8KLzZwo=\ntrusted comment: timestamp:1635443258\tfile:test\thashed\n/cj37GK60vryibFn+ftOgbCvW9NKhKYgjVpFFQUcWPAnjO23wrvVDTt7cloNC06maoBli9q6qwZDXXoaxweICQ==\n"
+)
+
+// newKey returns an ed25519 key pair derived from seed
+func newKey(seed byte) (ed25519.PublicKey, ed25519.PrivateKey) {
+	private := ed25519.NewKeyFromSeed([]byte(strings.Repeat(string(rune(seed)), ed25519.SeedSize)))
+	return private.Public().(ed25519.PublicKey), private
+}
+
+// minisignKey encodes a public key in minisign format with the given key ID
+func minisignKey(public ed25519.PublicKey, keyID []byte) string {
+	raw := append(append([]byte("Ed"), keyID...), public...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(raw) + "\n"
+}
+
+// minisignSign produces a hashed minisign signature over data
+func minisignSign(private ed25519.PrivateKey, keyID []byte, data []byte, trustedComment string) string {
+	hash := blake2b.Sum512(data)
+	sig := ed25519.Sign(private, hash[:])
+	global := ed25519.Sign(private, append(append([]byte{}, sig...), trustedComment...))
+	raw := append(append([]byte("ED"), keyID...), sig...)
+	return "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(raw) + "\n" +
+		"trusted comment: " + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestParsePublicKey(t *testing.T) {
+	public, _ := newKey(1)
+
+	tests := []struct {
+		name    string
+		key     string
+		wantID  string
+		wantErr bool
+	}{
+		{name: "minisign with comment", key: minisignPublicKey, wantID: "E7620F1842B4E81F"},
+		{name: "minisign key line only", key: "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3", wantID: "E7620F1842B4E81F"},
+		{name: "raw base64", key: base64.StdEncoding.EncodeToString(public), wantID: hex.EncodeToString(public[:8])},
+		{name: "raw hex", key: hex.EncodeToString(public), wantID: hex.EncodeToString(public[:8])},
+		{name: "empty", key: "  ", wantErr: true},
+		{name: "not encoded", key: "not a key!", wantErr: true},
+		{name: "wrong length", key: base64.StdEncoding.EncodeToString(public[:16]), wantErr: true},
+		{name: "unsupported algorithm", key: base64.StdEncoding.EncodeToString(append(append([]byte("Xx"), make([]byte, 8)...), public...)), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParsePublicKey(tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("ParsePublicKey() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePublicKey() unexpected error: %v", err)
+			}
+			if key.ID() != tt.wantID {
+				t.Errorf("ID() = %s, want %s", key.ID(), tt.wantID)
+			}
+		})
+	}
+}
+
+func TestVerifier_VerifyFile(t *testing.T) {
+	data := []byte("release contents")
+	public, private := newKey(1)
+	otherPublic, otherPrivate := newKey(2)
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	otherKeyID := []byte{8, 7, 6, 5, 4, 3, 2, 1}
+
+	tampered := minisignSign(private, keyID, data, "timestamp:1\tfile:myapp")
+	tampered = strings.Replace(tampered, "file:myapp", "file:other", 1)
+
+	tests := []struct {
+		name      string
+		keys      []string
+		data      []byte
+		signature string
+		wantID    string
+		wantErr   bool
+	}{
+		{
+			name:      "minisign legacy signature",
+			keys:      []string{minisignPublicKey},
+			data:      []byte("test"),
+			signature: minisignLegacySig,
+			wantID:    "E7620F1842B4E81F",
+		},
+		{
+			name:      "minisign hashed signature",
+			keys:      []string{minisignPublicKey},
+			data:      []byte("test"),
+			signature: minisignHashedSig,
+			wantID:    "E7620F1842B4E81F",
+		},
+		{
+			name:      "minisign signature over other data",
+			keys:      []string{minisignPublicKey},
+			data:      []byte("tampered"),
+			signature: minisignHashedSig,
+			wantErr:   true,
+		},
+		{
+			name:      "rotated key",
+			keys:      []string{minisignKey(public, keyID), minisignKey(otherPublic, otherKeyID)},
+			data:      data,
+			signature: minisignSign(otherPrivate, otherKeyID, data, "timestamp:1"),
+			wantID:    "0102030405060708",
+		},
+		{
+			name:      "untrusted key",
+			keys:      []string{minisignKey(public, keyID)},
+			data:      data,
+			signature: minisignSign(otherPrivate, otherKeyID, data, "timestamp:1"),
+			wantErr:   true,
+		},
+		{
+			name:      "tampered trusted comment",
+			keys:      []string{minisignKey(public, keyID)},
+			data:      data,
+			signature: tampered,
+			wantErr:   true,
+		},
+		{
+			name:      "raw key with minisign signature",
+			keys:      []string{base64.StdEncoding.EncodeToString(public)},
+			data:      data,
+			signature: minisignSign(private, keyID, data, "timestamp:1"),
+			wantID:    hex.EncodeToString(public[:8]),
+		},
+		{
+			name:      "raw binary signature",
+			keys:      []string{base64.StdEncoding.EncodeToString(otherPublic), base64.StdEncoding.EncodeToString(public)},
+			data:      data,
+			signature: string(ed25519.Sign(private, data)),
+			wantID:    hex.EncodeToString(public[:8]),
+		},
+		{
+			name:      "raw base64 signature",
+			keys:      []string{base64.StdEncoding.EncodeToString(public)},
+			data:      data,
+			signature: base64.StdEncoding.EncodeToString(ed25519.Sign(private, data)) + "\n",
+			wantID:    hex.EncodeToString(public[:8]),
+		},
+		{
+			name:      "raw signature by untrusted key",
+			keys:      []string{base64.StdEncoding.EncodeToString(public)},
+			data:      data,
+			signature: string(ed25519.Sign(otherPrivate, data)),
+			wantErr:   true,
+		},
+		{
+			name:      "malformed signature",
+			keys:      []string{base64.StdEncoding.EncodeToString(public)},
+			data:      data,
+			signature: "not a signature",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filePath := writeFile(t, dir, "myapp", tt.data)
+			sigPath := writeFile(t, dir, "myapp.minisig", []byte(tt.signature))
+
+			verifier, err := NewVerifier(tt.keys)
+			if err != nil {
+				t.Fatalf("NewVerifier() unexpected error: %v", err)
+			}
+
+			key, err := verifier.VerifyFile(filePath, sigPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("VerifyFile() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyFile() unexpected error: %v", err)
+			}
+			if key.ID() != tt.wantID {
+				t.Errorf("VerifyFile() key = %s, want %s", key.ID(), tt.wantID)
+			}
+		})
+	}
+}
+
+func TestNewVerifier_InvalidKey(t *testing.T) {
+	if _, err := NewVerifier([]string{minisignPublicKey, "bogus"}); err == nil {
+		t.Error("NewVerifier() expected error, got nil")
+	}
+}
+
+func TestIsSignatureFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"myapp.tar.gz.minisig": true,
+		"myapp.SIG":            true,
+		"myapp.sigstore.json":  true,
+		"release.json":         false,
+		"myapp.tar.gz":         false,
+		"checksums.txt":        false,
+	} {
+		if got := IsSignatureFile(name); got != want {
+			t.Errorf("IsSignatureFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestSchemes(t *testing.T) {
+	for name, want := range map[string][]string{
+		"myapp.tar.gz.minisig":       {SchemeMinisign},
+		"myapp.tar.gz.asc":           {SchemeOpenPGP},
+		"myapp.tar.gz.sig":           {SchemeMinisign, SchemeOpenPGP},
+		"myapp.tar.gz.sigstore.json": {SchemeSigstore},
+		"myapp.tar.gz":               nil,
+	} {
+		if got := Schemes(name); !slices.Equal(got, want) {
+			t.Errorf("Schemes(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,605 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha512" // Registers SHA-384 and SHA-512 for crypto.Hash
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SigstoreExtensions are the suffixes of Sigstore bundle files, as written by
+// cosign sign-blob --bundle
+var SigstoreExtensions = []string{".sigstore.json"}
+
+// maxBundleSize limits how much of a Sigstore bundle or trusted root is read
+const maxBundleSize = 1 << 20
+
+// Object identifiers used in Fulcio certificates
+var (
+	oidSubjectAltName    = asn1.ObjectIdentifier{2, 5, 29, 17}
+	oidIssuer            = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1} // Deprecated, holds the raw issuer
+	oidIssuerV2          = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8} // Holds the issuer as a UTF8String
+	oidOtherNameUsername = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 7}
+)
+
+// SigstoreVerifier checks Sigstore bundles made by keyless signing, such as
+// with cosign sign-blob, without contacting any Sigstore service
+// A bundle is accepted when its certificate chains to a certificate authority
+// in the trusted root, was logged by a transparency log in the trusted root,
+// names the expected identity and OIDC issuer, and signs the file.
+type SigstoreVerifier struct {
+	Identity       string         // Exact certificate identity, e.g. a workflow URL or email
+	IdentityRegexp *regexp.Regexp // Pattern the identity must match, if Identity is empty
+	Issuer         string         // OIDC issuer, e.g. https://token.actions.githubusercontent.com
+
+	authorities []certificateAuthority
+	logs        []transparencyLog
+}
+
+// certificateAuthority is a Fulcio instance from the trusted root
+type certificateAuthority struct {
+	root          *x509.Certificate
+	intermediates []*x509.Certificate
+	validFor      validity
+}
+
+// transparencyLog is a Rekor instance from the trusted root
+type transparencyLog struct {
+	id        []byte
+	publicKey crypto.PublicKey
+	validFor  validity
+}
+
+// validity is the period a trusted root entry may be used for
+type validity struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end"`
+}
+
+// contains reports whether t falls within the validity period
+func (v validity) contains(t time.Time) bool {
+	return !t.Before(v.Start) && (v.End == nil || !t.After(*v.End))
+}
+
+// rawBytes is a base64-encoded value in Sigstore's JSON formats
+type rawBytes struct {
+	RawBytes []byte `json:"rawBytes"`
+}
+
+// trustedRootJSON is the part of a Sigstore trusted_root.json guppy uses
+type trustedRootJSON struct {
+	Tlogs []struct {
+		PublicKey struct {
+			RawBytes []byte   `json:"rawBytes"`
+			ValidFor validity `json:"validFor"`
+		} `json:"publicKey"`
+		LogID struct {
+			KeyID []byte `json:"keyId"`
+		} `json:"logId"`
+	} `json:"tlogs"`
+	CertificateAuthorities []struct {
+		CertChain struct {
+			Certificates []rawBytes `json:"certificates"`
+		} `json:"certChain"`
+		ValidFor validity `json:"validFor"`
+	} `json:"certificateAuthorities"`
+}
+
+// int64String is an int64 that Sigstore's JSON formats write as a string
+type int64String int64
+
+// UnmarshalJSON accepts the value either quoted or as a number
+func (n *int64String) UnmarshalJSON(data []byte) error {
+	v, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	*n = int64String(v)
+	return nil
+}
+
+// bundleJSON is the part of a Sigstore bundle guppy uses
+type bundleJSON struct {
+	MediaType            string `json:"mediaType"`
+	VerificationMaterial struct {
+		Certificate          *rawBytes `json:"certificate"`
+		X509CertificateChain *struct {
+			Certificates []rawBytes `json:"certificates"`
+		} `json:"x509CertificateChain"`
+		TlogEntries []tlogEntryJSON `json:"tlogEntries"`
+	} `json:"verificationMaterial"`
+	MessageSignature *struct {
+		MessageDigest struct {
+			Algorithm string `json:"algorithm"`
+			Digest    []byte `json:"digest"`
+		} `json:"messageDigest"`
+		Signature []byte `json:"signature"`
+	} `json:"messageSignature"`
+	DSSEEnvelope json.RawMessage `json:"dsseEnvelope"`
+}
+
+// tlogEntryJSON is a transparency log entry in a Sigstore bundle
+type tlogEntryJSON struct {
+	LogIndex int64String `json:"logIndex"`
+	LogID    struct {
+		KeyID []byte `json:"keyId"`
+	} `json:"logId"`
+	KindVersion struct {
+		Kind    string `json:"kind"`
+		Version string `json:"version"`
+	} `json:"kindVersion"`
+	IntegratedTime   int64String `json:"integratedTime"`
+	InclusionPromise *struct {
+		SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+	} `json:"inclusionPromise"`
+	CanonicalizedBody []byte `json:"canonicalizedBody"`
+}
+
+// hashedRekordJSON is the body of a hashedrekord transparency log entry
+type hashedRekordJSON struct {
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content   []byte `json:"content"`
+			PublicKey struct {
+				Content []byte `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// NewSigstoreVerifier reads a Sigstore trusted root, as written by
+// cosign trusted-root create or fetched from Sigstore's TUF repository, and
+// returns a verifier for bundles signed by identity and issued by issuer
+// identityRegexp is used when identity is empty; one of them is required. It
+// must match the whole identity, as if it were wrapped in ^(?:...)$.
+func NewSigstoreVerifier(trustedRootPath, identity, identityRegexp, issuer string) (*SigstoreVerifier, error) {
+	if identity == "" && identityRegexp == "" {
+		return nil, fmt.Errorf("a certificate identity or identity regexp is required")
+	}
+	if issuer == "" {
+		return nil, fmt.Errorf("a certificate OIDC issuer is required")
+	}
+
+	v := &SigstoreVerifier{Identity: identity, Issuer: issuer}
+	if identity == "" {
+		re, err := regexp.Compile("^(?:" + identityRegexp + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate identity regexp: %w", err)
+		}
+		v.IdentityRegexp = re
+	}
+
+	data, err := readLimited(trustedRootPath, maxBundleSize)
+	if err != nil {
+		return nil, fmt.Errorf("error reading trusted root: %w", err)
+	}
+	var root trustedRootJSON
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("error decoding trusted root %s: %w", trustedRootPath, err)
+	}
+
+	for _, ca := range root.CertificateAuthorities {
+		certs, err := parseCertificates(ca.CertChain.Certificates)
+		if err != nil || len(certs) == 0 {
+			return nil, fmt.Errorf("invalid certificate authority in trusted root %s", trustedRootPath)
+		}
+		v.authorities = append(v.authorities, certificateAuthority{
+			root:          certs[len(certs)-1],
+			intermediates: certs[:len(certs)-1],
+			validFor:      ca.ValidFor,
+		})
+	}
+	for _, tlog := range root.Tlogs {
+		publicKey, err := x509.ParsePKIXPublicKey(tlog.PublicKey.RawBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transparency log key in trusted root %s: %w", trustedRootPath, err)
+		}
+		v.logs = append(v.logs, transparencyLog{
+			id:        tlog.LogID.KeyID,
+			publicKey: publicKey,
+			validFor:  tlog.PublicKey.ValidFor,
+		})
+	}
+
+	if len(v.authorities) == 0 || len(v.logs) == 0 {
+		return nil, fmt.Errorf("trusted root %s needs a certificate authority and a transparency log", trustedRootPath)
+	}
+	return v, nil
+}
+
+// VerifyFile checks the Sigstore bundle in bundlePath over filePath
+// It returns the identity in the signing certificate, or an error if the
+// bundle does not prove that the trusted identity signed the file.
+func (v *SigstoreVerifier) VerifyFile(filePath string, bundlePath string) (string, error) {
+	data, err := readLimited(bundlePath, maxBundleSize)
+	if err != nil {
+		return "", fmt.Errorf("error reading Sigstore bundle: %w", err)
+	}
+	var bundle bundleJSON
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return "", fmt.Errorf("invalid Sigstore bundle: %w", err)
+	}
+	if !strings.HasPrefix(bundle.MediaType, "application/vnd.dev.sigstore.bundle") {
+		return "", fmt.Errorf("invalid Sigstore bundle: unexpected media type %q", bundle.MediaType)
+	}
+	if bundle.MessageSignature == nil {
+		if bundle.DSSEEnvelope != nil {
+			return "", fmt.Errorf("unsupported Sigstore bundle: attestations (DSSE) are not supported, sign the file with cosign sign-blob")
+		}
+		return "", fmt.Errorf("invalid Sigstore bundle: no message signature")
+	}
+
+	hash, err := sigstoreHash(bundle.MessageSignature.MessageDigest.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	digest, err := fileDigest(filePath, hash)
+	if err != nil {
+		return "", err
+	}
+	return v.verifyBundle(&bundle, hash, digest)
+}
+
+// verifyBundle checks a parsed bundle over a file with the given digest
+func (v *SigstoreVerifier) verifyBundle(bundle *bundleJSON, hash crypto.Hash, digest []byte) (string, error) {
+	var chain []rawBytes
+	if bundle.VerificationMaterial.Certificate != nil {
+		chain = []rawBytes{*bundle.VerificationMaterial.Certificate}
+	} else if bundle.VerificationMaterial.X509CertificateChain != nil {
+		chain = bundle.VerificationMaterial.X509CertificateChain.Certificates
+	}
+	certs, err := parseCertificates(chain)
+	if err != nil {
+		return "", fmt.Errorf("invalid Sigstore bundle: %w", err)
+	}
+	if len(certs) == 0 {
+		return "", fmt.Errorf("unsupported Sigstore bundle: no signing certificate, only keyless signatures are supported")
+	}
+	leaf := certs[0]
+
+	if len(bundle.MessageSignature.MessageDigest.Digest) > 0 && !bytes.Equal(bundle.MessageSignature.MessageDigest.Digest, digest) {
+		return "", fmt.Errorf("Sigstore verification failed: bundle is for a different file")
+	}
+	signature := bundle.MessageSignature.Signature
+
+	// The transparency log entry proves when the signature was made, which
+	// must be while the short-lived certificate was valid
+	signedAt, err := v.verifyTlogEntries(bundle.VerificationMaterial.TlogEntries, leaf, signature, hash, hex.EncodeToString(digest))
+	if err != nil {
+		return "", err
+	}
+
+	if err := v.verifyChain(leaf, certs[1:], signedAt); err != nil {
+		return "", err
+	}
+
+	identity, err := v.verifyIdentity(leaf)
+	if err != nil {
+		return "", err
+	}
+
+	if err := verifyDigest(leaf.PublicKey, hash, digest, signature); err != nil {
+		return "", fmt.Errorf("Sigstore verification failed: %w", err)
+	}
+	return identity, nil
+}
+
+// verifyTlogEntries checks that a trusted transparency log recorded the
+// signature and returns the time it was logged
+// Entries are checked offline through their signed entry timestamp.
+func (v *SigstoreVerifier) verifyTlogEntries(entries []tlogEntryJSON, leaf *x509.Certificate, signature []byte, hash crypto.Hash, hexDigest string) (time.Time, error) {
+	var lastErr error = fmt.Errorf("bundle has no transparency log entry")
+	for _, entry := range entries {
+		if err := v.verifyTlogEntry(entry, leaf, signature, hash, hexDigest); err != nil {
+			lastErr = err
+			continue
+		}
+		return time.Unix(int64(entry.IntegratedTime), 0), nil
+	}
+	return time.Time{}, fmt.Errorf("Sigstore verification failed: %w", lastErr)
+}
+
+// verifyTlogEntry checks a single transparency log entry
+func (v *SigstoreVerifier) verifyTlogEntry(entry tlogEntryJSON, leaf *x509.Certificate, signature []byte, hash crypto.Hash, hexDigest string) error {
+	if entry.KindVersion.Kind != "hashedrekord" {
+		return fmt.Errorf("unsupported transparency log entry kind %q", entry.KindVersion.Kind)
+	}
+	if entry.InclusionPromise == nil {
+		return fmt.Errorf("transparency log entry has no signed entry timestamp")
+	}
+
+	integratedTime := time.Unix(int64(entry.IntegratedTime), 0)
+	var log *transparencyLog
+	for i := range v.logs {
+		if bytes.Equal(v.logs[i].id, entry.LogID.KeyID) && v.logs[i].validFor.contains(integratedTime) {
+			log = &v.logs[i]
+			break
+		}
+	}
+	if log == nil {
+		return fmt.Errorf("transparency log %s is not in the trusted root", hex.EncodeToString(entry.LogID.KeyID))
+	}
+
+	// The signed entry timestamp covers the canonical JSON of these fields,
+	// with keys in sorted order
+	payload, err := json.Marshal(struct {
+		Body           string `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogID          string `json:"logID"`
+		LogIndex       int64  `json:"logIndex"`
+	}{
+		Body:           base64.StdEncoding.EncodeToString(entry.CanonicalizedBody),
+		IntegratedTime: int64(entry.IntegratedTime),
+		LogID:          hex.EncodeToString(entry.LogID.KeyID),
+		LogIndex:       int64(entry.LogIndex),
+	})
+	if err != nil {
+		return err
+	}
+	if err := verifyMessage(log.publicKey, crypto.SHA256, payload, entry.InclusionPromise.SignedEntryTimestamp); err != nil {
+		return fmt.Errorf("invalid signed entry timestamp: %w", err)
+	}
+
+	// The logged entry must be for this signature, certificate and file
+	var body hashedRekordJSON
+	if err := json.Unmarshal(entry.CanonicalizedBody, &body); err != nil {
+		return fmt.Errorf("invalid transparency log entry: %w", err)
+	}
+	block, _ := pem.Decode(body.Spec.Signature.PublicKey.Content)
+	switch {
+	case block == nil || !bytes.Equal(block.Bytes, leaf.Raw):
+		return fmt.Errorf("transparency log entry is for a different certificate")
+	case !bytes.Equal(body.Spec.Signature.Content, signature):
+		return fmt.Errorf("transparency log entry is for a different signature")
+	case body.Spec.Data.Hash.Algorithm != rekordHashAlgorithm(hash):
+		return fmt.Errorf("transparency log entry records a %q digest, want %q", body.Spec.Data.Hash.Algorithm, rekordHashAlgorithm(hash))
+	case !strings.EqualFold(body.Spec.Data.Hash.Value, hexDigest):
+		return fmt.Errorf("transparency log entry is for a different file")
+	}
+	return nil
+}
+
+// verifyChain checks that the certificate was issued by a trusted
+// certificate authority and was valid at signedAt
+func (v *SigstoreVerifier) verifyChain(leaf *x509.Certificate, bundleIntermediates []*x509.Certificate, signedAt time.Time) error {
+	// A subject alternative name holding only a Fulcio username is critical
+	// but not understood by the standard library; it is checked by verifyIdentity
+	if len(otherNameIdentities(leaf)) > 0 {
+		leaf.UnhandledCriticalExtensions = slices.DeleteFunc(leaf.UnhandledCriticalExtensions, func(id asn1.ObjectIdentifier) bool {
+			return id.Equal(oidSubjectAltName)
+		})
+	}
+
+	for _, ca := range v.authorities {
+		if !ca.validFor.contains(signedAt) {
+			continue
+		}
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.root)
+		intermediates := x509.NewCertPool()
+		for _, cert := range append(ca.intermediates, bundleIntermediates...) {
+			intermediates.AddCert(cert)
+		}
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			CurrentTime:   signedAt,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		})
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("Sigstore verification failed: certificate was not issued by a trusted certificate authority at %s", signedAt.UTC().Format(time.RFC3339))
+}
+
+// verifyIdentity checks the certificate's OIDC issuer and identity, returning the identity
+func (v *SigstoreVerifier) verifyIdentity(leaf *x509.Certificate) (string, error) {
+	if issuer := certificateIssuer(leaf); issuer != v.Issuer {
+		return "", fmt.Errorf("Sigstore verification failed: certificate issuer %q does not match %q", issuer, v.Issuer)
+	}
+
+	identities := certificateIdentities(leaf)
+	for _, identity := range identities {
+		if v.Identity != "" && identity == v.Identity {
+			return identity, nil
+		}
+		if v.Identity == "" && v.IdentityRegexp.MatchString(identity) {
+			return identity, nil
+		}
+	}
+	want := v.Identity
+	if want == "" {
+		want = v.IdentityRegexp.String()
+	}
+	return "", fmt.Errorf("Sigstore verification failed: certificate identity %v does not match %q", identities, want)
+}
+
+// certificateIssuer returns the OIDC issuer Fulcio recorded in a certificate
+func certificateIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidIssuerV2) {
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err == nil {
+				return issuer
+			}
+		}
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidIssuer) {
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+// certificateIdentities returns the identities in a certificate's subject
+// alternative names: URIs such as CI workflow URLs, emails and Fulcio usernames
+func certificateIdentities(cert *x509.Certificate) []string {
+	var identities []string
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	identities = append(identities, cert.EmailAddresses...)
+	return append(identities, otherNameIdentities(cert)...)
+}
+
+// otherNameIdentities returns the Fulcio usernames in a certificate's subject
+// alternative names, which the standard library does not parse
+func otherNameIdentities(cert *x509.Certificate) []string {
+	var identities []string
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+		var names asn1.RawValue
+		if _, err := asn1.Unmarshal(ext.Value, &names); err != nil {
+			continue
+		}
+		for rest := names.Bytes; len(rest) > 0; {
+			var name asn1.RawValue
+			var err error
+			if rest, err = asn1.Unmarshal(rest, &name); err != nil {
+				break
+			}
+			// otherName is [0] IMPLICIT SEQUENCE { type-id OID, value [0] EXPLICIT ANY }
+			if name.Class != asn1.ClassContextSpecific || name.Tag != 0 {
+				continue
+			}
+			var otherName struct {
+				TypeID asn1.ObjectIdentifier
+				Value  string `asn1:"tag:0,explicit,utf8"`
+			}
+			if _, err := asn1.UnmarshalWithParams(name.FullBytes, &otherName, "tag:0"); err == nil && otherName.TypeID.Equal(oidOtherNameUsername) {
+				identities = append(identities, otherName.Value)
+			}
+		}
+	}
+	return identities
+}
+
+// rekordHashAlgorithm returns the name a hashedrekord entry gives a hash
+func rekordHashAlgorithm(hash crypto.Hash) string {
+	switch hash {
+	case crypto.SHA256:
+		return "sha256"
+	case crypto.SHA384:
+		return "sha384"
+	case crypto.SHA512:
+		return "sha512"
+	}
+	return ""
+}
+
+// sigstoreHash returns the hash named by a Sigstore message digest algorithm
+func sigstoreHash(algorithm string) (crypto.Hash, error) {
+	switch algorithm {
+	case "SHA2_256", "":
+		return crypto.SHA256, nil
+	case "SHA2_384":
+		return crypto.SHA384, nil
+	case "SHA2_512":
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported Sigstore message digest algorithm %q", algorithm)
+}
+
+// fileDigest returns the digest of a file
+func fileDigest(filePath string, hash crypto.Hash) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	h := hash.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	return h.Sum(nil), nil
+}
+
+// verifyMessage checks a signature over a message
+func verifyMessage(publicKey crypto.PublicKey, hash crypto.Hash, message, signature []byte) error {
+	if key, ok := publicKey.(ed25519.PublicKey); ok {
+		if !ed25519.Verify(key, message, signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+	h := hash.New()
+	h.Write(message)
+	return verifyDigest(publicKey, hash, h.Sum(nil), signature)
+}
+
+// verifyDigest checks a signature over a message digest
+func verifyDigest(publicKey crypto.PublicKey, hash crypto.Hash, digest, signature []byte) error {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, signature) {
+			return fmt.Errorf("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+			if rsa.VerifyPSS(key, hash, digest, signature, nil) != nil {
+				return fmt.Errorf("invalid signature")
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	return nil
+}
+
+// parseCertificates parses DER certificates
+func parseCertificates(raw []rawBytes) ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, 0, len(raw))
+	for _, r := range raw {
+		cert, err := x509.ParseCertificate(r.RawBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// readLimited reads a file, failing if it is larger than limit
+func readLimited(path string, limit int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s is larger than %d bytes", path, limit)
+	}
+	return data, nil
+}
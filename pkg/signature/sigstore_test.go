@@ -0,0 +1,309 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIdentity = "https://github.com/example/myapp/.github/workflows/release.yml@refs/tags/v1.0.0"
+	testIssuer   = "https://token.actions.githubusercontent.com"
+)
+
+// sigstoreFixture is a certificate authority and transparency log standing in for
+// Fulcio and Rekor, with a trusted root naming them
+type sigstoreFixture struct {
+	t        *testing.T
+	caKey    *ecdsa.PrivateKey
+	caCert   *x509.Certificate
+	logKey   *ecdsa.PrivateKey
+	logID    []byte
+	signedAt time.Time
+}
+
+// newSigstoreFixture creates a certificate authority and transparency log
+func newSigstoreFixture(t *testing.T) *sigstoreFixture {
+	t.Helper()
+	f := &sigstoreFixture{t: t, signedAt: time.Now().Add(-time.Hour).Truncate(time.Second)}
+
+	f.caKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-fulcio"},
+		NotBefore:             f.signedAt.Add(-24 * time.Hour),
+		NotAfter:              f.signedAt.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &f.caKey.PublicKey, f.caKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate() failed: %v", err)
+	}
+	f.caCert, _ = x509.ParseCertificate(der)
+
+	f.logKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	logPublic, _ := x509.MarshalPKIXPublicKey(&f.logKey.PublicKey)
+	logID := sha256.Sum256(logPublic)
+	f.logID = logID[:]
+	return f
+}
+
+// writeTrustedRoot writes a trusted_root.json naming the fixture's certificate
+// authority and transparency log, and returns its path
+func (f *sigstoreFixture) writeTrustedRoot(dir string) string {
+	f.t.Helper()
+	logPublic, _ := x509.MarshalPKIXPublicKey(&f.logKey.PublicKey)
+	start := f.signedAt.Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	root := map[string]any{
+		"mediaType": "application/vnd.dev.sigstore.trustedroot+json;version=0.1",
+		"tlogs": []any{map[string]any{
+			"baseUrl":       "https://rekor.example.com",
+			"hashAlgorithm": "SHA2_256",
+			"publicKey": map[string]any{
+				"rawBytes":   logPublic,
+				"keyDetails": "PKIX_ECDSA_P256_SHA_256",
+				"validFor":   map[string]any{"start": start},
+			},
+			"logId": map[string]any{"keyId": f.logID},
+		}},
+		"certificateAuthorities": []any{map[string]any{
+			"uri":       "https://fulcio.example.com",
+			"certChain": map[string]any{"certificates": []any{map[string]any{"rawBytes": f.caCert.Raw}}},
+			"validFor":  map[string]any{"start": start},
+		}},
+	}
+	data, _ := json.Marshal(root)
+	return writeFile(f.t, dir, "trusted_root.json", data)
+}
+
+// bundleOptions changes how sigstoreFixture.bundle signs, to produce bad bundles
+type bundleOptions struct {
+	identity  string
+	issuer    string
+	signed    []byte            // Data signed instead of the file
+	logged    []byte            // Data logged instead of the file
+	logKey    *ecdsa.PrivateKey // Key signing the log entry instead of the trusted log's
+	logHash   string            // Digest algorithm the log entry records, default sha256
+	certValid time.Duration     // How long the certificate is valid after signing, default 10 minutes
+	chain     bool              // Use x509CertificateChain instead of certificate
+}
+
+// bundle signs data with a new short-lived certificate, logs it and returns
+// the bundle JSON, as cosign sign-blob --bundle does
+func (f *sigstoreFixture) bundle(data []byte, opts bundleOptions) []byte {
+	f.t.Helper()
+	if opts.identity == "" {
+		opts.identity = testIdentity
+	}
+	if opts.issuer == "" {
+		opts.issuer = testIssuer
+	}
+	if opts.signed == nil {
+		opts.signed = data
+	}
+	if opts.logged == nil {
+		opts.logged = data
+	}
+	if opts.logKey == nil {
+		opts.logKey = f.logKey
+	}
+	if opts.logHash == "" {
+		opts.logHash = "sha256"
+	}
+	if opts.certValid == 0 {
+		opts.certValid = 10 * time.Minute
+	}
+
+	signingKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	uri, _ := url.Parse(opts.identity)
+	issuer, _ := asn1.MarshalWithParams(opts.issuer, "utf8")
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       f.signedAt.Add(-time.Minute),
+		NotAfter:        f.signedAt.Add(opts.certValid),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		URIs:            []*url.URL{uri},
+		ExtraExtensions: []pkix.Extension{{Id: oidIssuerV2, Value: issuer}},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, f.caCert, &signingKey.PublicKey, f.caKey)
+	if err != nil {
+		f.t.Fatalf("CreateCertificate() failed: %v", err)
+	}
+
+	digest := sha256.Sum256(data)
+	signedDigest := sha256.Sum256(opts.signed)
+	sig, _ := ecdsa.SignASN1(rand.Reader, signingKey, signedDigest[:])
+
+	loggedDigest := sha256.Sum256(opts.logged)
+	body, _ := json.Marshal(map[string]any{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]any{
+			"data": map[string]any{"hash": map[string]any{"algorithm": opts.logHash, "value": hex.EncodeToString(loggedDigest[:])}},
+			"signature": map[string]any{
+				"content":   sig,
+				"publicKey": map[string]any{"content": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})},
+			},
+		},
+	})
+	set := f.signEntry(opts.logKey, body, f.signedAt.Unix(), 42)
+
+	material := map[string]any{
+		"tlogEntries": []any{map[string]any{
+			"logIndex":          "42",
+			"logId":             map[string]any{"keyId": f.logID},
+			"kindVersion":       map[string]any{"kind": "hashedrekord", "version": "0.0.1"},
+			"integratedTime":    strconv.FormatInt(f.signedAt.Unix(), 10),
+			"inclusionPromise":  map[string]any{"signedEntryTimestamp": set},
+			"canonicalizedBody": body,
+		}},
+	}
+	if opts.chain {
+		material["x509CertificateChain"] = map[string]any{"certificates": []any{map[string]any{"rawBytes": certDER}}}
+	} else {
+		material["certificate"] = map[string]any{"rawBytes": certDER}
+	}
+	bundle, _ := json.Marshal(map[string]any{
+		"mediaType":            "application/vnd.dev.sigstore.bundle.v0.3+json",
+		"verificationMaterial": material,
+		"messageSignature": map[string]any{
+			"messageDigest": map[string]any{"algorithm": "SHA2_256", "digest": digest[:]},
+			"signature":     sig,
+		},
+	})
+	return bundle
+}
+
+// signEntry returns the signed entry timestamp a transparency log gives an entry
+func (f *sigstoreFixture) signEntry(key *ecdsa.PrivateKey, body []byte, integratedTime, logIndex int64) []byte {
+	payload := `{"body":"` + base64.StdEncoding.EncodeToString(body) + `","integratedTime":` + strconv.FormatInt(integratedTime, 10) +
+		`,"logID":"` + hex.EncodeToString(f.logID) + `","logIndex":` + strconv.FormatInt(logIndex, 10) + `}`
+	digest := sha256.Sum256([]byte(payload))
+	set, _ := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	return set
+}
+
+func TestSigstoreVerifier_VerifyFile(t *testing.T) {
+	data := []byte("release contents")
+	f := newSigstoreFixture(t)
+	otherLog, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	tests := []struct {
+		name           string
+		identity       string
+		identityRegexp string
+		bundle         []byte
+		wantErr        string
+	}{
+		{name: "exact identity", identity: testIdentity, bundle: f.bundle(data, bundleOptions{})},
+		{name: "identity regexp", identityRegexp: `https://github\.com/example/myapp/\.github/workflows/release\.yml@refs/tags/v.*`, bundle: f.bundle(data, bundleOptions{})},
+		{name: "identity regexp matches whole identity", identityRegexp: `https://github\.com/example/myapp`, bundle: f.bundle(data, bundleOptions{identity: "https://github.com/example/myapp-evil/.github/workflows/release.yml@refs/tags/v1"}), wantErr: "certificate identity"},
+		{name: "certificate chain", identity: testIdentity, bundle: f.bundle(data, bundleOptions{chain: true})},
+		{name: "wrong identity", identity: testIdentity, bundle: f.bundle(data, bundleOptions{identity: "https://github.com/attacker/myapp/.github/workflows/release.yml@refs/heads/main"}), wantErr: "certificate identity"},
+		{name: "identity regexp mismatch", identityRegexp: `^https://github\.com/example/other/`, bundle: f.bundle(data, bundleOptions{}), wantErr: "certificate identity"},
+		{name: "wrong issuer", identity: testIdentity, bundle: f.bundle(data, bundleOptions{issuer: "https://accounts.google.com"}), wantErr: "certificate issuer"},
+		{name: "bundle for other file", identity: testIdentity, bundle: f.bundle([]byte("tampered"), bundleOptions{}), wantErr: "different file"},
+		{name: "signature over other data", identity: testIdentity, bundle: f.bundle(data, bundleOptions{signed: []byte("tampered")}), wantErr: "invalid signature"},
+		{name: "logged entry for other data", identity: testIdentity, bundle: f.bundle(data, bundleOptions{logged: []byte("tampered")}), wantErr: "transparency log entry is for a different file"},
+		{name: "logged entry with other digest algorithm", identity: testIdentity, bundle: f.bundle(data, bundleOptions{logHash: "sha512"}), wantErr: "digest"},
+		{name: "untrusted log", identity: testIdentity, bundle: f.bundle(data, bundleOptions{logKey: otherLog}), wantErr: "signed entry timestamp"},
+		{name: "certificate expired when logged", identity: testIdentity, bundle: f.bundle(data, bundleOptions{certValid: -time.Second}), wantErr: "trusted certificate authority"},
+		{name: "attestation", identity: testIdentity, bundle: []byte(`{"mediaType":"application/vnd.dev.sigstore.bundle.v0.3+json","dsseEnvelope":{}}`), wantErr: "DSSE"},
+		{name: "not a bundle", identity: testIdentity, bundle: []byte(`{"mediaType":"text/plain"}`), wantErr: "media type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filePath := writeFile(t, dir, "myapp", data)
+			bundlePath := writeFile(t, dir, "myapp.sigstore.json", tt.bundle)
+
+			verifier, err := NewSigstoreVerifier(f.writeTrustedRoot(dir), tt.identity, tt.identityRegexp, testIssuer)
+			if err != nil {
+				t.Fatalf("NewSigstoreVerifier() unexpected error: %v", err)
+			}
+
+			identity, err := verifier.VerifyFile(filePath, bundlePath)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("VerifyFile() expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("VerifyFile() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyFile() unexpected error: %v", err)
+			}
+			if identity != testIdentity {
+				t.Errorf("VerifyFile() identity = %s, want %s", identity, testIdentity)
+			}
+		})
+	}
+}
+
+func TestSigstoreVerifier_UntrustedAuthority(t *testing.T) {
+	data := []byte("release contents")
+	trusted := newSigstoreFixture(t)
+	other := newSigstoreFixture(t)
+	other.logKey, other.logID = trusted.logKey, trusted.logID
+
+	dir := t.TempDir()
+	filePath := writeFile(t, dir, "myapp", data)
+	bundlePath := writeFile(t, dir, "myapp.sigstore.json", other.bundle(data, bundleOptions{}))
+
+	verifier, err := NewSigstoreVerifier(trusted.writeTrustedRoot(dir), testIdentity, "", testIssuer)
+	if err != nil {
+		t.Fatalf("NewSigstoreVerifier() unexpected error: %v", err)
+	}
+	if _, err := verifier.VerifyFile(filePath, bundlePath); err == nil {
+		t.Error("VerifyFile() expected error for certificate from untrusted authority, got nil")
+	}
+}
+
+func TestNewSigstoreVerifier_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	rootPath := newSigstoreFixture(t).writeTrustedRoot(dir)
+
+	tests := []struct {
+		name           string
+		rootPath       string
+		identity       string
+		identityRegexp string
+		issuer         string
+	}{
+		{name: "missing identity", rootPath: rootPath, issuer: testIssuer},
+		{name: "missing issuer", rootPath: rootPath, identity: testIdentity},
+		{name: "invalid regexp", rootPath: rootPath, identityRegexp: "(", issuer: testIssuer},
+		{name: "missing trusted root", rootPath: filepath.Join(dir, "missing.json"), identity: testIdentity, issuer: testIssuer},
+		{name: "empty trusted root", rootPath: writeFile(t, dir, "empty.json", []byte("{}")), identity: testIdentity, issuer: testIssuer},
+		{name: "not a trusted root", rootPath: writeFile(t, dir, "bogus.json", []byte("not json")), identity: testIdentity, issuer: testIssuer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSigstoreVerifier(tt.rootPath, tt.identity, tt.identityRegexp, tt.issuer); err == nil {
+				t.Error("NewSigstoreVerifier() expected error, got nil")
+			}
+		})
+	}
+}